@@ -1,18 +1,20 @@
 package main
 
 import (
-	"library-api/internal/app"
-	"library-api/pkg/config"
+	"context"
+	"library-api/internal/cli"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
-func init() {
-	err := config.Load()
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	err := cli.New(os.Stdin, os.Stdout, os.Stderr, os.Environ()).Run(ctx, os.Args[1:])
+	stop()
 	if err != nil {
-		log.Fatalln("failed to load configs:", err)
+		log.Fatalln(err)
 	}
 }
-
-func main() {
-	app.Start()
-}
@@ -1,18 +1,33 @@
 package app
 
 import (
+	"context"
+	"library-api/internal/audit"
+	"library-api/internal/auth"
 	"library-api/internal/handler"
+	"library-api/internal/idempotency"
+	"library-api/internal/notify"
+	"library-api/internal/scheduler"
+	"library-api/internal/service"
 	"library-api/internal/store"
-	"library-api/pkg/config"
 	"library-api/pkg/db"
+	"library-api/pkg/db/migrate"
+	"library-api/pkg/db/migrations"
+	"library-api/pkg/tracing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/hashicorp/go-hclog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel"
 )
 
-func (s *server) generate() error {
-	s.Handler = logger.New()
+func (s *server) generate(ctx context.Context) error {
+	s.Handler = logger.New(logger.Config{
+		Format:     "${time} | ${status} | ${latency} | ${ip} | ${method} | ${path} | ${trace_id} | ${error}\n",
+		CustomTags: map[string]logger.LogFunc{"trace_id": traceIDTag},
+	})
 
 	s.app = fiber.New(
 		fiber.Config{
@@ -22,36 +37,132 @@ func (s *server) generate() error {
 
 	s.logger = hclog.New(&hclog.LoggerOptions{
 		JSONEscapeDisabled: true,
-		Level:              hclog.Debug,
+		Level:              hclog.LevelFromString(s.config.LogLevel),
 		JSONFormat:         true,
 	})
 
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		s.logger.Warn("tracing failed", "error", err.Error())
+	}))
+
+	stopTracing, err := tracing.Setup(ctx, s.config.Tracing())
+	if err != nil {
+		s.logger.Error("tracing setup failed", "error", err.Error())
+		return err
+	}
+	s.stopTracing = stopTracing
+
+	if s.config.TracingEndpoint != "" {
+		s.logger.Info("exporting traces", "endpoint", s.config.TracingEndpoint, "sample_ratio", s.config.TracingSampleRatio)
+	}
+
+	verifier, err := auth.NewVerifier(s.config.JWTSecret, s.config.JWTPublicKey,
+		s.config.JWTIssuer, s.config.JWTAudience)
+	if err != nil {
+		s.logger.Error("jwt verifier configuration failed", "error", err.Error())
+		return err
+	}
+	s.verifier = verifier
+
+	signer, err := auth.NewSigner(s.config.JWTSecret, s.config.JWTPrivateKey,
+		s.config.JWTIssuer, s.config.JWTAudience, s.config.AccessTokenTTL)
+	if err != nil {
+		s.logger.Error("jwt signer configuration failed", "error", err.Error())
+		return err
+	}
+
 	s.useMiddleware()
 
-	postgres, err := db.Connect(config.Get().DbConn)
+	postgres, err := db.Connect(ctx, s.config.DbConn, s.config.Database(), s.logger)
 	if err != nil {
 		s.logger.Error("database connection failed", "error", err.Error())
 		return err
 	}
 	s.postgres = postgres
 
+	migrator, err := migrate.New(s.postgres, migrations.FS, s.logger)
+	if err != nil {
+		s.logger.Error("reading migrations failed", "error", err.Error())
+		return err
+	}
+	s.migrator = migrator
+
+	err = prometheus.Register(collectors.NewDBStatsCollector(s.postgres, "library-db"))
+	if err != nil {
+		s.logger.Error("database metrics registration failed", "error", err.Error())
+		return err
+	}
+
+	auditStore := store.NewAuditStore(s.postgres, s.logger)
+	auditor := audit.NewAuditor(auditStore, s.logger)
+	auditHandler := handler.NewAuditHandler(auditStore, s.logger)
+	s.auditHandler = auditHandler
+
+	transactor := store.NewTransactor(s.postgres, s.logger)
+
 	authorStore := store.NewAuthorStore(s.postgres, s.logger)
-	authorHandler := handler.NewAuthorHandler(authorStore, s.logger)
+	bookStore := store.NewBookStore(s.postgres, s.logger)
+	catalog := service.NewCatalogService(authorStore, bookStore, transactor, auditor, s.logger)
+
+	authorHandler := handler.NewAuthorHandler(catalog, s.logger)
 	s.authorHandler = authorHandler
 
-	bookStore := store.NewBookStore(s.postgres, s.logger)
-	bookHandler := handler.NewBookHandler(bookStore, s.logger)
+	bookHandler := handler.NewBookHandler(catalog, s.logger)
 	s.bookHandler = bookHandler
 
+	sruHandler := handler.NewSRUHandler(bookStore, s.logger)
+	s.sruHandler = sruHandler
+
 	memberStore := store.NewMemberStore(s.postgres, s.logger)
-	memberHandler := handler.NewMemberHandler(memberStore, s.logger)
+	membership := service.NewMembershipService(memberStore, transactor, auditor, s.logger)
+	memberHandler := handler.NewMemberHandler(membership, s.logger)
 	s.memberHandler = memberHandler
+	s.membership = membership
 
 	borrowedStore := store.NewBorrowedStore(s.postgres, s.logger)
-	borrowedHandler := handler.NewBorrowedHandler(borrowedStore, s.logger)
+	circulation := service.NewCirculationService(borrowedStore, transactor, auditor, service.LoanPolicy{MaxLoans: s.config.MaxLoans}, s.logger)
+	borrowedHandler := handler.NewBorrowedHandler(circulation, s.logger)
 	s.borrowedHandler = borrowedHandler
+	s.circulation = circulation
+
+	apiKeyStore := store.NewAPIKeyStore(s.postgres, s.logger)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyStore, s.logger)
+	s.apiKeyStore = apiKeyStore
+	s.apiKeyHandler = apiKeyHandler
+
+	accountStore := store.NewAccountStore(s.postgres, s.logger)
+	var notifier notify.Notifier = notify.NewLogNotifier(s.logger)
+	if s.config.NotifyWebhookURL != "" {
+		notifier = notify.NewWebhookNotifier(s.config.NotifyWebhookURL, s.logger)
+	} else {
+		s.logger.Warn("NOTIFY_WEBHOOK_URL is not set, password reset tokens will not be delivered")
+	}
+
+	authHandler := handler.NewAuthHandler(accountStore, membership, signer, notifier,
+		s.config.RefreshTokenTTL, s.config.PasswordResetTTL, s.logger)
+	s.authHandler = authHandler
 
+	purgeStore := store.NewPurgeStore(s.postgres, s.logger)
+	retention := service.NewRetentionService(purgeStore, transactor, auditor, s.logger)
+	purgeHandler := handler.NewPurgeHandler(retention, s.config.PurgeRetention, s.logger)
+	s.purgeHandler = purgeHandler
+
+	idempotencyStore := store.NewIdempotencyStore(s.postgres, s.logger)
+	s.idempotency = idempotency.New(idempotencyStore, s.config.IdempotencyTTL, s.logger)
+	s.idempotencyKeys = idempotencyStore
+
+	s.apply(s.config)
 	s.router()
 
+	s.scheduler = scheduler.New(s.logger, scheduler.Task{
+		Name:     "membership-sweep",
+		Interval: s.config.MembershipSweep,
+		Run:      s.sweepMemberships,
+	}, scheduler.Task{
+		Name:     "idempotency-key-sweep",
+		Interval: s.config.IdempotencySweep,
+		Run:      s.sweepIdempotencyKeys,
+	})
+
 	return nil
 }
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var errShuttingDown = errors.New("server is shutting down")
+
+type check struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+type checkResult struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"-"`
+}
+
+const (
+	statusOK          = "ok"
+	statusFailed      = "failed"
+	statusUnavailable = "unavailable"
+)
+
+// livez only shows that the process still serves requests. It checks nothing
+// outside the process, so a database outage never gets the pod restarted.
+func (s *server) livez(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": statusOK})
+}
+
+// readyz reports whether the server should receive traffic: it is not
+// shutting down, the database answers and its schema is migrated. With
+// ?verbose every check is listed with its latency.
+func (s *server) readyz(c *fiber.Ctx) error {
+	status, results := s.check(c.UserContext())
+
+	if c.Context().QueryArgs().Has("verbose") {
+		return c.Status(statusCode(status)).JSON(fiber.Map{"status": status, "checks": results})
+	}
+
+	return c.Status(statusCode(status)).JSON(fiber.Map{"status": status})
+}
+
+// healthz runs the readiness checks and always lists each of them with its
+// latency. It is meant for people and dashboards, not for probes.
+func (s *server) healthz(c *fiber.Ctx) error {
+	status, results := s.check(c.UserContext())
+	return c.Status(statusCode(status)).JSON(fiber.Map{"status": status, "checks": results})
+}
+
+// check runs the readiness checks within HEALTH_CHECK_TIMEOUT, so a hanging
+// database fails them before the probe gives up. Errors are logged but never
+// returned, as the health endpoints are public. Probes poll every few seconds,
+// so a failure is only logged when the server stops being ready, and a
+// recovery when it becomes ready again.
+func (s *server) check(ctx context.Context) (string, []checkResult) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.HealthCheckTimeout)
+	defer cancel()
+
+	checks := []check{
+		{name: "shutdown", run: func(context.Context) error {
+			if s.shuttingDown.Load() {
+				return errShuttingDown
+			}
+
+			return nil
+		}},
+		{name: "database", run: s.postgres.PingContext},
+		{name: "migrations", run: s.migrator.Check},
+	}
+
+	status := statusOK
+	results := make([]checkResult, 0, len(checks))
+	for _, check := range checks {
+		start := time.Now()
+		err := check.run(ctx)
+		result := checkResult{Name: check.name, Status: statusOK, Latency: time.Since(start).String()}
+		if err != nil {
+			result.Status = statusFailed
+			result.Error = err.Error()
+			status = statusUnavailable
+		}
+
+		results = append(results, result)
+	}
+
+	unready := status != statusOK
+	if s.unready.Swap(unready) != unready {
+		if unready {
+			for _, result := range results {
+				if result.Status == statusFailed {
+					s.logger.Warn("readiness check failed", "check", result.Name, "error", result.Error)
+				}
+			}
+		} else {
+			s.logger.Info("server is ready again")
+		}
+	}
+
+	return status, results
+}
+
+func statusCode(status string) int {
+	if status != statusOK {
+		return fiber.StatusServiceUnavailable
+	}
+
+	return fiber.StatusOK
+}
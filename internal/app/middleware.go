@@ -1,41 +1,224 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"library-api/internal/audit"
+	"library-api/internal/auth"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+	"net/http"
+
 	"github.com/ansrivas/fiberprometheus/v2"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func (s *server) useMiddleware() {
-	s.app.Use(cors.New(
-		cors.Config{
-			AllowMethods: "GET,POST,DELETE,PATCH",
-			AllowHeaders: "Origin, X-Requested-With, Content-Type, Accept, Authorization",
-			MaxAge:       120,
-		}),
-	)
+	s.app.Use(requestid.New())
+	s.app.Use(s.trace)
+
+	s.app.Use(s.allowOrigins)
 
 	prometheus := fiberprometheus.New("library-api")
 	prometheus.RegisterAt(s.app, "/metrics")
 	s.app.Use(prometheus.Middleware)
 
 	s.app.Use(s.selectiveLogging)
+	s.app.Use(s.rateLimit)
+	s.app.Use(s.timeout)
+	s.app.Use(s.authenticate)
+	s.app.Use(audit.Middleware)
+}
+
+// allowOrigins and rateLimit run the handlers built from the current
+// configuration, which a reload swaps out.
+func (s *server) allowOrigins(c *fiber.Ctx) error {
+	return (*s.cors.Load())(c)
+}
+
+func (s *server) rateLimit(c *fiber.Ctx) error {
+	return (*s.limiter.Load())(c)
 }
 
 func (s *server) selectiveLogging(c *fiber.Ctx) error {
-	if c.Path() == "/healthz" || c.Path() == "/metrics" {
+	if operational(c.Path()) {
 		return c.Next()
 	}
 
 	return s.Handler(c)
 }
 
-func (s *server) healthcheck(c *fiber.Ctx) error {
-	err := s.postgres.Ping()
+// operational reports whether path is a probe or metrics endpoint, which are
+// polled by the platform and neither logged, authenticated nor rate limited.
+func operational(path string) bool {
+	switch path {
+	case "/livez", "/readyz", "/healthz", "/metrics":
+		return true
+	}
+
+	return false
+}
+
+// trace starts a server span for the request, continuing the caller's trace
+// when the request carries a traceparent header. Store queries made under the
+// request context become its children.
+func (s *server) trace(c *fiber.Ctx) error {
+	if operational(c.Path()) {
+		return c.Next()
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), propagation.HeaderCarrier(http.Header(c.GetReqHeaders())))
+	ctx, span := otel.Tracer(tracing.ServiceName).Start(ctx, c.Method(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(c.Method()),
+			semconv.URLPath(c.Path()),
+			semconv.ClientAddress(c.IP())))
+	defer span.End()
+
+	c.SetUserContext(ctx)
+
+	err := c.Next()
+
+	status := c.Response().StatusCode()
+	var fiberError *fiber.Error
+	if errors.As(err, &fiberError) {
+		status = fiberError.Code
+	} else if err != nil {
+		status = fiber.StatusInternalServerError
+	}
+
+	span.SetName(c.Method() + " " + c.Route().Path)
+	span.SetAttributes(semconv.HTTPRoute(c.Route().Path), semconv.HTTPResponseStatusCode(status))
+	if status >= fiber.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+
+	return err
+}
+
+// traceIDTag writes the trace ID of the request to the access log, so that a
+// log line leads to its trace. Requests without a span get a dash.
+func traceIDTag(output logger.Buffer, c *fiber.Ctx, _ *logger.Data, _ string) (int, error) {
+	traceID := tracing.TraceID(c.UserContext())
+	if traceID == "" {
+		traceID = "-"
+	}
+
+	return output.WriteString(traceID)
+}
+
+// timeout puts a deadline on the request context, which every store query
+// runs under. A request that failed because the deadline passed is answered
+// with 504 instead of the handler's generic server error. The configuration
+// rejects a timeout that is not positive; should one get here anyway, no
+// deadline is set rather than failing every request.
+func (s *server) timeout(c *fiber.Ctx) error {
+	timeout := s.runtime.Load().RequestTimeout
+	if timeout <= 0 {
+		return c.Next()
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+	defer cancel()
+
+	c.SetUserContext(ctx)
+
+	err := c.Next()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && (err != nil || c.Response().StatusCode() >= fiber.StatusInternalServerError) {
+		tracing.Logger(c.UserContext(), s.logger).Info("request timed out", "path", c.Path(), "timeout", timeout.String())
+		return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{"error": "request timed out"})
+	}
+
+	return err
+}
+
+func (s *server) authenticate(c *fiber.Ctx) error {
+	if operational(c.Path()) {
+		return c.Next()
+	}
+
+	if key := c.Get(auth.APIKeyHeader); key != "" {
+		return s.authenticateAPIKey(c, key)
+	}
+
+	header := c.Get(fiber.HeaderAuthorization)
+	if header == "" {
+		return c.Next()
+	}
+
+	token, err := auth.BearerToken(header)
 	if err != nil {
-		s.logger.Error("error pinging database", "error", err.Error())
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "error pinging database"})
+		return unauthorized(c)
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "OK"})
+	claims, err := s.verifier.Verify(token)
+	if err != nil {
+		tracing.Logger(c.UserContext(), s.logger).Info("jwt verification failed", "error", err.Error())
+		return unauthorized(c)
+	}
+
+	auth.SetClaims(c, claims)
+
+	return c.Next()
+}
+
+func (s *server) authenticateAPIKey(c *fiber.Ctx, key string) error {
+	apiKey, err := s.apiKeyStore.Authenticate(c.UserContext(), auth.HashToken(key))
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			tracing.Logger(c.UserContext(), s.logger).Info("unknown, expired or revoked api key", "path", c.Path())
+			return unauthorized(c)
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "server error"})
+	}
+
+	claims := &auth.Claims{Scopes: make([]auth.Permission, 0, len(apiKey.Scopes))}
+	claims.Subject = "apikey:" + apiKey.ID
+	for _, scope := range apiKey.Scopes {
+		claims.Scopes = append(claims.Scopes, auth.Permission(scope))
+	}
+
+	auth.SetClaims(c, claims)
+
+	return c.Next()
+}
+
+func (s *server) authorize(r route) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := auth.ClaimsFrom(c)
+		if !ok {
+			return unauthorized(c)
+		}
+
+		if claims.Can(r.permission) || (r.owned && claims.Owns(c.Params("id"))) {
+			return c.Next()
+		}
+
+		tracing.Logger(c.UserContext(), s.logger).Info("access denied", "subject", claims.Subject, "role", claims.Role, "path", c.Path())
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
+	}
+}
+
+// requireIfMatch rejects writes that do not name the version they are based
+// on, so a client cannot overwrite a record it has not seen.
+func requireIfMatch(c *fiber.Ctx) error {
+	if c.Get(fiber.HeaderIfMatch) == "" {
+		return c.Status(fiber.StatusPreconditionRequired).JSON(fiber.Map{"error": "If-Match header is required"})
+	}
+
+	return c.Next()
+}
+
+func unauthorized(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
 }
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"library-api/internal/idempotency"
+	"library-api/internal/service"
+	"library-api/pkg/config"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/hashicorp/go-hclog"
+)
+
+// reload loads the configuration from its sources again and applies the
+// settings that can change while the server runs. An invalid configuration is
+// rejected as a whole and the current one kept.
+func (s *server) reload() {
+	s.logger.Info("reloading configuration")
+
+	next, err := config.Load(s.sources)
+	if err != nil {
+		s.logger.Error("configuration reload rejected, keeping the current configuration", "error", err.Error())
+		return
+	}
+
+	current := s.runtime.Load()
+	changes := current.Diff(next)
+	reloaded := 0
+	for _, change := range changes {
+		if !change.Reloadable {
+			s.logger.Warn("setting change needs a restart", "setting", change.Key, "from", change.From, "to", change.To)
+			continue
+		}
+
+		s.logger.Info("setting changed", "setting", change.Key, "from", change.From, "to", change.To)
+		reloaded++
+	}
+
+	s.apply(current.Reload(next))
+	s.logger.Info("configuration reloaded", "changed", reloaded)
+}
+
+// apply makes the reloadable settings of c take effect. The CORS and rate
+// limiting handlers are only rebuilt when their settings change, since a new
+// limiter starts counting from zero.
+func (s *server) apply(c *config.Config) {
+	s.logger.SetLevel(hclog.LevelFromString(c.LogLevel))
+
+	current := s.runtime.Load()
+	if current == nil || current.CORSAllowOrigins != c.CORSAllowOrigins {
+		handler := cors.New(corsConfig(c.CORSAllowOrigins))
+		s.cors.Store(&handler)
+	}
+
+	if current == nil || current.RateLimit != c.RateLimit || current.RateLimitWindow != c.RateLimitWindow {
+		handler := rateLimiter(c.RateLimit, c.RateLimitWindow)
+		s.limiter.Store(&handler)
+	}
+
+	s.circulation.SetPolicy(service.LoanPolicy{MaxLoans: c.MaxLoans})
+	s.runtime.Store(c)
+}
+
+func corsConfig(origins string) cors.Config {
+	return cors.Config{
+		AllowOrigins:  origins,
+		AllowMethods:  "GET,POST,PUT,DELETE,PATCH",
+		AllowHeaders:  "Origin, X-Requested-With, Content-Type, Accept, Authorization, X-API-Key, X-Request-ID, If-Match, If-None-Match, " + idempotency.Header,
+		ExposeHeaders: "X-Request-ID, ETag, Location, " + idempotency.ReplayedHeader,
+		MaxAge:        120,
+	}
+}
+
+// rateLimiter allows max requests per client address in every window. A max
+// of 0 turns rate limiting off.
+func rateLimiter(max int, window time.Duration) fiber.Handler {
+	if max == 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: window,
+		Next: func(c *fiber.Ctx) bool {
+			return operational(c.Path())
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "too many requests"})
+		},
+	})
+}
@@ -1,26 +1,91 @@
 package app
 
+import (
+	"library-api/internal/auth"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type route struct {
+	method      string
+	path        string
+	handler     fiber.Handler
+	permission  auth.Permission
+	owned       bool
+	conditional bool
+	idempotent  bool
+}
+
+func (s *server) routes() []route {
+	return []route{
+		{method: fiber.MethodGet, path: "/livez", handler: s.livez},
+		{method: fiber.MethodGet, path: "/readyz", handler: s.readyz},
+		{method: fiber.MethodGet, path: "/healthz", handler: s.healthz},
+
+		{method: fiber.MethodPost, path: "/auth/login", handler: s.authHandler.Login},
+		{method: fiber.MethodPost, path: "/auth/refresh", handler: s.authHandler.Refresh},
+		{method: fiber.MethodPost, path: "/auth/logout", handler: s.authHandler.Logout},
+		{method: fiber.MethodPost, path: "/auth/password/forgot", handler: s.authHandler.ForgotPassword},
+		{method: fiber.MethodPost, path: "/auth/password/reset", handler: s.authHandler.ResetPassword},
+
+		{method: fiber.MethodGet, path: "/authors", handler: s.authorHandler.Get},
+		{method: fiber.MethodPost, path: "/author", handler: s.authorHandler.Create, permission: auth.PermissionCatalogWrite, idempotent: true},
+		{method: fiber.MethodGet, path: "/author/:id", handler: s.authorHandler.GetByID},
+		{method: fiber.MethodPatch, path: "/author/:id", handler: s.authorHandler.Update, permission: auth.PermissionCatalogWrite, conditional: true},
+		{method: fiber.MethodDelete, path: "/author/:id", handler: s.authorHandler.Delete, permission: auth.PermissionCatalogWrite, conditional: true},
+		{method: fiber.MethodPost, path: "/author/:id/restore", handler: s.authorHandler.Restore, permission: auth.PermissionCatalogWrite},
+		{method: fiber.MethodGet, path: "/author/:id/books", handler: s.authorHandler.GetAuthorBooks},
+
+		{method: fiber.MethodGet, path: "/books", handler: s.bookHandler.Get},
+		{method: fiber.MethodPost, path: "/book", handler: s.bookHandler.Create, permission: auth.PermissionCatalogWrite, idempotent: true},
+		{method: fiber.MethodGet, path: "/book/:id", handler: s.bookHandler.GetByID},
+		{method: fiber.MethodPatch, path: "/book/:id", handler: s.bookHandler.Update, permission: auth.PermissionCatalogWrite, conditional: true},
+		{method: fiber.MethodDelete, path: "/book/:id", handler: s.bookHandler.Delete, permission: auth.PermissionCatalogWrite, conditional: true},
+		{method: fiber.MethodPost, path: "/book/:id/restore", handler: s.bookHandler.Restore, permission: auth.PermissionCatalogWrite},
+		{method: fiber.MethodGet, path: "/book/:id/cite", handler: s.bookHandler.Cite},
+		{method: fiber.MethodPost, path: "/books/cite", handler: s.bookHandler.CiteList},
+
+		{method: fiber.MethodGet, path: "/members", handler: s.memberHandler.Get, permission: auth.PermissionMembersRead},
+		{method: fiber.MethodPost, path: "/member", handler: s.memberHandler.Create, permission: auth.PermissionMembersWrite, idempotent: true},
+		{method: fiber.MethodGet, path: "/member/:id", handler: s.memberHandler.GetByID, permission: auth.PermissionMembersRead, owned: true},
+		{method: fiber.MethodPatch, path: "/member/:id", handler: s.memberHandler.Update, permission: auth.PermissionMembersWrite, conditional: true},
+		{method: fiber.MethodDelete, path: "/member/:id", handler: s.memberHandler.Delete, permission: auth.PermissionMembersWrite, conditional: true},
+		{method: fiber.MethodPost, path: "/member/:id/restore", handler: s.memberHandler.Restore, permission: auth.PermissionMembersWrite},
+		{method: fiber.MethodPost, path: "/member/:id/suspend", handler: s.memberHandler.Suspend, permission: auth.PermissionMembersWrite},
+		{method: fiber.MethodPost, path: "/member/:id/reinstate", handler: s.memberHandler.Reinstate, permission: auth.PermissionMembersWrite},
+		{method: fiber.MethodPut, path: "/member/:id/credentials", handler: s.authHandler.SetCredentials, permission: auth.PermissionMembersWrite},
+
+		{method: fiber.MethodGet, path: "/member/:id/borrowed", handler: s.borrowedHandler.Get, permission: auth.PermissionCirculationRead, owned: true},
+		{method: fiber.MethodPost, path: "/member/borrowed", handler: s.borrowedHandler.Create, permission: auth.PermissionCirculationWrite},
+		{method: fiber.MethodDelete, path: "/member/:id/borrowed/:book_id", handler: s.borrowedHandler.Delete, permission: auth.PermissionCirculationWrite},
+		{method: fiber.MethodDelete, path: "/member/:id/borrowed", handler: s.borrowedHandler.DeleteList, permission: auth.PermissionCirculationWrite},
+
+		{method: fiber.MethodGet, path: "/sru", handler: s.sruHandler.Handle},
+
+		{method: fiber.MethodGet, path: "/audit", handler: s.auditHandler.Get, permission: auth.PermissionAuditRead},
+		{method: fiber.MethodPost, path: "/purge", handler: s.purgeHandler.Purge, permission: auth.PermissionRecordsPurge},
+
+		{method: fiber.MethodGet, path: "/api-keys", handler: s.apiKeyHandler.Get, permission: auth.PermissionAPIKeysManage},
+		{method: fiber.MethodPost, path: "/api-key", handler: s.apiKeyHandler.Create, permission: auth.PermissionAPIKeysManage},
+		{method: fiber.MethodDelete, path: "/api-key/:id", handler: s.apiKeyHandler.Revoke, permission: auth.PermissionAPIKeysManage},
+	}
+}
+
 func (s *server) router() {
-	s.app.Get("/healthz", s.healthcheck)
-
-	s.app.Get("/authors", s.authorHandler.Get)
-	s.app.Post("/author", s.authorHandler.Create)
-	s.app.Patch("/author/:id", s.authorHandler.Update)
-	s.app.Delete("/author/:id", s.authorHandler.Delete)
-	s.app.Get("/author/:id/books", s.authorHandler.GetAuthorBooks)
-
-	s.app.Get("/books", s.bookHandler.Get)
-	s.app.Post("/book", s.bookHandler.Create)
-	s.app.Patch("/book/:id", s.bookHandler.Update)
-	s.app.Delete("/book/:id", s.bookHandler.Delete)
-
-	s.app.Get("/members", s.memberHandler.Get)
-	s.app.Post("/member", s.memberHandler.Create)
-	s.app.Patch("/member/:id", s.memberHandler.Update)
-	s.app.Delete("/member/:id", s.memberHandler.Delete)
-
-	s.app.Get("/member/:id/borrowed", s.borrowedHandler.Get)
-	s.app.Post("/member/borrowed", s.borrowedHandler.Create)
-	s.app.Delete("/member/:id/borrowed/:book_id", s.borrowedHandler.Delete)
-	s.app.Delete("/member/:id/borrowed", s.borrowedHandler.DeleteList)
+	for _, r := range s.routes() {
+		var handlers []fiber.Handler
+		if r.permission != "" {
+			handlers = append(handlers, s.authorize(r))
+		}
+
+		if r.conditional && s.config.RequireIfMatch {
+			handlers = append(handlers, requireIfMatch)
+		}
+
+		if r.idempotent {
+			handlers = append(handlers, s.idempotency.Handle)
+		}
+
+		s.app.Add(r.method, r.path, append(handlers, r.handler)...)
+	}
 }
@@ -1,10 +1,18 @@
 package app
 
 import (
+	"context"
 	"database/sql"
+	"library-api/internal/auth"
 	"library-api/internal/handler"
+	"library-api/internal/idempotency"
+	"library-api/internal/scheduler"
+	"library-api/internal/service"
+	"library-api/internal/store"
 	"library-api/pkg/config"
+	"library-api/pkg/db/migrate"
 	"os"
+	"sync/atomic"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/hashicorp/go-hclog"
@@ -12,33 +20,60 @@ import (
 
 type server struct {
 	fiber.Handler
+	config          *config.Config
+	sources         config.Sources
+	runtime         atomic.Pointer[config.Config]
+	cors            atomic.Pointer[fiber.Handler]
+	limiter         atomic.Pointer[fiber.Handler]
 	app             *fiber.App
 	logger          hclog.Logger
 	authorHandler   *handler.AuthorHandler
 	bookHandler     *handler.BookHandler
 	memberHandler   *handler.MemberHandler
 	borrowedHandler *handler.BorrowedHandler
+	membership      *service.MembershipService
+	circulation     *service.CirculationService
+	sruHandler      *handler.SRUHandler
+	apiKeyHandler   *handler.APIKeyHandler
+	apiKeyStore     *store.APIKeyStore
+	authHandler     *handler.AuthHandler
+	auditHandler    *handler.AuditHandler
+	purgeHandler    *handler.PurgeHandler
+	idempotency     *idempotency.Middleware
+	idempotencyKeys *store.IdempotencyStore
 	postgres        *sql.DB
+	migrator        *migrate.Migrator
+	shuttingDown    atomic.Bool
+	unready         atomic.Bool
+	verifier        *auth.Verifier
+	scheduler       *scheduler.Scheduler
+	stopTracing     func(ctx context.Context) error
 }
 
-func Start() {
-	s := new(server)
+// Start runs the server until it receives SIGINT or SIGTERM. ctx only bounds
+// the startup, while the database may still be coming up. On SIGHUP the
+// configuration is loaded from sources again and its reloadable settings
+// applied. The error is ErrShutdownTimeout if requests outlived the shutdown
+// deadline.
+func Start(ctx context.Context, config *config.Config, sources config.Sources) error {
+	s := &server{config: config, sources: sources}
 
-	err := s.generate()
+	err := s.generate(ctx)
 	if err != nil {
-		s.logger.Error("error generating server", "error", err)
-		os.Exit(1)
+		return err
 	}
 
+	s.scheduler.Start()
+
 	go func() {
 		s.logger.Info("starting server...")
 
-		err = s.app.Listen(":" + config.Get().Port)
+		err := s.app.Listen(":" + s.config.Port)
 		if err != nil {
 			s.logger.Error("error starting server", "error", err)
 			os.Exit(1)
 		}
 	}()
 
-	s.gracefulShutdown()
+	return s.gracefulShutdown()
 }
@@ -1,29 +1,66 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
-func (s *server) gracefulShutdown() {
-	exit := make(chan os.Signal, 1)
-	signal.Notify(exit, os.Interrupt, syscall.SIGTERM)
+var ErrShutdownTimeout = errors.New("shutdown deadline exceeded")
 
-	<-exit
-	s.logger.Info("graceful shutdown started")
+// gracefulShutdown reloads the configuration on SIGHUP until SIGINT or
+// SIGTERM arrives. It then fails readiness for the drain delay, so that load
+// balancers stop sending traffic, gives in-flight requests until the shutdown
+// timeout, and finally stops the background tasks, closes the database and
+// flushes the remaining spans. It returns ErrShutdownTimeout when requests
+// were still running at the deadline.
+func (s *server) gracefulShutdown() error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
 
-	err := s.app.Shutdown()
-	if err != nil {
+	for sig := range signals {
+		if sig != syscall.SIGHUP {
+			break
+		}
+
+		s.reload()
+	}
+	s.logger.Info("graceful shutdown started", "drain_delay", s.config.ShutdownDrainDelay.String(),
+		"timeout", s.config.ShutdownTimeout.String())
+	s.shuttingDown.Store(true)
+
+	time.Sleep(s.config.ShutdownDrainDelay)
+
+	var shutdownErr error
+	err := s.app.ShutdownWithTimeout(s.config.ShutdownTimeout)
+	if errors.Is(err, context.DeadlineExceeded) {
+		s.logger.Error("requests still running at the shutdown deadline", "timeout", s.config.ShutdownTimeout.String())
+		shutdownErr = fmt.Errorf("%w: requests still running after %s", ErrShutdownTimeout, s.config.ShutdownTimeout)
+	} else if err != nil {
 		s.logger.Error("error shutting down", "err", err)
 	}
 
+	s.scheduler.Stop()
+
 	err = s.postgres.Close()
 	if err != nil {
 		s.logger.Error("error closing postgres", "err", err)
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+
+	err = s.stopTracing(ctx)
+	if err != nil {
+		s.logger.Error("error flushing traces", "err", err)
+	}
+
 	s.logger.Info("server stopped")
 
-	return
+	return shutdownErr
 }
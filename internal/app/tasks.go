@@ -0,0 +1,36 @@
+package app
+
+import (
+	"context"
+	"library-api/internal/audit"
+)
+
+// schedulerActor is recorded in the audit log for changes made by scheduled
+// tasks.
+const schedulerActor = "scheduler"
+
+func (s *server) sweepMemberships(ctx context.Context) error {
+	expired, lifted, err := s.membership.SweepStatuses(audit.WithOrigin(ctx, schedulerActor, ""))
+	if err != nil {
+		return err
+	}
+
+	if expired > 0 || lifted > 0 {
+		s.logger.Info("membership statuses updated", "expired", expired, "suspensions_lifted", lifted)
+	}
+
+	return nil
+}
+
+func (s *server) sweepIdempotencyKeys(ctx context.Context) error {
+	deleted, err := s.idempotencyKeys.DeleteExpired(ctx)
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		s.logger.Info("expired idempotency keys deleted", "count", deleted)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"library-api/internal/auth"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+	"reflect"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hashicorp/go-hclog"
+)
+
+const anonymousActor = "anonymous"
+
+type store interface {
+	Create(ctx context.Context, entry *model.AuditEntry) error
+}
+
+type Auditor struct {
+	store  store
+	logger hclog.Logger
+}
+
+func NewAuditor(store store, logger hclog.Logger) *Auditor {
+	return &Auditor{
+		store:  store,
+		logger: logger,
+	}
+}
+
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type originKey struct{}
+
+// origin is who made the changes under a context and in which request.
+type origin struct {
+	actor     string
+	requestID string
+}
+
+// WithOrigin returns a copy of ctx that attributes the changes made under it
+// to actor. The request ID may be empty for changes made outside a request.
+func WithOrigin(ctx context.Context, actor string, requestID string) context.Context {
+	return context.WithValue(ctx, originKey{}, origin{actor: actor, requestID: requestID})
+}
+
+// Middleware attributes the changes a request makes to its authenticated
+// subject. It has to run after authentication.
+func Middleware(c *fiber.Ctx) error {
+	c.SetUserContext(WithOrigin(c.UserContext(), Actor(c), c.GetRespHeader(fiber.HeaderXRequestID)))
+	return c.Next()
+}
+
+// Record writes an audit entry for a change. It is meant to be called with
+// the context of the transaction that applies the change, so that the entry
+// is committed or rolled back together with it; an error should fail the
+// change.
+func (a *Auditor) Record(ctx context.Context, action string, entityType string, entityID string, before any, after any) error {
+	changes, err := Diff(before, after)
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("audit diff failed", "entity_type", entityType, "entity_id", entityID, "error", err.Error())
+		return err
+	}
+
+	o, ok := ctx.Value(originKey{}).(origin)
+	if !ok || o.actor == "" {
+		o.actor = anonymousActor
+	}
+
+	entry := model.AuditEntry{
+		Actor:      o.actor,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+		RequestID:  o.requestID,
+	}
+
+	return a.store.Create(ctx, &entry)
+}
+
+func Actor(c *fiber.Ctx) string {
+	claims, ok := auth.ClaimsFrom(c)
+	if !ok || claims.Subject == "" {
+		return anonymousActor
+	}
+
+	return claims.Subject
+}
+
+// Diff compares the JSON representations of two snapshots field by field and
+// returns only the fields that differ. A nil snapshot stands for an entity
+// that does not exist yet or no longer exists.
+func Diff(before any, after any) (json.RawMessage, error) {
+	beforeFields, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterFields, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for name, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[name]) {
+			changes[name] = Change{Before: value, After: afterFields[name]}
+		}
+	}
+
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = Change{After: value}
+		}
+	}
+
+	return json.Marshal(changes)
+}
+
+func fields(snapshot any) (map[string]any, error) {
+	result := make(map[string]any)
+	if snapshot == nil || reflect.ValueOf(snapshot).Kind() == reflect.Pointer && reflect.ValueOf(snapshot).IsNil() {
+		return result, nil
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
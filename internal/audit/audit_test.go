@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"library-api/internal/auth"
+	"library-api/internal/model"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStore struct {
+	entries []model.AuditEntry
+	err     error
+}
+
+func (s *testStore) Create(ctx context.Context, entry *model.AuditEntry) error {
+	s.entries = append(s.entries, *entry)
+	return s.err
+}
+
+type snapshot struct {
+	Name  string `json:"name"`
+	Genre string `json:"genre,omitempty"`
+}
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		description string
+		before      any
+		after       any
+		expected    string
+	}{
+		{
+			description: "created",
+			after:       snapshot{Name: "It", Genre: "horror"},
+			expected:    `{"genre":{"before":null,"after":"horror"},"name":{"before":null,"after":"It"}}`,
+		},
+		{
+			description: "changed fields only",
+			before:      &snapshot{Name: "It", Genre: "horror"},
+			after:       &snapshot{Name: "It", Genre: "thriller"},
+			expected:    `{"genre":{"before":"horror","after":"thriller"}}`,
+		},
+		{
+			description: "field removed",
+			before:      snapshot{Name: "It", Genre: "horror"},
+			after:       snapshot{Name: "It"},
+			expected:    `{"genre":{"before":"horror","after":null}}`,
+		},
+		{
+			description: "deleted through a nil pointer",
+			before:      snapshot{Name: "It"},
+			after:       (*snapshot)(nil),
+			expected:    `{"name":{"before":"It","after":null}}`,
+		},
+		{
+			description: "nothing changed",
+			before:      snapshot{Name: "It"},
+			after:       snapshot{Name: "It"},
+			expected:    `{}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			actual, err := Diff(testCase.before, testCase.after)
+			assert.NoError(t, err)
+			assert.JSONEq(t, testCase.expected, string(actual))
+		})
+	}
+}
+
+func TestAuditor_Record(t *testing.T) {
+	testCases := []struct {
+		description   string
+		claims        *auth.Claims
+		requestID     string
+		expectedActor string
+	}{
+		{
+			description:   "authenticated actor",
+			claims:        &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "5d574a92-4b78-46eb-8ab0-02709b710b15"}},
+			requestID:     "6f1b1c36-46ff-4dc5-8a44-5c0bde0f77a7",
+			expectedActor: "5d574a92-4b78-46eb-8ab0-02709b710b15",
+		},
+		{
+			description:   "anonymous actor",
+			expectedActor: "anonymous",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			store := &testStore{}
+			auditor := NewAuditor(store, hclog.NewNullLogger())
+
+			app := fiber.New()
+			app.Post("/", func(c *fiber.Ctx) error {
+				if testCase.claims != nil {
+					auth.SetClaims(c, testCase.claims)
+				}
+				if testCase.requestID != "" {
+					c.Set(fiber.HeaderXRequestID, testCase.requestID)
+				}
+
+				return c.Next()
+			}, Middleware, func(c *fiber.Ctx) error {
+				err := auditor.Record(c.UserContext(), model.AuditActionUpdate, "book", "1", snapshot{Name: "It"}, snapshot{Name: "Carrie"})
+				assert.NoError(t, err)
+
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			_, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/", nil), -1)
+			assert.NoError(t, err)
+
+			assert.Len(t, store.entries, 1)
+			entry := store.entries[0]
+			assert.Equal(t, testCase.expectedActor, entry.Actor)
+			assert.Equal(t, model.AuditActionUpdate, entry.Action)
+			assert.Equal(t, "book", entry.EntityType)
+			assert.Equal(t, "1", entry.EntityID)
+			assert.Equal(t, testCase.requestID, entry.RequestID)
+			assert.JSONEq(t, `{"name":{"before":"It","after":"Carrie"}}`, string(entry.Changes))
+		})
+	}
+}
+
+func TestAuditor_RecordOutsideRequest(t *testing.T) {
+	store := &testStore{err: errors.New("connection reset")}
+	auditor := NewAuditor(store, hclog.NewNullLogger())
+
+	ctx := WithOrigin(context.Background(), "cli", "")
+	err := auditor.Record(ctx, model.AuditActionCreate, "author", "1", nil, snapshot{Name: "King"})
+	assert.EqualError(t, err, "connection reset")
+
+	assert.Len(t, store.entries, 1)
+	assert.Equal(t, "cli", store.entries[0].Actor)
+	assert.Empty(t, store.entries[0].RequestID)
+}
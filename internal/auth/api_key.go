@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+	apiKeyPrefix = "lib"
+)
+
+// GenerateAPIKey returns a new secret in the form lib_<prefix>_<random>
+// together with its public prefix and the hash that is stored instead of it.
+func GenerateAPIKey() (string, string, string, error) {
+	prefixBytes := make([]byte, 4)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", "", err
+	}
+
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", "", err
+	}
+
+	prefix := hex.EncodeToString(prefixBytes)
+	key := strings.Join([]string{apiKeyPrefix, prefix, base64.RawURLEncoding.EncodeToString(secretBytes)}, "_")
+
+	return key, prefix, HashToken(key), nil
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const claimsKey = "auth_claims"
+
+var (
+	ErrMissingToken = errors.New("missing bearer token")
+	ErrNoKeys       = errors.New("no jwt verification keys configured")
+)
+
+type Claims struct {
+	jwt.RegisteredClaims
+	Role     string       `json:"role,omitempty"`
+	MemberID string       `json:"member_id,omitempty"`
+	Scopes   []Permission `json:"scopes,omitempty"`
+}
+
+func SetClaims(c *fiber.Ctx, claims *Claims) {
+	c.Locals(claimsKey, claims)
+}
+
+func ClaimsFrom(c *fiber.Ctx) (*Claims, bool) {
+	claims, ok := c.Locals(claimsKey).(*Claims)
+	return claims, ok && claims != nil
+}
+
+func BearerToken(header string) (string, error) {
+	scheme, token, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", ErrMissingToken
+	}
+
+	return strings.TrimSpace(token), nil
+}
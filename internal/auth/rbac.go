@@ -0,0 +1,77 @@
+package auth
+
+type Permission string
+
+const (
+	RoleAdmin     = "admin"
+	RoleLibrarian = "librarian"
+	RoleMember    = "member"
+)
+
+const (
+	PermissionCatalogWrite     Permission = "catalog:write"
+	PermissionMembersRead      Permission = "members:read"
+	PermissionMembersWrite     Permission = "members:write"
+	PermissionCirculationRead  Permission = "circulation:read"
+	PermissionCirculationWrite Permission = "circulation:write"
+	PermissionAPIKeysManage    Permission = "apikeys:manage"
+	PermissionAuditRead        Permission = "audit:read"
+	PermissionRecordsPurge     Permission = "records:purge"
+)
+
+// Members hold no permissions of their own; routes marked as owned grant
+// them access to their own records instead.
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermissionCatalogWrite,
+		PermissionMembersRead,
+		PermissionMembersWrite,
+		PermissionCirculationRead,
+		PermissionCirculationWrite,
+		PermissionAPIKeysManage,
+		PermissionAuditRead,
+		PermissionRecordsPurge,
+	},
+	RoleLibrarian: {
+		PermissionMembersRead,
+		PermissionMembersWrite,
+		PermissionCirculationRead,
+		PermissionCirculationWrite,
+	},
+	RoleMember: {},
+}
+
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func ValidPermission(permission Permission) bool {
+	for _, p := range rolePermissions[RoleAdmin] {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Claims) Can(permission Permission) bool {
+	for _, p := range rolePermissions[c.Role] {
+		if p == permission {
+			return true
+		}
+	}
+
+	for _, p := range c.Scopes {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Claims) Owns(memberID string) bool {
+	return c.MemberID != "" && c.MemberID == memberID
+}
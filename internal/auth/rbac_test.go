@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaims_Can(t *testing.T) {
+	testCases := []struct {
+		role       string
+		permission Permission
+		expected   bool
+	}{
+		{role: RoleAdmin, permission: PermissionCatalogWrite, expected: true},
+		{role: RoleAdmin, permission: PermissionCirculationWrite, expected: true},
+		{role: RoleLibrarian, permission: PermissionCatalogWrite, expected: false},
+		{role: RoleLibrarian, permission: PermissionMembersWrite, expected: true},
+		{role: RoleLibrarian, permission: PermissionCirculationWrite, expected: true},
+		{role: RoleMember, permission: PermissionCirculationRead, expected: false},
+		{role: "", permission: PermissionMembersRead, expected: false},
+		{role: "superuser", permission: PermissionMembersRead, expected: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.role+" "+string(testCase.permission), func(t *testing.T) {
+			claims := &Claims{Role: testCase.role}
+			assert.Equal(t, testCase.expected, claims.Can(testCase.permission))
+		})
+	}
+}
+
+func TestClaims_Owns(t *testing.T) {
+	claims := &Claims{Role: RoleMember, MemberID: "5d574a92-4b78-46eb-8ab0-02709b710b15"}
+
+	assert.True(t, claims.Owns("5d574a92-4b78-46eb-8ab0-02709b710b15"))
+	assert.False(t, claims.Owns("56013726-9dd0-436a-8722-f5e5a9896dc6"))
+	assert.False(t, (&Claims{}).Owns(""))
+}
+
+func TestValidRole(t *testing.T) {
+	assert.True(t, ValidRole(RoleLibrarian))
+	assert.False(t, ValidRole("root"))
+}
+
+func TestClaims_CanWithScopes(t *testing.T) {
+	claims := &Claims{Scopes: []Permission{PermissionCirculationWrite}}
+
+	assert.True(t, claims.Can(PermissionCirculationWrite))
+	assert.False(t, claims.Can(PermissionCatalogWrite))
+}
+
+func TestValidPermission(t *testing.T) {
+	assert.True(t, ValidPermission(PermissionAPIKeysManage))
+	assert.False(t, ValidPermission("catalog:delete"))
+}
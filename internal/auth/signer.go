@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type Signer struct {
+	method   jwt.SigningMethod
+	key      any
+	issuer   string
+	audience string
+	ttl      time.Duration
+}
+
+// NewSigner prefers RS256 when a private key is configured and falls back to
+// HS256 with the shared secret, mirroring what Verifier accepts.
+func NewSigner(secret string, privateKeyPEM string, issuer string, audience string, ttl time.Duration) (*Signer, error) {
+	s := &Signer{issuer: issuer, audience: audience, ttl: ttl}
+
+	switch {
+	case privateKeyPEM != "":
+		key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("parsing jwt private key: %w", err)
+		}
+		s.method = jwt.SigningMethodRS256
+		s.key = key
+	case secret != "":
+		s.method = jwt.SigningMethodHS256
+		s.key = []byte(secret)
+	default:
+		return nil, ErrNoKeys
+	}
+
+	return s, nil
+}
+
+func (s *Signer) TTL() time.Duration {
+	return s.ttl
+}
+
+func (s *Signer) Sign(claims Claims) (string, error) {
+	now := time.Now()
+
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(s.ttl))
+	if s.issuer != "" {
+		claims.Issuer = s.issuer
+	}
+	if s.audience != "" {
+		claims.Audience = jwt.ClaimStrings{s.audience}
+	}
+
+	return jwt.NewWithClaims(s.method, claims).SignedString(s.key)
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigner_Sign(t *testing.T) {
+	signer, err := NewSigner("secret", "", "library-api", "library-clients", time.Minute)
+	assert.NoError(t, err)
+
+	verifier, err := NewVerifier("secret", "", "library-api", "library-clients")
+	assert.NoError(t, err)
+
+	claims := Claims{Role: RoleMember, MemberID: "5d574a92-4b78-46eb-8ab0-02709b710b15"}
+	claims.Subject = claims.MemberID
+
+	token, err := signer.Sign(claims)
+	assert.NoError(t, err)
+
+	actual, err := verifier.Verify(token)
+	assert.NoError(t, err)
+
+	assert.Equal(t, RoleMember, actual.Role)
+	assert.Equal(t, claims.MemberID, actual.MemberID)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), actual.ExpiresAt.Time, 5*time.Second)
+}
+
+func TestSigner_SignRS256(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	privateKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}))
+	publicKey, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	assert.NoError(t, err)
+	publicKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}))
+
+	signer, err := NewSigner("secret", privateKeyPEM, "", "", time.Minute)
+	assert.NoError(t, err)
+
+	token, err := signer.Sign(Claims{Role: RoleAdmin})
+	assert.NoError(t, err)
+
+	verifier, err := NewVerifier("", publicKeyPEM, "", "")
+	assert.NoError(t, err)
+
+	claims, err := verifier.Verify(token)
+	assert.NoError(t, err)
+	assert.Equal(t, RoleAdmin, claims.Role)
+}
+
+func TestNewSigner(t *testing.T) {
+	_, err := NewSigner("", "", "", "", time.Minute)
+	assert.Equal(t, ErrNoKeys, err)
+
+	_, err = NewSigner("", "not a pem", "", "", time.Minute)
+	assert.Error(t, err)
+}
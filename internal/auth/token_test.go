@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateToken(t *testing.T) {
+	token, hash, err := GenerateToken()
+	assert.NoError(t, err)
+
+	assert.Len(t, token, 43)
+	assert.Equal(t, HashToken(token), hash)
+}
+
+func TestHashToken(t *testing.T) {
+	assert.Equal(t, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", HashToken("foo"))
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func signedToken(t *testing.T, method jwt.SigningMethod, key any, claims jwt.RegisteredClaims) string {
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	assert.NoError(t, err)
+
+	return token
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	assert.NoError(t, err)
+	publicKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}))
+
+	verifier, err := NewVerifier("secret", publicKeyPEM, "library-api", "")
+	assert.NoError(t, err)
+
+	valid := jwt.RegisteredClaims{
+		Subject:   "5d574a92-4b78-46eb-8ab0-02709b710b15",
+		Issuer:    "library-api",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+
+	testCases := []struct {
+		description string
+		token       string
+		expectError bool
+	}{
+		{
+			description: "hs256 token",
+			token:       signedToken(t, jwt.SigningMethodHS256, []byte("secret"), valid),
+		},
+		{
+			description: "rs256 token",
+			token:       signedToken(t, jwt.SigningMethodRS256, rsaKey, valid),
+		},
+		{
+			description: "wrong secret",
+			token:       signedToken(t, jwt.SigningMethodHS256, []byte("other"), valid),
+			expectError: true,
+		},
+		{
+			description: "unsupported algorithm",
+			token:       signedToken(t, jwt.SigningMethodHS512, []byte("secret"), valid),
+			expectError: true,
+		},
+		{
+			description: "expired token",
+			token: signedToken(t, jwt.SigningMethodHS256, []byte("secret"), jwt.RegisteredClaims{
+				Issuer:    "library-api",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+			}),
+			expectError: true,
+		},
+		{
+			description: "missing expiry",
+			token: signedToken(t, jwt.SigningMethodHS256, []byte("secret"), jwt.RegisteredClaims{
+				Issuer: "library-api",
+			}),
+			expectError: true,
+		},
+		{
+			description: "wrong issuer",
+			token: signedToken(t, jwt.SigningMethodHS256, []byte("secret"), jwt.RegisteredClaims{
+				Issuer:    "someone-else",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			}),
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			claims, err := verifier.Verify(testCase.token)
+			if testCase.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, valid.Subject, claims.Subject)
+		})
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	_, err := NewVerifier("", "", "", "")
+	assert.Equal(t, ErrNoKeys, err)
+
+	_, err = NewVerifier("", "not a pem", "", "")
+	assert.Error(t, err)
+}
+
+func TestBearerToken(t *testing.T) {
+	token, err := BearerToken("Bearer abc.def.ghi")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc.def.ghi", token)
+
+	token, err = BearerToken("bearer   abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", token)
+
+	_, err = BearerToken("Basic dXNlcjpwYXNz")
+	assert.Equal(t, ErrMissingToken, err)
+
+	_, err = BearerToken("Bearer ")
+	assert.Equal(t, ErrMissingToken, err)
+}
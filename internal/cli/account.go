@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"library-api/internal/audit"
+	"library-api/internal/auth"
+	"library-api/internal/model"
+	"library-api/internal/service"
+	"library-api/internal/store"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type createdUser struct {
+	MemberID   string `json:"member_id"`
+	CardNumber string `json:"card_number"`
+	Login      string `json:"login"`
+	Role       string `json:"role"`
+}
+
+// createUser registers a member and gives them login credentials in one
+// transaction. It is how the first admin gets into a fresh installation.
+// The password is read from the first line of stdin so that it does not end
+// up in the shell history.
+func (c *CLI) createUser(ctx context.Context, args []string) error {
+	fs, output := c.flags("user create")
+	login := fs.String("login", "", "login name")
+	name := fs.String("name", "", "full name of the member")
+	email := fs.String("email", "", "email address")
+	role := fs.String("role", auth.RoleMember, "role: admin, librarian or member")
+	err := parse(fs, output, args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return ErrUsage
+	}
+
+	if strings.TrimSpace(*login) == "" || strings.TrimSpace(*name) == "" {
+		return errors.New("login and name are required")
+	}
+
+	if !auth.ValidRole(*role) {
+		return fmt.Errorf("unknown role: %s", *role)
+	}
+
+	password, err := bufio.NewReader(c.stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	hash, err := auth.HashPassword(strings.TrimRight(password, "\r\n"))
+	if err != nil {
+		return err
+	}
+
+	postgres, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer postgres.Close()
+
+	transactor := store.NewTransactor(postgres, c.logger)
+	auditor := audit.NewAuditor(store.NewAuditStore(postgres, c.logger), c.logger)
+	membership := service.NewMembershipService(store.NewMemberStore(postgres, c.logger), transactor, auditor, c.logger)
+	accounts := store.NewAccountStore(postgres, c.logger)
+
+	member := model.Member{FullName: strings.TrimSpace(*name), Email: *email}
+	credentials := model.Credentials{
+		Login:        strings.TrimSpace(*login),
+		PasswordHash: hash,
+		Role:         *role,
+	}
+
+	ctx = audit.WithOrigin(ctx, cliActor, "")
+	err = transactor.Run(ctx, func(ctx context.Context) error {
+		err := membership.Register(ctx, &member)
+		if err != nil {
+			return err
+		}
+
+		credentials.MemberID = member.ID
+		return accounts.SetCredentials(ctx, &credentials, credentials.Role)
+	})
+	if err != nil {
+		return fmt.Errorf("user creation failed: %w", err)
+	}
+
+	user := createdUser{
+		MemberID:   member.ID,
+		CardNumber: member.CardNumber,
+		Login:      credentials.Login,
+		Role:       credentials.Role,
+	}
+	return c.print(*output, user, func(w io.Writer) {
+		fmt.Fprintf(w, "created %s %q as member %s (card %s)\n", user.Role, user.Login, user.MemberID, user.CardNumber)
+	})
+}
+
+// createAPIKey issues a key for machine clients. The secret is shown once and
+// only its hash is stored.
+func (c *CLI) createAPIKey(ctx context.Context, args []string) error {
+	fs, output := c.flags("apikey create")
+	name := fs.String("name", "", "name of the key")
+	scopes := fs.String("scopes", "", "comma separated permissions, e.g. catalog:write,members:read")
+	expiresIn := fs.Duration("expires-in", 0, "lifetime of the key, no expiry when zero")
+	err := parse(fs, output, args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return ErrUsage
+	}
+
+	if strings.TrimSpace(*name) == "" || strings.TrimSpace(*scopes) == "" {
+		return errors.New("name and scopes are required")
+	}
+
+	key := model.APIKey{
+		ID:   uuid.New().String(),
+		Name: strings.TrimSpace(*name),
+	}
+
+	for _, scope := range strings.Split(*scopes, ",") {
+		scope = strings.TrimSpace(scope)
+		if !auth.ValidPermission(auth.Permission(scope)) {
+			return fmt.Errorf("unknown scope: %s", scope)
+		}
+
+		key.Scopes = append(key.Scopes, scope)
+	}
+
+	if *expiresIn < 0 {
+		return errors.New("expires-in must not be negative")
+	}
+
+	if *expiresIn > 0 {
+		expiresAt := time.Now().Add(*expiresIn)
+		key.ExpiresAt = &expiresAt
+	}
+
+	secret, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return err
+	}
+	key.Prefix = prefix
+
+	postgres, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer postgres.Close()
+
+	err = store.NewAPIKeyStore(postgres, c.logger).Create(ctx, &key, hash)
+	if err != nil {
+		return fmt.Errorf("api key creation failed: %w", err)
+	}
+
+	key.Secret = secret
+
+	return c.print(*output, key, func(w io.Writer) {
+		fmt.Fprintf(w, "created api key %q (%s) with scopes %s\n", key.Name, key.ID, strings.Join(key.Scopes, ", "))
+		fmt.Fprintln(w, "secret, shown only once:")
+		fmt.Fprintln(w, secret)
+	})
+}
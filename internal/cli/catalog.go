@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"library-api/internal/audit"
+	"library-api/internal/model"
+	"library-api/internal/service"
+	"library-api/internal/store"
+	"os"
+)
+
+// catalogFile is the format written by export and read by import. Books
+// refer to their author by id, so the ids are kept across the round trip.
+type catalogFile struct {
+	Authors []catalogAuthor `json:"authors"`
+	Books   []catalogBook   `json:"books"`
+}
+
+type catalogAuthor struct {
+	ID             string `json:"id"`
+	FullName       string `json:"full_name"`
+	NickName       string `json:"nick_name"`
+	Specialization string `json:"specialization"`
+}
+
+type catalogBook struct {
+	ID        string `json:"id"`
+	AuthorsID string `json:"authors_id"`
+	Title     string `json:"title"`
+	Genre     string `json:"genre"`
+	ISBN      string `json:"isbn"`
+}
+
+type catalogCounts struct {
+	Authors int `json:"authors"`
+	Books   int `json:"books"`
+}
+
+// importCatalog adds the authors and books of a catalogue file in one
+// transaction: either the whole file is imported or nothing is. Entries
+// without an id get a new one.
+func (c *CLI) importCatalog(ctx context.Context, args []string) error {
+	fs, output := c.flags("import")
+	file := fs.String("file", "", "catalogue file to import, - for stdin")
+	err := parse(fs, output, args)
+	if err != nil {
+		return err
+	}
+
+	if *file == "" || fs.NArg() > 0 {
+		return ErrUsage
+	}
+
+	catalog, err := c.readCatalog(*file)
+	if err != nil {
+		return err
+	}
+
+	postgres, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer postgres.Close()
+
+	transactor := store.NewTransactor(postgres, c.logger)
+	auditor := audit.NewAuditor(store.NewAuditStore(postgres, c.logger), c.logger)
+	catalogService := service.NewCatalogService(store.NewAuthorStore(postgres, c.logger),
+		store.NewBookStore(postgres, c.logger), transactor, auditor, c.logger)
+
+	ctx = audit.WithOrigin(ctx, cliActor, "")
+	err = transactor.Run(ctx, func(ctx context.Context) error {
+		for _, entry := range catalog.Authors {
+			fullName := entry.FullName
+			author := model.Author{
+				ID:             entry.ID,
+				FullName:       &fullName,
+				NickName:       entry.NickName,
+				Specialization: entry.Specialization,
+			}
+
+			err := catalogService.ImportAuthor(ctx, &author)
+			if err != nil {
+				return fmt.Errorf("author %q: %w", entry.FullName, err)
+			}
+		}
+
+		for _, entry := range catalog.Books {
+			book := model.Book{
+				ID:        entry.ID,
+				AuthorsID: entry.AuthorsID,
+				Title:     entry.Title,
+				Genre:     entry.Genre,
+				ISBN:      entry.ISBN,
+			}
+
+			err := catalogService.ImportBook(ctx, &book)
+			if errors.Is(err, model.ErrNotFound) {
+				return fmt.Errorf("book %q: author %s is deleted", entry.Title, entry.AuthorsID)
+			}
+
+			if err != nil {
+				return fmt.Errorf("book %q: %w", entry.Title, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("import failed: %w", err)
+	}
+
+	result := catalogCounts{Authors: len(catalog.Authors), Books: len(catalog.Books)}
+	return c.print(*output, result, func(w io.Writer) {
+		fmt.Fprintf(w, "imported %d authors and %d books\n", result.Authors, result.Books)
+	})
+}
+
+func (c *CLI) readCatalog(file string) (*catalogFile, error) {
+	var r io.Reader = c.stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	var catalog catalogFile
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&catalog)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s failed: %w", file, err)
+	}
+
+	return &catalog, nil
+}
+
+// exportCatalog writes every live author and book. The export is always
+// JSON, so -output only changes what is reported when writing to a file.
+func (c *CLI) exportCatalog(ctx context.Context, args []string) error {
+	fs, output := c.flags("export")
+	file := fs.String("file", "", "file to write, stdout when empty")
+	err := parse(fs, output, args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return ErrUsage
+	}
+
+	postgres, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer postgres.Close()
+
+	authors, err := store.NewAuthorStore(postgres, c.logger).Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	books, err := store.NewBookStore(postgres, c.logger).Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	catalog := catalogFile{
+		Authors: make([]catalogAuthor, 0, len(authors)),
+		Books:   make([]catalogBook, 0, len(books)),
+	}
+	for _, author := range authors {
+		entry := catalogAuthor{
+			ID:             author.ID,
+			NickName:       author.NickName,
+			Specialization: author.Specialization,
+		}
+		if author.FullName != nil {
+			entry.FullName = *author.FullName
+		}
+
+		catalog.Authors = append(catalog.Authors, entry)
+	}
+
+	for _, book := range books {
+		catalog.Books = append(catalog.Books, catalogBook{
+			ID:        book.ID,
+			AuthorsID: book.AuthorsID,
+			Title:     book.Title,
+			Genre:     book.Genre,
+			ISBN:      book.ISBN,
+		})
+	}
+
+	if *file == "" {
+		encoder := json.NewEncoder(c.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(catalog)
+	}
+
+	content, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(*file, append(content, '\n'), 0o644)
+	if err != nil {
+		return err
+	}
+
+	result := catalogCounts{Authors: len(catalog.Authors), Books: len(catalog.Books)}
+	return c.print(*output, result, func(w io.Writer) {
+		fmt.Fprintf(w, "exported %d authors and %d books to %s\n", result.Authors, result.Books, *file)
+	})
+}
@@ -0,0 +1,241 @@
+// Package cli implements the administrative commands of the library-api
+// binary. Every command reads the same configuration as the server and works
+// on the database through the regular stores.
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"library-api/internal/app"
+	"library-api/pkg/config"
+	"library-api/pkg/db"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+const usage = `usage: app [settings] <command> [flags]
+
+settings:
+  -config FILE               YAML config file, also taken from CONFIG_FILE
+  -<setting> VALUE           override a setting, e.g. -port 9090 or
+                             -db-max-open-conns 50; see config print
+
+commands:
+  serve                      start the server (default)
+  migrate up                 apply all pending migrations
+  migrate down [N]           roll back the last N migrations (default 1)
+  migrate goto V             migrate up or down to version V
+  migrate status             show the schema version and pending migrations
+  seed                       load the sample data
+  import -file F             import authors and books from a JSON export
+  export [-file F]           export authors and books as JSON
+  user create                create a member with login credentials,
+                             reading the password from stdin
+  apikey create              create an API key
+  config print [-redact]     show the effective configuration
+
+every command except serve accepts -output text|json`
+
+var ErrUsage = errors.New(usage)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// cliActor is recorded in the audit log for changes made from the command
+// line, where there is no authenticated subject.
+const cliActor = "cli"
+
+type CLI struct {
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+	environ []string
+	config  *config.Config
+	logger  hclog.Logger
+	connect func(ctx context.Context, options db.Options) (*sql.DB, error)
+	serve   func(ctx context.Context, config *config.Config, sources config.Sources) error
+}
+
+// New returns a CLI that reads its configuration from environ, the flags and
+// the config file.
+func New(stdin io.Reader, stdout io.Writer, stderr io.Writer, environ []string) *CLI {
+	c := &CLI{
+		stdin:   stdin,
+		stdout:  stdout,
+		stderr:  stderr,
+		environ: environ,
+		logger: hclog.New(&hclog.LoggerOptions{
+			Name:   "library-api",
+			Output: stderr,
+		}),
+		serve: app.Start,
+	}
+	c.connect = func(ctx context.Context, options db.Options) (*sql.DB, error) {
+		return db.Connect(ctx, c.config.DbConn, options, c.logger)
+	}
+
+	return c
+}
+
+// Run loads the configuration and executes the command named by the first
+// argument after the settings. Without a command it starts the server.
+func (c *CLI) Run(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(c.stderr, usage)
+	}
+
+	file := fs.String("config", "", "YAML config file")
+	settings := make(map[string]string)
+	for _, key := range config.Keys() {
+		name := strings.ReplaceAll(strings.ToLower(key), "_", "-")
+		fs.Func(name, "overrides "+key, func(value string) error {
+			settings[key] = value
+			return nil
+		})
+	}
+
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	args = fs.Args()
+	if len(args) > 0 && (args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help") {
+		fmt.Fprintln(c.stdout, usage)
+		return nil
+	}
+
+	sources := config.Sources{File: *file, Environ: c.environ, Flags: settings}
+	c.config, err = config.Load(sources)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return c.serve(ctx, c.config, sources)
+	}
+
+	switch args[0] {
+	case "serve":
+		if len(args) > 1 {
+			return ErrUsage
+		}
+
+		return c.serve(ctx, c.config, sources)
+	case "migrate":
+		return c.migrate(ctx, args[1:])
+	case "seed":
+		return c.seed(ctx, args[1:])
+	case "import":
+		return c.importCatalog(ctx, args[1:])
+	case "export":
+		return c.exportCatalog(ctx, args[1:])
+	case "user":
+		if len(args) < 2 || args[1] != "create" {
+			return ErrUsage
+		}
+
+		return c.createUser(ctx, args[2:])
+	case "apikey":
+		if len(args) < 2 || args[1] != "create" {
+			return ErrUsage
+		}
+
+		return c.createAPIKey(ctx, args[2:])
+	case "config":
+		if len(args) < 2 || args[1] != "print" {
+			return ErrUsage
+		}
+
+		return c.printConfig(args[2:])
+	}
+
+	return fmt.Errorf("unknown command %q\n%w", args[0], ErrUsage)
+}
+
+// flags returns a flag set for a command together with its -output flag.
+func (c *CLI) flags(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	output := fs.String("output", outputText, "output format, text or json")
+
+	return fs, output
+}
+
+func parse(fs *flag.FlagSet, output *string, args []string) error {
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *output != outputText && *output != outputJSON {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	return nil
+}
+
+// print writes v as JSON or, in text mode, whatever text writes.
+func (c *CLI) print(output string, v any, text func(w io.Writer)) error {
+	if output == outputJSON {
+		encoder := json.NewEncoder(c.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	text(c.stdout)
+	return nil
+}
+
+func (c *CLI) open(ctx context.Context) (*sql.DB, error) {
+	return c.openWith(ctx, c.config.Database())
+}
+
+func (c *CLI) openWith(ctx context.Context, options db.Options) (*sql.DB, error) {
+	postgres, err := c.connect(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("database connection failed: %w", err)
+	}
+
+	return postgres, nil
+}
+
+// printConfig shows the effective settings after all layers are applied.
+func (c *CLI) printConfig(args []string) error {
+	fs, output := c.flags("config print")
+	redact := fs.Bool("redact", false, "hide secrets")
+	err := parse(fs, output, args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return ErrUsage
+	}
+
+	settings := c.config.Settings(*redact)
+	values := make(map[string]string, len(settings))
+	for _, setting := range settings {
+		values[setting.Key] = setting.Value
+	}
+
+	return c.print(*output, values, func(w io.Writer) {
+		for _, setting := range settings {
+			fmt.Fprintf(w, "%s=%s\n", setting.Key, setting.Value)
+		}
+	})
+}
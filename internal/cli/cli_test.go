@@ -0,0 +1,287 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"library-api/pkg/config"
+	"library-api/pkg/db"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+var memberColumns = []string{"id", "full_name", "email", "phone", "address", "date_of_birth", "card_number",
+	"registered_at", "expires_at", "status", "suspension_reason", "suspended_until", "version"}
+
+func TestCLI_Run(t *testing.T) {
+	today := time.Now().Truncate(24 * time.Hour)
+	testCases := []struct {
+		description    string
+		args           []string
+		stdin          string
+		environ        []string
+		setupMock      func(mock sqlmock.Sqlmock)
+		serveError     error
+		expectedServe  bool
+		expectedOutput []string
+		expectedError  string
+	}{
+		{
+			description:   "serve by default",
+			expectedServe: true,
+		},
+		{
+			description:   "serve",
+			args:          []string{"serve"},
+			expectedServe: true,
+		},
+		{
+			description:   "serve fails when shutdown runs out of time",
+			args:          []string{"serve"},
+			serveError:    errors.New("shutdown deadline exceeded"),
+			expectedServe: true,
+			expectedError: "shutdown deadline exceeded",
+		},
+		{
+			description:    "help",
+			args:           []string{"help"},
+			expectedOutput: []string{"usage: app [settings] <command> [flags]"},
+		},
+		{
+			description:    "help without configuration",
+			args:           []string{"help"},
+			environ:        []string{"PORT=none"},
+			expectedOutput: []string{"usage: app [settings] <command> [flags]"},
+		},
+		{
+			description:   "every configuration problem at once",
+			args:          []string{"-request-timeout", "0s", "serve"},
+			environ:       []string{"PORT=none", "DB_MAX_OPEN_CONNS=many"},
+			expectedError: "PORT must be a number between 1 and 65535, got \"none\"\n  REQUEST_TIMEOUT must be positive",
+		},
+		{
+			description:    "config print redacted",
+			args:           []string{"config", "print", "-redact"},
+			expectedOutput: []string{"PORT=8080\n", "DB_CONN=[redacted]\n", "JWT_SECRET=[redacted]\n", "JWT_PRIVATE_KEY=\n", "REQUEST_TIMEOUT=30s\n"},
+		},
+		{
+			description:    "flags override the environment",
+			args:           []string{"-port", "9090", "config", "print", "-output", "json"},
+			expectedOutput: []string{`"PORT": "9090"`, `"DB_CONN": "host=db"`},
+		},
+		{
+			description:   "unknown command",
+			args:          []string{"purge"},
+			expectedError: `unknown command "purge"`,
+		},
+		{
+			description:   "unknown subcommand",
+			args:          []string{"user", "delete"},
+			expectedError: "usage: app [settings] <command> [flags]",
+		},
+		{
+			description:   "unknown output format",
+			args:          []string{"seed", "-output", "yaml"},
+			expectedError: `unknown output format "yaml"`,
+		},
+		{
+			description:   "migrate with a bad step count",
+			args:          []string{"migrate", "down", "two"},
+			expectedError: `invalid number of steps "two"`,
+		},
+		{
+			description: "migrate status as json",
+			args:        []string{"migrate", "status", "-output", "json"},
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+					WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(12, false))
+			},
+			expectedOutput: []string{`"version": 12`, `"dirty": false`, `"name": "add_idempotency_key_location"`},
+		},
+		{
+			description: "seed",
+			args:        []string{"seed"},
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO authors").WillReturnResult(sqlmock.NewResult(0, 5))
+			},
+			expectedOutput: []string{"sample data loaded"},
+		},
+		{
+			description: "import from stdin",
+			args:        []string{"import", "-file", "-"},
+			stdin: `{"authors":[{"id":"4ce0ddc1-ed52-4173-8e82-e32926ddff2e","full_name":"Stephen King","nick_name":"The King","specialization":"Horror"}],
+				"books":[{"authors_id":"4ce0ddc1-ed52-4173-8e82-e32926ddff2e","title":"It","genre":"Horror","isbn":"978-0-670-81302-8"}]}`,
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO authors").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "full_name", "nick_name", "specialization", "version"}).
+						AddRow("4ce0ddc1-ed52-4173-8e82-e32926ddff2e", "Stephen King", "The King", "Horror", 1))
+				mock.ExpectQuery("INSERT INTO audit_log").
+					WithArgs("cli", "create", "author", "4ce0ddc1-ed52-4173-8e82-e32926ddff2e", sqlmock.AnyArg(), "").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+				mock.ExpectQuery("INSERT INTO books").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "authors_id", "title", "genre", "isbn", "version"}).
+						AddRow("82a531a6-2c7e-484a-b2e4-95e75519c8b7", "4ce0ddc1-ed52-4173-8e82-e32926ddff2e", "It", "Horror", "978-0-670-81302-8", 1))
+				mock.ExpectQuery("INSERT INTO audit_log").
+					WithArgs("cli", "create", "book", sqlmock.AnyArg(), sqlmock.AnyArg(), "").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(2, time.Now()))
+				mock.ExpectCommit()
+			},
+			expectedOutput: []string{"imported 1 authors and 1 books"},
+		},
+		{
+			description: "import rolled back when a book fails",
+			args:        []string{"import", "-file", "-"},
+			stdin:       `{"authors":[],"books":[{"authors_id":"4ce0ddc1-ed52-4173-8e82-e32926ddff2e","title":"It","genre":"Horror","isbn":"1"}]}`,
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO books").WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			expectedError: `import failed: book "It": author 4ce0ddc1-ed52-4173-8e82-e32926ddff2e is deleted`,
+		},
+		{
+			description:   "import of an unknown field",
+			args:          []string{"import", "-file", "-"},
+			stdin:         `{"shelves":[]}`,
+			expectedError: `json: unknown field "shelves"`,
+		},
+		{
+			description:   "import without file",
+			args:          []string{"import"},
+			expectedError: "usage: app [settings] <command> [flags]",
+		},
+		{
+			description: "export to stdout",
+			args:        []string{"export"},
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM authors").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "full_name", "nick_name", "specialization", "version"}).
+						AddRow("4ce0ddc1-ed52-4173-8e82-e32926ddff2e", "Stephen King", "The King", "Horror", 3))
+				mock.ExpectQuery("SELECT (.+) FROM books").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "authors_id", "title", "genre", "isbn", "version"}).
+						AddRow("82a531a6-2c7e-484a-b2e4-95e75519c8b7", "4ce0ddc1-ed52-4173-8e82-e32926ddff2e", "It", "Horror", "978-0-670-81302-8", 2))
+			},
+			expectedOutput: []string{`"full_name": "Stephen King"`, `"title": "It"`, `"authors_id": "4ce0ddc1-ed52-4173-8e82-e32926ddff2e"`},
+		},
+		{
+			description: "create admin user",
+			args:        []string{"user", "create", "-login", "admin", "-name", "Ada Admin", "-role", "admin", "-output", "json"},
+			stdin:       "correct horse battery\n",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO members").
+					WillReturnRows(sqlmock.NewRows(memberColumns).
+						AddRow("3f45f596-ae05-4a60-802c-e2d45e7c26a2", "Ada Admin", "", "", "", nil, "29000000000015",
+							today, today.AddDate(1, 0, 0), "active", "", nil, 1))
+				mock.ExpectQuery("INSERT INTO audit_log").
+					WithArgs("cli", "create", "member", "3f45f596-ae05-4a60-802c-e2d45e7c26a2", sqlmock.AnyArg(), "").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+				mock.ExpectQuery("INSERT INTO member_credentials").
+					WithArgs("3f45f596-ae05-4a60-802c-e2d45e7c26a2", "admin", sqlmock.AnyArg(), "admin", "admin").
+					WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+				mock.ExpectCommit()
+			},
+			expectedOutput: []string{`"member_id": "3f45f596-ae05-4a60-802c-e2d45e7c26a2"`, `"card_number": "29000000000015"`, `"role": "admin"`},
+		},
+		{
+			description: "user not created when the login is taken",
+			args:        []string{"user", "create", "-login", "admin", "-name", "Ada Admin"},
+			stdin:       "correct horse battery\n",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO members").
+					WillReturnRows(sqlmock.NewRows(memberColumns).
+						AddRow("3f45f596-ae05-4a60-802c-e2d45e7c26a2", "Ada Admin", "", "", "", nil, "29000000000015",
+							today, today.AddDate(1, 0, 0), "active", "", nil, 1))
+				mock.ExpectQuery("INSERT INTO audit_log").
+					WithArgs("cli", "create", "member", "3f45f596-ae05-4a60-802c-e2d45e7c26a2", sqlmock.AnyArg(), "").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+				mock.ExpectQuery("INSERT INTO member_credentials").
+					WillReturnError(errors.New("duplicate key value violates unique constraint"))
+				mock.ExpectRollback()
+			},
+			expectedError: "user creation failed: duplicate key value violates unique constraint",
+		},
+		{
+			description:   "user with a weak password",
+			args:          []string{"user", "create", "-login", "admin", "-name", "Ada Admin"},
+			stdin:         "short\n",
+			expectedError: "password must be at least 8 characters long",
+		},
+		{
+			description:   "user with an unknown role",
+			args:          []string{"user", "create", "-login", "admin", "-name", "Ada Admin", "-role", "root"},
+			expectedError: "unknown role: root",
+		},
+		{
+			description: "create api key",
+			args:        []string{"apikey", "create", "-name", "importer", "-scopes", "catalog:write, members:read"},
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO api_keys").
+					WithArgs(sqlmock.AnyArg(), "importer", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+			},
+			expectedOutput: []string{`created api key "importer"`, "with scopes catalog:write, members:read", "lib_"},
+		},
+		{
+			description:   "api key with an unknown scope",
+			args:          []string{"apikey", "create", "-name", "importer", "-scopes", "books:burn"},
+			expectedError: "unknown scope: books:burn",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			postgres, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer postgres.Close()
+
+			if testCase.setupMock != nil {
+				testCase.setupMock(mock)
+			}
+			mock.ExpectClose()
+
+			var stdout, stderr bytes.Buffer
+			served := false
+			c := &CLI{
+				stdin:   strings.NewReader(testCase.stdin),
+				stdout:  &stdout,
+				stderr:  &stderr,
+				environ: append([]string{"PORT=8080", "DB_CONN=host=db", "JWT_SECRET=secret"}, testCase.environ...),
+				logger:  hclog.NewNullLogger(),
+				connect: func(ctx context.Context, options db.Options) (*sql.DB, error) {
+					return postgres, nil
+				},
+				serve: func(ctx context.Context, config *config.Config, sources config.Sources) error {
+					served = true
+					return testCase.serveError
+				},
+			}
+
+			err = c.Run(context.Background(), testCase.args)
+			if testCase.expectedError != "" {
+				assert.ErrorContains(t, err, testCase.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, testCase.expectedServe, served)
+			for _, expected := range testCase.expectedOutput {
+				assert.Contains(t, stdout.String(), expected)
+			}
+
+			if testCase.setupMock != nil {
+				assert.NoError(t, mock.ExpectationsWereMet())
+			}
+		})
+	}
+}
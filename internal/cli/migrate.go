@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"library-api/pkg/db/migrate"
+	"library-api/pkg/db/migrations"
+	"library-api/pkg/db/seeds"
+	"strconv"
+)
+
+// migrate runs a migration action and then reports where the schema stands.
+func (c *CLI) migrate(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+
+	fs, output := c.flags("migrate " + args[0])
+	err := parse(fs, output, args[1:])
+	if err != nil {
+		return err
+	}
+
+	action, err := migrateAction(args[0], fs.Args())
+	if err != nil {
+		return err
+	}
+
+	// Migrations may rewrite whole tables, so the statement timeout meant for
+	// request handling does not apply to them.
+	options := c.config.Database()
+	options.StatementTimeout = 0
+
+	postgres, err := c.openWith(ctx, options)
+	if err != nil {
+		return err
+	}
+	defer postgres.Close()
+
+	migrator, err := migrate.New(postgres, migrations.FS, c.logger)
+	if err != nil {
+		return err
+	}
+
+	if action != nil {
+		err = action(ctx, migrator)
+		if err != nil {
+			return err
+		}
+	}
+
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.print(*output, status, func(w io.Writer) {
+		fmt.Fprintf(w, "version: %d\n", status.Version)
+		if status.Dirty {
+			fmt.Fprintln(w, "dirty: the last migration failed halfway, repair the schema before migrating")
+		}
+
+		for _, migration := range status.Applied {
+			fmt.Fprintf(w, "  applied  %03d_%s\n", migration.Version, migration.Name)
+		}
+
+		for _, migration := range status.Pending {
+			fmt.Fprintf(w, "  pending  %03d_%s\n", migration.Version, migration.Name)
+		}
+	})
+}
+
+// migrateAction parses the arguments before anything connects to the
+// database, so a typo fails fast. Status has no action of its own.
+func migrateAction(name string, args []string) (func(context.Context, *migrate.Migrator) error, error) {
+	switch {
+	case name == "up" && len(args) == 0:
+		return func(ctx context.Context, m *migrate.Migrator) error {
+			return m.Up(ctx)
+		}, nil
+	case name == "down" && len(args) <= 1:
+		steps := 1
+		if len(args) == 1 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid number of steps %q", args[0])
+			}
+
+			steps = n
+		}
+
+		return func(ctx context.Context, m *migrate.Migrator) error {
+			return m.Down(ctx, steps)
+		}, nil
+	case name == "goto" && len(args) == 1:
+		version, err := strconv.ParseUint(args[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", args[0])
+		}
+
+		return func(ctx context.Context, m *migrate.Migrator) error {
+			return m.Goto(ctx, uint(version))
+		}, nil
+	case name == "status" && len(args) == 0:
+		return nil, nil
+	}
+
+	return nil, ErrUsage
+}
+
+// seed loads the sample data. Postgres runs the statements of a single
+// multi-statement query in one transaction, so a failed seed leaves nothing
+// behind.
+func (c *CLI) seed(ctx context.Context, args []string) error {
+	fs, output := c.flags("seed")
+	err := parse(fs, output, args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return ErrUsage
+	}
+
+	postgres, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer postgres.Close()
+
+	_, err = postgres.ExecContext(ctx, seeds.SQL)
+	if err != nil {
+		return fmt.Errorf("seeding failed: %w", err)
+	}
+
+	return c.print(*output, map[string]bool{"seeded": true}, func(w io.Writer) {
+		fmt.Fprintln(w, "sample data loaded")
+	})
+}
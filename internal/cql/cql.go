@@ -0,0 +1,61 @@
+package cql
+
+import "fmt"
+
+// Diagnostic codes from the SRU diagnostics list (info:srw/diagnostic/1/*).
+const (
+	CodeSyntaxError                 = 10
+	CodeUnsupportedIndex            = 16
+	CodeUnsupportedRelation         = 19
+	CodeUnsupportedRelationModifier = 20
+	CodeUnsupportedBooleanOperator  = 37
+	CodeUnsupportedBooleanModifier  = 46
+	CodeSortNotSupported            = 80
+)
+
+const (
+	ServerChoiceIndex = "cql.serverchoice"
+
+	booleanAnd  = "and"
+	booleanOr   = "or"
+	booleanNot  = "not"
+	booleanProx = "prox"
+)
+
+type Error struct {
+	Code    int
+	Message string
+	Details string
+}
+
+func (e *Error) Error() string {
+	if e.Details == "" {
+		return e.Message
+	}
+
+	return fmt.Sprintf("%s: %s", e.Message, e.Details)
+}
+
+type Node interface {
+	node()
+}
+
+type Boolean struct {
+	Op    string
+	Left  Node
+	Right Node
+}
+
+type Clause struct {
+	Index     string
+	Relation  string
+	Modifiers []string
+	Term      string
+}
+
+func (*Boolean) node() {}
+func (*Clause) node()  {}
+
+func syntaxError(details string) *Error {
+	return &Error{Code: CodeSyntaxError, Message: "query syntax error", Details: details}
+}
@@ -0,0 +1,204 @@
+package cql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testIndexes = map[string]Index{
+	"dc.title":        {Columns: []string{"books.title"}},
+	"dc.creator":      {Columns: []string{"authors.full_name"}},
+	"bath.isbn":       {Columns: []string{"books.isbn"}, IgnoreHyphens: true},
+	ServerChoiceIndex: {Columns: []string{"books.title", "authors.full_name"}},
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		description   string
+		query         string
+		expected      Node
+		expectedError *Error
+	}{
+		{
+			description: "bare term",
+			query:       "shining",
+			expected:    &Clause{Index: ServerChoiceIndex, Relation: "=", Term: "shining"},
+		},
+		{
+			description: "index relation term",
+			query:       `dc.title = "the shining"`,
+			expected:    &Clause{Index: "dc.title", Relation: "=", Term: "the shining"},
+		},
+		{
+			description: "named relation with prefix and modifier",
+			query:       `dc.title cql.any/stem "foundation robot"`,
+			expected:    &Clause{Index: "dc.title", Relation: "any", Modifiers: []string{"stem"}, Term: "foundation robot"},
+		},
+		{
+			description: "booleans are left associative",
+			query:       "dc.creator=king and dc.title=it or dc.title=carrie",
+			expected: &Boolean{
+				Op: "or",
+				Left: &Boolean{
+					Op:    "and",
+					Left:  &Clause{Index: "dc.creator", Relation: "=", Term: "king"},
+					Right: &Clause{Index: "dc.title", Relation: "=", Term: "it"},
+				},
+				Right: &Clause{Index: "dc.title", Relation: "=", Term: "carrie"},
+			},
+		},
+		{
+			description: "parentheses",
+			query:       "dc.creator==King NOT (dc.title=it)",
+			expected: &Boolean{
+				Op:    "not",
+				Left:  &Clause{Index: "dc.creator", Relation: "==", Term: "King"},
+				Right: &Clause{Index: "dc.title", Relation: "=", Term: "it"},
+			},
+		},
+		{
+			description:   "unbalanced parentheses",
+			query:         "(dc.title=it",
+			expectedError: &Error{Code: CodeSyntaxError, Message: "query syntax error", Details: "expected ')' at position 12"},
+		},
+		{
+			description:   "unterminated string",
+			query:         `dc.title="it`,
+			expectedError: &Error{Code: CodeSyntaxError, Message: "query syntax error", Details: "unterminated string at position 9"},
+		},
+		{
+			description:   "empty query",
+			query:         "  ",
+			expectedError: &Error{Code: CodeSyntaxError, Message: "query syntax error", Details: "unexpected end of query"},
+		},
+		{
+			description:   "proximity",
+			query:         "a prox b",
+			expectedError: &Error{Code: CodeUnsupportedBooleanOperator, Message: "unsupported boolean operator", Details: "prox"},
+		},
+		{
+			description:   "sort",
+			query:         "dc.title=it sortBy dc.title",
+			expectedError: &Error{Code: CodeSortNotSupported, Message: "sort not supported"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			actual, err := Parse(testCase.query)
+			if testCase.expectedError != nil {
+				assert.Equal(t, testCase.expectedError, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, actual)
+		})
+	}
+}
+
+func TestToSQL(t *testing.T) {
+	testCases := []struct {
+		description   string
+		query         string
+		expectedWhere string
+		expectedArgs  []any
+		expectedCode  int
+	}{
+		{
+			description:   "server choice searches several columns",
+			query:         "king",
+			expectedWhere: "(books.title ILIKE $3 OR authors.full_name ILIKE $4)",
+			expectedArgs:  []any{"%king%", "%king%"},
+		},
+		{
+			description:   "exact match with wildcards",
+			query:         `dc.title == "harry potter*"`,
+			expectedWhere: "books.title ILIKE $3",
+			expectedArgs:  []any{"harry potter%"},
+		},
+		{
+			description:   "like characters are escaped",
+			query:         `dc.title = "100%_sure"`,
+			expectedWhere: "books.title ILIKE $3",
+			expectedArgs:  []any{`%100\%\_sure%`},
+		},
+		{
+			description:   "anchored term",
+			query:         `dc.title = "^the"`,
+			expectedWhere: "books.title ILIKE $3",
+			expectedArgs:  []any{"the%"},
+		},
+		{
+			description:   "all words",
+			query:         `dc.title all "harry stone"`,
+			expectedWhere: "(books.title ILIKE $3 AND books.title ILIKE $4)",
+			expectedArgs:  []any{"%harry%", "%stone%"},
+		},
+		{
+			description:   "isbn ignores hyphens",
+			query:         "bath.isbn=978-0-385-12167-5",
+			expectedWhere: "REPLACE(books.isbn, '-', '') ILIKE $3",
+			expectedArgs:  []any{"%9780385121675%"},
+		},
+		{
+			description:   "booleans",
+			query:         "dc.creator=king not dc.title<>it",
+			expectedWhere: "(authors.full_name ILIKE $3 AND ((books.title IS NULL OR books.title NOT ILIKE $4)) IS NOT TRUE)",
+			expectedArgs:  []any{"%king%", "it"},
+		},
+		{
+			description:   "not keeps rows where the excluded index is null",
+			query:         "dc.title=it not dc.creator=king",
+			expectedWhere: "(books.title ILIKE $3 AND (authors.full_name ILIKE $4) IS NOT TRUE)",
+			expectedArgs:  []any{"%it%", "%king%"},
+		},
+		{
+			description:   "not equal needs every column to differ",
+			query:         "cql.serverChoice <> king",
+			expectedWhere: "((books.title IS NULL OR books.title NOT ILIKE $3) AND (authors.full_name IS NULL OR authors.full_name NOT ILIKE $4))",
+			expectedArgs:  []any{"king", "king"},
+		},
+		{
+			description:   "ordering relation",
+			query:         "dc.title >= m",
+			expectedWhere: "books.title >= $3",
+			expectedArgs:  []any{"m"},
+		},
+		{
+			description:  "unsupported index",
+			query:        "dc.publisher=penguin",
+			expectedCode: CodeUnsupportedIndex,
+		},
+		{
+			description:  "unsupported relation",
+			query:        "dc.title within x",
+			expectedCode: CodeUnsupportedRelation,
+		},
+		{
+			description:  "unsupported relation modifier",
+			query:        "dc.title =/stem x",
+			expectedCode: CodeUnsupportedRelationModifier,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			node, err := Parse(testCase.query)
+			assert.NoError(t, err)
+
+			where, args, err := ToSQL(node, testIndexes, 2)
+			if testCase.expectedCode != 0 {
+				var cqlErr *Error
+				assert.ErrorAs(t, err, &cqlErr)
+				assert.Equal(t, testCase.expectedCode, cqlErr.Code)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedWhere, where)
+			assert.Equal(t, testCase.expectedArgs, args)
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package cql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenSymbol
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+var namedRelations = map[string]bool{
+	"adj":      true,
+	"all":      true,
+	"any":      true,
+	"exact":    true,
+	"within":   true,
+	"encloses": true,
+}
+
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == '/':
+			tokens = append(tokens, token{kind: tokenSymbol, value: string(r), pos: i})
+			i++
+		case r == '=' || r == '<' || r == '>':
+			value := string(r)
+			if i+1 < len(runes) {
+				next := string(runes[i : i+2])
+				if next == "==" || next == "<>" || next == "<=" || next == ">=" {
+					value = next
+				}
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, value: value, pos: i})
+			i += len([]rune(value))
+		case r == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					sb.WriteRune(runes[i])
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, syntaxError(fmt.Sprintf("unterminated string at position %d", start))
+			}
+			tokens = append(tokens, token{kind: tokenString, value: sb.String(), pos: start})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(`()=<>"/`, runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, value: string(runes[start:i]), pos: start})
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse turns a CQL query into a syntax tree. Prefix assignments, sort
+// clauses and proximity are not supported and are reported as diagnostics.
+func Parse(query string) (Node, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	node, err := p.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		if t.kind == tokenWord && strings.EqualFold(t.value, "sortby") {
+			return nil, &Error{Code: CodeSortNotSupported, Message: "sort not supported"}
+		}
+		return nil, syntaxError(fmt.Sprintf("unexpected %q at position %d", t.value, t.pos))
+	}
+
+	return node, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) parseQuery() (Node, error) {
+	left, err := p.parseSearchClause()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		if t.kind != tokenWord {
+			return left, nil
+		}
+
+		op := strings.ToLower(t.value)
+		switch op {
+		case booleanAnd, booleanOr, booleanNot:
+		case booleanProx:
+			return nil, &Error{Code: CodeUnsupportedBooleanOperator, Message: "unsupported boolean operator", Details: t.value}
+		default:
+			return left, nil
+		}
+		p.next()
+
+		if p.peek().kind == tokenSymbol && p.peek().value == "/" {
+			return nil, &Error{Code: CodeUnsupportedBooleanModifier, Message: "unsupported boolean modifier", Details: op}
+		}
+
+		right, err := p.parseSearchClause()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &Boolean{Op: op, Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseSearchClause() (Node, error) {
+	t := p.next()
+
+	switch {
+	case t.kind == tokenSymbol && t.value == "(":
+		node, err := p.parseQuery()
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.kind != tokenSymbol || closing.value != ")" {
+			return nil, syntaxError(fmt.Sprintf("expected ')' at position %d", closing.pos))
+		}
+
+		return node, nil
+	case t.kind == tokenWord || t.kind == tokenString:
+	case t.kind == tokenEOF:
+		return nil, syntaxError("unexpected end of query")
+	default:
+		return nil, syntaxError(fmt.Sprintf("unexpected %q at position %d", t.value, t.pos))
+	}
+
+	if !p.atRelation() {
+		return &Clause{Index: ServerChoiceIndex, Relation: "=", Term: t.value}, nil
+	}
+
+	if t.kind != tokenWord {
+		return nil, syntaxError(fmt.Sprintf("index must not be quoted at position %d", t.pos))
+	}
+
+	clause := &Clause{
+		Index:    strings.ToLower(t.value),
+		Relation: strings.TrimPrefix(strings.ToLower(p.next().value), "cql."),
+	}
+
+	for p.peek().kind == tokenSymbol && p.peek().value == "/" {
+		p.next()
+		modifier := p.next()
+		if modifier.kind != tokenWord {
+			return nil, syntaxError(fmt.Sprintf("expected relation modifier at position %d", modifier.pos))
+		}
+		clause.Modifiers = append(clause.Modifiers, strings.ToLower(modifier.value))
+	}
+
+	term := p.next()
+	if term.kind != tokenWord && term.kind != tokenString {
+		return nil, syntaxError(fmt.Sprintf("expected search term at position %d", term.pos))
+	}
+	clause.Term = term.value
+
+	return clause, nil
+}
+
+func (p *parser) atRelation() bool {
+	t := p.peek()
+	switch t.kind {
+	case tokenSymbol:
+		return t.value != "(" && t.value != ")" && t.value != "/"
+	case tokenWord:
+		name := strings.TrimPrefix(strings.ToLower(t.value), "cql.")
+		if !namedRelations[name] {
+			return false
+		}
+
+		following := p.peekAt(1)
+		return following.kind == tokenWord || following.kind == tokenString ||
+			(following.kind == tokenSymbol && following.value == "/")
+	default:
+		return false
+	}
+}
@@ -0,0 +1,217 @@
+package cql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Index maps a CQL index onto one or more SQL column expressions. A clause
+// on an index with several columns matches if any of them matches, except
+// for '<>', which matches only if none of them equals the term.
+type Index struct {
+	Columns       []string
+	IgnoreHyphens bool
+}
+
+type translator struct {
+	indexes map[string]Index
+	args    []any
+	offset  int
+}
+
+// ToSQL translates a parsed query into a parameterized WHERE condition.
+// Placeholders are numbered from argOffset+1 so the condition can be
+// combined with arguments the caller already has.
+func ToSQL(node Node, indexes map[string]Index, argOffset int) (string, []any, error) {
+	t := &translator{indexes: indexes, offset: argOffset}
+
+	where, err := t.translate(node)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return where, t.args, nil
+}
+
+func (t *translator) bind(value any) string {
+	t.args = append(t.args, value)
+	return fmt.Sprintf("$%d", t.offset+len(t.args))
+}
+
+func (t *translator) translate(node Node) (string, error) {
+	switch n := node.(type) {
+	case *Boolean:
+		left, err := t.translate(n.Left)
+		if err != nil {
+			return "", err
+		}
+
+		right, err := t.translate(n.Right)
+		if err != nil {
+			return "", err
+		}
+
+		switch n.Op {
+		case booleanAnd:
+			return fmt.Sprintf("(%s AND %s)", left, right), nil
+		case booleanOr:
+			return fmt.Sprintf("(%s OR %s)", left, right), nil
+		case booleanNot:
+			// NOT of a NULL is NULL, so a plain NOT would drop the rows whose
+			// right operand is on a NULL column, e.g. a book without author.
+			return fmt.Sprintf("(%s AND (%s) IS NOT TRUE)", left, right), nil
+		default:
+			return "", &Error{Code: CodeUnsupportedBooleanOperator, Message: "unsupported boolean operator", Details: n.Op}
+		}
+	case *Clause:
+		return t.translateClause(n)
+	default:
+		return "", syntaxError("unknown node")
+	}
+}
+
+func (t *translator) translateClause(clause *Clause) (string, error) {
+	index, ok := t.indexes[strings.ToLower(clause.Index)]
+	if !ok {
+		return "", &Error{Code: CodeUnsupportedIndex, Message: "unsupported index", Details: clause.Index}
+	}
+
+	if len(clause.Modifiers) > 0 {
+		return "", &Error{Code: CodeUnsupportedRelationModifier, Message: "unsupported relation modifier", Details: clause.Modifiers[0]}
+	}
+
+	term := clause.Term
+	if index.IgnoreHyphens {
+		term = strings.ReplaceAll(term, "-", "")
+	}
+
+	conditions := make([]string, 0, len(index.Columns))
+	for _, column := range index.Columns {
+		if index.IgnoreHyphens {
+			column = fmt.Sprintf("REPLACE(%s, '-', '')", column)
+		}
+
+		condition, err := t.condition(column, clause.Relation, term)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if len(conditions) == 1 {
+		return conditions[0], nil
+	}
+
+	joiner := " OR "
+	if clause.Relation == "<>" {
+		joiner = " AND "
+	}
+
+	return "(" + strings.Join(conditions, joiner) + ")", nil
+}
+
+func (t *translator) condition(column, relation, term string) (string, error) {
+	switch relation {
+	case "=", "adj":
+		return fmt.Sprintf("%s ILIKE %s", column, t.bind(likePattern(term, true))), nil
+	case "==", "exact":
+		return fmt.Sprintf("%s ILIKE %s", column, t.bind(likePattern(term, false))), nil
+	case "<>":
+		// Columns from a LEFT JOIN can be NULL, which NOT ILIKE would never
+		// match, although no value is certainly not equal to the term.
+		return fmt.Sprintf("(%s IS NULL OR %s NOT ILIKE %s)", column, column, t.bind(likePattern(term, false))), nil
+	case "any", "all":
+		words := strings.Fields(term)
+		if len(words) == 0 {
+			words = []string{""}
+		}
+
+		parts := make([]string, 0, len(words))
+		for _, word := range words {
+			parts = append(parts, fmt.Sprintf("%s ILIKE %s", column, t.bind(likePattern(word, true))))
+		}
+
+		if len(parts) == 1 {
+			return parts[0], nil
+		}
+
+		joiner := " AND "
+		if relation == "any" {
+			joiner = " OR "
+		}
+
+		return "(" + strings.Join(parts, joiner) + ")", nil
+	case "<", ">", "<=", ">=":
+		return fmt.Sprintf("%s %s %s", column, relation, t.bind(unescape(term))), nil
+	default:
+		return "", &Error{Code: CodeUnsupportedRelation, Message: "unsupported relation", Details: relation}
+	}
+}
+
+// likePattern converts CQL masking characters into a LIKE pattern: '*' and
+// '?' become '%' and '_', a backslash escapes the next character and '^'
+// anchors the term to the start or end of the value. When contains is set,
+// unanchored ends are padded with '%'.
+func likePattern(term string, contains bool) string {
+	runes := []rune(term)
+	anchoredStart := len(runes) > 0 && runes[0] == '^'
+	if anchoredStart {
+		runes = runes[1:]
+	}
+
+	anchoredEnd := len(runes) > 0 && runes[len(runes)-1] == '^' &&
+		(len(runes) < 2 || runes[len(runes)-2] != '\\')
+	if anchoredEnd {
+		runes = runes[:len(runes)-1]
+	}
+
+	var sb strings.Builder
+	if contains && !anchoredStart {
+		sb.WriteRune('%')
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch r {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				writeLikeLiteral(&sb, runes[i])
+			}
+		case '*':
+			sb.WriteRune('%')
+		case '?':
+			sb.WriteRune('_')
+		default:
+			writeLikeLiteral(&sb, r)
+		}
+	}
+
+	if contains && !anchoredEnd {
+		sb.WriteRune('%')
+	}
+
+	return sb.String()
+}
+
+func writeLikeLiteral(sb *strings.Builder, r rune) {
+	if r == '%' || r == '_' || r == '\\' {
+		sb.WriteRune('\\')
+	}
+
+	sb.WriteRune(r)
+}
+
+func unescape(term string) string {
+	var sb strings.Builder
+	runes := []rune(term)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) {
+			i++
+		}
+		sb.WriteRune(runes[i])
+	}
+
+	return sb.String()
+}
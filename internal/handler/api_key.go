@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"library-api/internal/auth"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type apiKeyStore interface {
+	Create(ctx context.Context, key *model.APIKey, hash string) error
+	Get(ctx context.Context) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+type createAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (a *APIKeyHandler) Create(c *fiber.Ctx) error {
+	var request createAPIKeyRequest
+	err := c.BodyParser(&request)
+	if err != nil {
+		tracing.Logger(c.UserContext(), a.logger).Error("api key body parsing failed for create", "error", err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "api key creation failed",
+		})
+	}
+
+	if strings.TrimSpace(request.Name) == "" || len(request.Scopes) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "name and scopes are required",
+		})
+	}
+
+	for _, scope := range request.Scopes {
+		if !auth.ValidPermission(auth.Permission(scope)) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "unknown scope: " + scope,
+			})
+		}
+	}
+
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "expires_at must be in the future",
+		})
+	}
+
+	secret, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		tracing.Logger(c.UserContext(), a.logger).Error("api key generation failed", "error", err.Error())
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	key := model.APIKey{
+		ID:        uuid.New().String(),
+		Name:      request.Name,
+		Prefix:    prefix,
+		Scopes:    request.Scopes,
+		ExpiresAt: request.ExpiresAt,
+	}
+
+	err = a.store.Create(c.UserContext(), &key, hash)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "api key creation failed",
+		})
+	}
+
+	key.Secret = secret
+
+	return c.Status(fiber.StatusCreated).JSON(key)
+}
+
+func (a *APIKeyHandler) Get(c *fiber.Ctx) error {
+	keys, err := a.store.Get(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	if len(keys) == 0 {
+		tracing.Logger(c.UserContext(), a.logger).Info("no api keys found")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "no api keys found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(keys)
+}
+
+// Revoke disables an api key. An id that is not a uuid cannot name a key and
+// is answered like an unknown one.
+func (a *APIKeyHandler) Revoke(c *fiber.Ctx) error {
+	id := c.Params("id")
+	_, err := uuid.Parse(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "api key not found",
+		})
+	}
+
+	err = a.store.Revoke(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "api key not found",
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "api key revoked",
+	})
+}
@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"library-api/internal/model"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeyStore struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyStore) Create(ctx context.Context, key *model.APIKey, hash string) error {
+	args := m.Called(key, hash)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyStore) Get(ctx context.Context) ([]model.APIKey, error) {
+	args := m.Called()
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyStore) Revoke(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func TestAPIKeyHandler_Create(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	testCases := []struct {
+		description    string
+		body           any
+		expectedStatus int
+		expectedBody   fiber.Map
+		expectedError  error
+	}{
+		{
+			description: "api key successfully created",
+			body: createAPIKeyRequest{
+				Name:   "kiosk",
+				Scopes: []string{"circulation:write"},
+			},
+			expectedStatus: fiber.StatusCreated,
+		},
+		{
+			description:    "body parsing failed",
+			body:           `{`,
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: fiber.Map{
+				"error": "api key creation failed",
+			},
+		},
+		{
+			description: "missing scopes",
+			body: createAPIKeyRequest{
+				Name: "kiosk",
+			},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: fiber.Map{
+				"error": "name and scopes are required",
+			},
+		},
+		{
+			description: "unknown scope",
+			body: createAPIKeyRequest{
+				Name:   "kiosk",
+				Scopes: []string{"everything"},
+			},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: fiber.Map{
+				"error": "unknown scope: everything",
+			},
+		},
+		{
+			description: "expiry in the past",
+			body: createAPIKeyRequest{
+				Name:      "kiosk",
+				Scopes:    []string{"circulation:write"},
+				ExpiresAt: &past,
+			},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: fiber.Map{
+				"error": "expires_at must be in the future",
+			},
+		},
+		{
+			description: "error from store",
+			body: createAPIKeyRequest{
+				Name:   "kiosk",
+				Scopes: []string{"circulation:write"},
+			},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: fiber.Map{
+				"error": "api key creation failed",
+			},
+			expectedError: errors.New("api key creation failed"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockAPIKeyStore := new(MockAPIKeyStore)
+			apiKeyHandler := &APIKeyHandler{
+				store:  mockAPIKeyStore,
+				logger: hclog.NewNullLogger(),
+			}
+
+			app.Post("/api-key", apiKeyHandler.Create)
+
+			mockAPIKeyStore.On("Create", mock.Anything, mock.Anything).Return(testCase.expectedError).Once()
+
+			body, err := json.Marshal(testCase.body)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(fiber.MethodPost, "/api-key", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+
+			respBody, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			if testCase.expectedStatus != fiber.StatusCreated {
+				var actual fiber.Map
+				err = json.Unmarshal(respBody, &actual)
+				assert.NoError(t, err)
+
+				assert.Equal(t, testCase.expectedBody, actual)
+				return
+			}
+
+			var actual model.APIKey
+			err = json.Unmarshal(respBody, &actual)
+			assert.NoError(t, err)
+
+			assert.Equal(t, "kiosk", actual.Name)
+			assert.True(t, strings.HasPrefix(actual.Secret, "lib_"+actual.Prefix+"_"))
+
+			stored := mockAPIKeyStore.Calls[0].Arguments.Get(0).(*model.APIKey)
+			hash := mockAPIKeyStore.Calls[0].Arguments.String(1)
+			assert.NotEqual(t, actual.Secret, hash)
+			assert.Equal(t, actual.ID, stored.ID)
+		})
+	}
+}
+
+func TestAPIKeyHandler_Get(t *testing.T) {
+	testCases := []struct {
+		description    string
+		keys           []model.APIKey
+		expectedStatus int
+		expectedError  error
+	}{
+		{
+			description: "api keys listed",
+			keys: []model.APIKey{
+				{ID: "6f1b1c36-46ff-4dc5-8a44-5c0bde0f77a7", Name: "kiosk", Prefix: "a1b2c3d4", Scopes: []string{"circulation:write"}},
+			},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			description:    "no keys",
+			keys:           []model.APIKey{},
+			expectedStatus: fiber.StatusNotFound,
+		},
+		{
+			description:    "store error",
+			keys:           []model.APIKey{},
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedError:  errors.New("server error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockAPIKeyStore := new(MockAPIKeyStore)
+			apiKeyHandler := &APIKeyHandler{
+				store:  mockAPIKeyStore,
+				logger: hclog.NewNullLogger(),
+			}
+
+			app.Get("/api-keys", apiKeyHandler.Get)
+
+			mockAPIKeyStore.On("Get").Return(testCase.keys, testCase.expectedError).Once()
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/api-keys", nil), -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+
+			respBody, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.NotContains(t, string(respBody), "secret")
+		})
+	}
+}
+
+func TestAPIKeyHandler_Revoke(t *testing.T) {
+	testCases := []struct {
+		description    string
+		id             string
+		expectedStatus int
+		expectedBody   fiber.Map
+		expectedError  error
+	}{
+		{
+			description:    "api key revoked",
+			expectedStatus: fiber.StatusOK,
+			expectedBody: fiber.Map{
+				"message": "api key revoked",
+			},
+		},
+		{
+			description:    "api key not found",
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody: fiber.Map{
+				"error": "api key not found",
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description:    "store error",
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody: fiber.Map{
+				"error": "server error",
+			},
+			expectedError: errors.New("server error"),
+		},
+		{
+			description:    "id is not a uuid",
+			id:             "not-a-uuid",
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody: fiber.Map{
+				"error": "api key not found",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockAPIKeyStore := new(MockAPIKeyStore)
+			apiKeyHandler := &APIKeyHandler{
+				store:  mockAPIKeyStore,
+				logger: hclog.NewNullLogger(),
+			}
+
+			app.Delete("/api-key/:id", apiKeyHandler.Revoke)
+
+			id := "6f1b1c36-46ff-4dc5-8a44-5c0bde0f77a7"
+			if testCase.id != "" {
+				id = testCase.id
+			} else {
+				mockAPIKeyStore.On("Revoke", id).Return(testCase.expectedError).Once()
+			}
+
+			req := httptest.NewRequest(fiber.MethodDelete, "/api-key/"+id, nil)
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+
+			respBody, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			var actual fiber.Map
+			err = json.Unmarshal(respBody, &actual)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedBody, actual)
+			mockAPIKeyStore.AssertExpectations(t)
+		})
+	}
+}
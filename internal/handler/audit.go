@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type auditStore interface {
+	Get(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error)
+}
+
+func (a *AuditHandler) Get(c *fiber.Ctx) error {
+	filter := model.AuditFilter{
+		Actor:      c.Query("actor"),
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+		Limit:      c.QueryInt("limit", defaultAuditLimit),
+		Offset:     c.QueryInt("offset", 0),
+	}
+
+	if filter.Limit < 1 || filter.Limit > maxAuditLimit || filter.Offset < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "limit must be between 1 and " + strconv.Itoa(maxAuditLimit) + " and offset must not be negative",
+		})
+	}
+
+	var err error
+	filter.From, err = queryTime(c, "from")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "from must be an RFC 3339 timestamp",
+		})
+	}
+
+	filter.To, err = queryTime(c, "to")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "to must be an RFC 3339 timestamp",
+		})
+	}
+
+	entries, err := a.store.Get(c.UserContext(), filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	if len(entries) == 0 {
+		tracing.Logger(c.UserContext(), a.logger).Info("no audit entries found")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "no audit entries found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(entries)
+}
+
+func queryTime(c *fiber.Ctx, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"library-api/internal/model"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type auditRecord struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	Before     any
+	After      any
+}
+
+type MockAuditor struct {
+	mu      sync.Mutex
+	records []auditRecord
+}
+
+func (m *MockAuditor) Record(ctx context.Context, action string, entityType string, entityID string, before any, after any) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records = append(m.records, auditRecord{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     before,
+		After:      after,
+	})
+
+	return nil
+}
+
+type MockAuditStore struct {
+	mock.Mock
+}
+
+func (m *MockAuditStore) Get(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.AuditEntry), args.Error(1)
+}
+
+func TestAuditHandler_Get(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []model.AuditEntry{
+		{
+			ID:         1,
+			Actor:      "5d574a92-4b78-46eb-8ab0-02709b710b15",
+			Action:     model.AuditActionDelete,
+			EntityType: "author",
+			EntityID:   "4dbec5df-c354-4c0a-8f33-7832dfbc12c0",
+			Changes:    json.RawMessage(`{"full_name":{"before":"Jane Doe","after":null}}`),
+			RequestID:  "6f1b1c36-46ff-4dc5-8a44-5c0bde0f77a7",
+			CreatedAt:  from,
+		},
+	}
+
+	testCases := []struct {
+		description    string
+		target         string
+		expectedFilter *model.AuditFilter
+		entries        []model.AuditEntry
+		storeError     error
+		expectedStatus int
+	}{
+		{
+			description:    "entries listed with default paging",
+			target:         "/audit",
+			expectedFilter: &model.AuditFilter{Limit: 100},
+			entries:        entries,
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			description: "entries filtered",
+			target:      "/audit?actor=admin&action=delete&entity_type=author&entity_id=4dbec5df&from=2026-01-01T00:00:00Z&limit=10&offset=20",
+			expectedFilter: &model.AuditFilter{
+				Actor:      "admin",
+				Action:     "delete",
+				EntityType: "author",
+				EntityID:   "4dbec5df",
+				From:       &from,
+				Limit:      10,
+				Offset:     20,
+			},
+			entries:        entries,
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			description:    "invalid timestamp",
+			target:         "/audit?to=yesterday",
+			expectedStatus: fiber.StatusBadRequest,
+		},
+		{
+			description:    "limit too large",
+			target:         "/audit?limit=5000",
+			expectedStatus: fiber.StatusBadRequest,
+		},
+		{
+			description:    "no entries",
+			target:         "/audit",
+			expectedFilter: &model.AuditFilter{Limit: 100},
+			entries:        []model.AuditEntry{},
+			expectedStatus: fiber.StatusNotFound,
+		},
+		{
+			description:    "store error",
+			target:         "/audit",
+			expectedFilter: &model.AuditFilter{Limit: 100},
+			entries:        []model.AuditEntry{},
+			storeError:     errors.New("server error"),
+			expectedStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockAuditStore := new(MockAuditStore)
+			auditHandler := &AuditHandler{
+				store:  mockAuditStore,
+				logger: hclog.NewNullLogger(),
+			}
+
+			app.Get("/audit", auditHandler.Get)
+
+			if testCase.expectedFilter != nil {
+				mockAuditStore.On("Get", *testCase.expectedFilter).Return(testCase.entries, testCase.storeError).Once()
+			}
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, testCase.target, nil), -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+
+			if testCase.expectedStatus == fiber.StatusOK {
+				respBody, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+
+				var actual []model.AuditEntry
+				err = json.Unmarshal(respBody, &actual)
+				assert.NoError(t, err)
+
+				assert.Equal(t, entries, actual)
+			}
+
+			mockAuditStore.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,339 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"library-api/internal/auth"
+	"library-api/internal/model"
+	"library-api/internal/notify"
+	"library-api/pkg/tracing"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type accountStore interface {
+	SetCredentials(ctx context.Context, credentials *model.Credentials, currentRole string) error
+	GetCredentials(ctx context.Context, login string) (*model.Credentials, error)
+	GetCredentialsByMember(ctx context.Context, memberID string) (*model.Credentials, error)
+	CreateRefreshToken(ctx context.Context, id string, memberID string, hash string, expiresAt time.Time) error
+	UseRefreshToken(ctx context.Context, hash string) (*model.Credentials, error)
+	RevokeRefreshToken(ctx context.Context, hash string) error
+	CreatePasswordReset(ctx context.Context, memberID string, hash string, expiresAt time.Time) error
+	ResetPassword(ctx context.Context, tokenHash string, passwordHash string) error
+}
+
+type loginRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type forgotPasswordRequest struct {
+	Login string `json:"login"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type credentialsRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+func (a *AuthHandler) Login(c *fiber.Ctx) error {
+	var request loginRequest
+	err := c.BodyParser(&request)
+	if err != nil || request.Login == "" || request.Password == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "login and password are required",
+		})
+	}
+
+	credentials, err := a.store.GetCredentials(c.UserContext(), request.Login)
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	hash := ""
+	if credentials != nil {
+		hash = credentials.PasswordHash
+	}
+
+	if !auth.CheckPassword(hash, request.Password) {
+		tracing.Logger(c.UserContext(), a.logger).Info("login failed", "login", request.Login)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "invalid login or password",
+		})
+	}
+
+	return a.issueTokens(c, credentials)
+}
+
+func (a *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var request refreshRequest
+	err := c.BodyParser(&request)
+	if err != nil || request.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "refresh_token is required",
+		})
+	}
+
+	credentials, err := a.store.UseRefreshToken(c.UserContext(), auth.HashToken(request.RefreshToken))
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "invalid refresh token",
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	return a.issueTokens(c, credentials)
+}
+
+func (a *AuthHandler) Logout(c *fiber.Ctx) error {
+	var request refreshRequest
+	err := c.BodyParser(&request)
+	if err != nil || request.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "refresh_token is required",
+		})
+	}
+
+	err = a.store.RevokeRefreshToken(c.UserContext(), auth.HashToken(request.RefreshToken))
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "logged out",
+	})
+}
+
+// ForgotPassword always answers 202 so the endpoint cannot be used to find
+// out which logins exist.
+func (a *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	var request forgotPasswordRequest
+	err := c.BodyParser(&request)
+	if err != nil || request.Login == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "login is required",
+		})
+	}
+
+	a.sendPasswordReset(c.UserContext(), request.Login)
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "if the login exists, reset instructions have been sent",
+	})
+}
+
+func (a *AuthHandler) sendPasswordReset(ctx context.Context, login string) {
+	credentials, err := a.store.GetCredentials(ctx, login)
+	if err != nil {
+		return
+	}
+
+	token, hash, err := auth.GenerateToken()
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("reset token generation failed", "error", err.Error())
+		return
+	}
+
+	member, err := a.members.Member(ctx, credentials.MemberID)
+	if err != nil {
+		return
+	}
+
+	if member.Email == "" {
+		tracing.Logger(ctx, a.logger).Info("password reset not sent, member has no email", "member_id", member.ID)
+		return
+	}
+
+	err = a.store.CreatePasswordReset(ctx, credentials.MemberID, hash, time.Now().Add(a.resetTTL))
+	if err != nil {
+		return
+	}
+
+	err = a.notifier.Send(ctx, notify.Message{
+		Kind:     notify.KindPasswordReset,
+		MemberID: credentials.MemberID,
+		To:       member.Email,
+		Subject:  "Password reset",
+		Body:     "Use this token to reset your password: " + token,
+	})
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("sending password reset failed", "member_id", credentials.MemberID, "error", err.Error())
+	}
+}
+
+func (a *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var request resetPasswordRequest
+	err := c.BodyParser(&request)
+	if err != nil || request.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "token and password are required",
+		})
+	}
+
+	hash, err := auth.HashPassword(request.Password)
+	if err != nil {
+		if errors.Is(err, auth.ErrWeakPassword) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		tracing.Logger(c.UserContext(), a.logger).Error("password hashing failed", "error", err.Error())
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	err = a.store.ResetPassword(c.UserContext(), auth.HashToken(request.Token), hash)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid or expired reset token",
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "password updated",
+	})
+}
+
+// SetCredentials creates or replaces the login and password of a member.
+// Only admins can touch staff accounts or change the role of an account.
+func (a *AuthHandler) SetCredentials(c *fiber.Ctx) error {
+	var request credentialsRequest
+	err := c.BodyParser(&request)
+	if err != nil || strings.TrimSpace(request.Login) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "login and password are required",
+		})
+	}
+
+	memberID := c.Params("id")
+	current, err := a.store.GetCredentialsByMember(c.UserContext(), memberID)
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	currentRole := auth.RoleMember
+	if current != nil {
+		currentRole = current.Role
+	}
+
+	if request.Role == "" {
+		request.Role = currentRole
+	}
+
+	if !auth.ValidRole(request.Role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "unknown role: " + request.Role,
+		})
+	}
+
+	staff := request.Role != auth.RoleMember || currentRole != auth.RoleMember
+	if claims, ok := auth.ClaimsFrom(c); staff && (!ok || claims.Role != auth.RoleAdmin) {
+		tracing.Logger(c.UserContext(), a.logger).Info("staff account change refused", "member_id", memberID, "role", request.Role)
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "only admins can manage staff accounts",
+		})
+	}
+
+	hash, err := auth.HashPassword(request.Password)
+	if err != nil {
+		if errors.Is(err, auth.ErrWeakPassword) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		tracing.Logger(c.UserContext(), a.logger).Error("password hashing failed", "error", err.Error())
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	credentials := model.Credentials{
+		MemberID:     memberID,
+		Login:        strings.TrimSpace(request.Login),
+		PasswordHash: hash,
+		Role:         request.Role,
+	}
+
+	err = a.store.SetCredentials(c.UserContext(), &credentials, currentRole)
+	if err != nil {
+		if errors.Is(err, model.ErrVersionConflict) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "account role was changed concurrently",
+			})
+		}
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "credentials update failed",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(credentials)
+}
+
+func (a *AuthHandler) issueTokens(c *fiber.Ctx, credentials *model.Credentials) error {
+	claims := auth.Claims{Role: credentials.Role, MemberID: credentials.MemberID}
+	claims.Subject = credentials.MemberID
+
+	accessToken, err := a.signer.Sign(claims)
+	if err != nil {
+		tracing.Logger(c.UserContext(), a.logger).Error("access token signing failed", "member_id", credentials.MemberID, "error", err.Error())
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	refreshToken, hash, err := auth.GenerateToken()
+	if err != nil {
+		tracing.Logger(c.UserContext(), a.logger).Error("refresh token generation failed", "error", err.Error())
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	err = a.store.CreateRefreshToken(c.UserContext(), uuid.New().String(), credentials.MemberID, hash, time.Now().Add(a.refreshTTL))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(model.TokenPair{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(a.signer.TTL().Seconds()),
+		RefreshToken: refreshToken,
+	})
+}
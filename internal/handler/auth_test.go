@@ -0,0 +1,626 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"library-api/internal/auth"
+	"library-api/internal/model"
+	"library-api/internal/notify"
+	"library-api/internal/service"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAccountStore struct {
+	mock.Mock
+}
+
+func (m *MockAccountStore) SetCredentials(ctx context.Context, credentials *model.Credentials, currentRole string) error {
+	args := m.Called(credentials, currentRole)
+	return args.Error(0)
+}
+
+func (m *MockAccountStore) GetCredentialsByMember(ctx context.Context, memberID string) (*model.Credentials, error) {
+	args := m.Called(memberID)
+	credentials, _ := args.Get(0).(*model.Credentials)
+	return credentials, args.Error(1)
+}
+
+func (m *MockAccountStore) GetCredentials(ctx context.Context, login string) (*model.Credentials, error) {
+	args := m.Called(login)
+	credentials, _ := args.Get(0).(*model.Credentials)
+	return credentials, args.Error(1)
+}
+
+func (m *MockAccountStore) CreateRefreshToken(ctx context.Context, id string, memberID string, hash string, expiresAt time.Time) error {
+	args := m.Called(id, memberID, hash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockAccountStore) UseRefreshToken(ctx context.Context, hash string) (*model.Credentials, error) {
+	args := m.Called(hash)
+	credentials, _ := args.Get(0).(*model.Credentials)
+	return credentials, args.Error(1)
+}
+
+func (m *MockAccountStore) RevokeRefreshToken(ctx context.Context, hash string) error {
+	args := m.Called(hash)
+	return args.Error(0)
+}
+
+func (m *MockAccountStore) CreatePasswordReset(ctx context.Context, memberID string, hash string, expiresAt time.Time) error {
+	args := m.Called(memberID, hash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockAccountStore) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) error {
+	args := m.Called(tokenHash, passwordHash)
+	return args.Error(0)
+}
+
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) Send(ctx context.Context, message notify.Message) error {
+	args := m.Called(message)
+	return args.Error(0)
+}
+
+const testMemberID = "5d574a92-4b78-46eb-8ab0-02709b710b15"
+
+func newTestAuthHandler(t *testing.T) (*AuthHandler, *MockAccountStore, *MockNotifier) {
+	signer, err := auth.NewSigner("secret", "", "", "", 15*time.Minute)
+	assert.NoError(t, err)
+
+	mockAccountStore := new(MockAccountStore)
+	mockNotifier := new(MockNotifier)
+
+	return &AuthHandler{
+		store:      mockAccountStore,
+		members:    service.NewMembershipService(new(MockMemberStore), testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+		signer:     signer,
+		notifier:   mockNotifier,
+		refreshTTL: time.Hour,
+		resetTTL:   time.Hour,
+		logger:     hclog.NewNullLogger(),
+	}, mockAccountStore, mockNotifier
+}
+
+func postJSON(t *testing.T, app *fiber.App, target string, body any) (int, []byte) {
+	payload, err := json.Marshal(body)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(fiber.MethodPost, target, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+
+	respBody, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	return resp.StatusCode, respBody
+}
+
+func TestAuthHandler_Login(t *testing.T) {
+	hash, err := auth.HashPassword("correct horse")
+	assert.NoError(t, err)
+
+	credentials := &model.Credentials{MemberID: testMemberID, Login: "john", PasswordHash: hash, Role: auth.RoleMember}
+
+	testCases := []struct {
+		description    string
+		body           loginRequest
+		setupMock      func(store *MockAccountStore)
+		expectedStatus int
+		expectedBody   fiber.Map
+	}{
+		{
+			description: "login successful",
+			body:        loginRequest{Login: "john", Password: "correct horse"},
+			setupMock: func(store *MockAccountStore) {
+				store.On("GetCredentials", "john").Return(credentials, nil).Once()
+				store.On("CreateRefreshToken", mock.Anything, testMemberID, mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			description:    "missing password",
+			body:           loginRequest{Login: "john"},
+			setupMock:      func(store *MockAccountStore) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: fiber.Map{
+				"error": "login and password are required",
+			},
+		},
+		{
+			description: "wrong password",
+			body:        loginRequest{Login: "john", Password: "wrong horse"},
+			setupMock: func(store *MockAccountStore) {
+				store.On("GetCredentials", "john").Return(credentials, nil).Once()
+			},
+			expectedStatus: fiber.StatusUnauthorized,
+			expectedBody: fiber.Map{
+				"error": "invalid login or password",
+			},
+		},
+		{
+			description: "unknown login",
+			body:        loginRequest{Login: "nobody", Password: "correct horse"},
+			setupMock: func(store *MockAccountStore) {
+				store.On("GetCredentials", "nobody").Return(nil, model.ErrNotFound).Once()
+			},
+			expectedStatus: fiber.StatusUnauthorized,
+			expectedBody: fiber.Map{
+				"error": "invalid login or password",
+			},
+		},
+		{
+			description: "store error",
+			body:        loginRequest{Login: "john", Password: "correct horse"},
+			setupMock: func(store *MockAccountStore) {
+				store.On("GetCredentials", "john").Return(nil, errors.New("server error")).Once()
+			},
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody: fiber.Map{
+				"error": "server error",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			authHandler, mockAccountStore, _ := newTestAuthHandler(t)
+			app.Post("/auth/login", authHandler.Login)
+
+			testCase.setupMock(mockAccountStore)
+
+			status, respBody := postJSON(t, app, "/auth/login", testCase.body)
+			assert.Equal(t, testCase.expectedStatus, status)
+
+			if testCase.expectedStatus == fiber.StatusOK {
+				var actual model.TokenPair
+				err = json.Unmarshal(respBody, &actual)
+				assert.NoError(t, err)
+
+				assert.Equal(t, "Bearer", actual.TokenType)
+				assert.Equal(t, 900, actual.ExpiresIn)
+
+				verifier, err := auth.NewVerifier("secret", "", "", "")
+				assert.NoError(t, err)
+
+				claims, err := verifier.Verify(actual.AccessToken)
+				assert.NoError(t, err)
+				assert.Equal(t, testMemberID, claims.MemberID)
+				assert.Equal(t, auth.RoleMember, claims.Role)
+
+				stored := mockAccountStore.Calls[1].Arguments.String(2)
+				assert.Equal(t, auth.HashToken(actual.RefreshToken), stored)
+			} else {
+				var actual fiber.Map
+				err = json.Unmarshal(respBody, &actual)
+				assert.NoError(t, err)
+
+				assert.Equal(t, testCase.expectedBody, actual)
+			}
+
+			mockAccountStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_Refresh(t *testing.T) {
+	credentials := &model.Credentials{MemberID: testMemberID, Login: "john", Role: auth.RoleLibrarian}
+
+	testCases := []struct {
+		description    string
+		body           refreshRequest
+		setupMock      func(store *MockAccountStore)
+		expectedStatus int
+	}{
+		{
+			description: "refresh token rotated",
+			body:        refreshRequest{RefreshToken: "old"},
+			setupMock: func(store *MockAccountStore) {
+				store.On("UseRefreshToken", auth.HashToken("old")).Return(credentials, nil).Once()
+				store.On("CreateRefreshToken", mock.Anything, testMemberID, mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			description:    "missing token",
+			body:           refreshRequest{},
+			setupMock:      func(store *MockAccountStore) {},
+			expectedStatus: fiber.StatusBadRequest,
+		},
+		{
+			description: "revoked or unknown token",
+			body:        refreshRequest{RefreshToken: "old"},
+			setupMock: func(store *MockAccountStore) {
+				store.On("UseRefreshToken", auth.HashToken("old")).Return(nil, model.ErrNotFound).Once()
+			},
+			expectedStatus: fiber.StatusUnauthorized,
+		},
+		{
+			description: "storing new token failed",
+			body:        refreshRequest{RefreshToken: "old"},
+			setupMock: func(store *MockAccountStore) {
+				store.On("UseRefreshToken", auth.HashToken("old")).Return(credentials, nil).Once()
+				store.On("CreateRefreshToken", mock.Anything, testMemberID, mock.Anything, mock.Anything).
+					Return(errors.New("server error")).Once()
+			},
+			expectedStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			authHandler, mockAccountStore, _ := newTestAuthHandler(t)
+			app.Post("/auth/refresh", authHandler.Refresh)
+
+			testCase.setupMock(mockAccountStore)
+
+			status, _ := postJSON(t, app, "/auth/refresh", testCase.body)
+			assert.Equal(t, testCase.expectedStatus, status)
+
+			mockAccountStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	testCases := []struct {
+		description    string
+		body           refreshRequest
+		expectedStatus int
+		expectedError  error
+	}{
+		{
+			description:    "logged out",
+			body:           refreshRequest{RefreshToken: "token"},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			description:    "already revoked token is fine",
+			body:           refreshRequest{RefreshToken: "token"},
+			expectedStatus: fiber.StatusOK,
+			expectedError:  model.ErrNotFound,
+		},
+		{
+			description:    "store error",
+			body:           refreshRequest{RefreshToken: "token"},
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedError:  errors.New("server error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			authHandler, mockAccountStore, _ := newTestAuthHandler(t)
+			app.Post("/auth/logout", authHandler.Logout)
+
+			mockAccountStore.On("RevokeRefreshToken", auth.HashToken("token")).Return(testCase.expectedError).Once()
+
+			status, _ := postJSON(t, app, "/auth/logout", testCase.body)
+			assert.Equal(t, testCase.expectedStatus, status)
+
+			mockAccountStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_ForgotPassword(t *testing.T) {
+	credentials := &model.Credentials{MemberID: testMemberID, Login: "jking", Role: auth.RoleMember}
+	member := &model.Member{ID: testMemberID, FullName: "John King", Email: "john@example.com"}
+
+	testCases := []struct {
+		description string
+		setupMock   func(store *MockAccountStore, members *MockMemberStore, notifier *MockNotifier)
+	}{
+		{
+			description: "reset token sent to the member's email",
+			setupMock: func(store *MockAccountStore, members *MockMemberStore, notifier *MockNotifier) {
+				store.On("GetCredentials", "jking").Return(credentials, nil).Once()
+				members.On("GetByID", testMemberID).Return(member, nil).Once()
+				store.On("CreatePasswordReset", testMemberID, mock.Anything, mock.Anything).Return(nil).Once()
+				notifier.On("Send", mock.MatchedBy(func(message notify.Message) bool {
+					return message.Kind == notify.KindPasswordReset && message.To == "john@example.com" &&
+						strings.Contains(message.Body, "reset your password")
+				})).Return(nil).Once()
+			},
+		},
+		{
+			description: "unknown login is not revealed",
+			setupMock: func(store *MockAccountStore, members *MockMemberStore, notifier *MockNotifier) {
+				store.On("GetCredentials", "jking").Return(nil, model.ErrNotFound).Once()
+			},
+		},
+		{
+			description: "member without email gets no token",
+			setupMock: func(store *MockAccountStore, members *MockMemberStore, notifier *MockNotifier) {
+				store.On("GetCredentials", "jking").Return(credentials, nil).Once()
+				members.On("GetByID", testMemberID).Return(&model.Member{ID: testMemberID, FullName: "John King"}, nil).Once()
+			},
+		},
+		{
+			description: "notifier failure is not revealed",
+			setupMock: func(store *MockAccountStore, members *MockMemberStore, notifier *MockNotifier) {
+				store.On("GetCredentials", "jking").Return(credentials, nil).Once()
+				members.On("GetByID", testMemberID).Return(member, nil).Once()
+				store.On("CreatePasswordReset", testMemberID, mock.Anything, mock.Anything).Return(nil).Once()
+				notifier.On("Send", mock.Anything).Return(errors.New("smtp down")).Once()
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			authHandler, mockAccountStore, mockNotifier := newTestAuthHandler(t)
+			mockMemberStore := new(MockMemberStore)
+			authHandler.members = service.NewMembershipService(mockMemberStore, testTransactor{}, new(MockAuditor), hclog.NewNullLogger())
+			app.Post("/auth/password/forgot", authHandler.ForgotPassword)
+
+			testCase.setupMock(mockAccountStore, mockMemberStore, mockNotifier)
+
+			status, _ := postJSON(t, app, "/auth/password/forgot", forgotPasswordRequest{Login: "jking"})
+			assert.Equal(t, fiber.StatusAccepted, status)
+
+			mockAccountStore.AssertExpectations(t)
+			mockMemberStore.AssertExpectations(t)
+			mockNotifier.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_ResetPassword(t *testing.T) {
+	testCases := []struct {
+		description    string
+		body           resetPasswordRequest
+		setupMock      func(store *MockAccountStore)
+		expectedStatus int
+		expectedBody   fiber.Map
+	}{
+		{
+			description: "password updated",
+			body:        resetPasswordRequest{Token: "token", Password: "new password"},
+			setupMock: func(store *MockAccountStore) {
+				store.On("ResetPassword", auth.HashToken("token"), mock.Anything).Return(nil).Once()
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody: fiber.Map{
+				"message": "password updated",
+			},
+		},
+		{
+			description:    "weak password",
+			body:           resetPasswordRequest{Token: "token", Password: "short"},
+			setupMock:      func(store *MockAccountStore) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: fiber.Map{
+				"error": auth.ErrWeakPassword.Error(),
+			},
+		},
+		{
+			description: "expired token",
+			body:        resetPasswordRequest{Token: "token", Password: "new password"},
+			setupMock: func(store *MockAccountStore) {
+				store.On("ResetPassword", auth.HashToken("token"), mock.Anything).Return(model.ErrNotFound).Once()
+			},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: fiber.Map{
+				"error": "invalid or expired reset token",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			authHandler, mockAccountStore, _ := newTestAuthHandler(t)
+			app.Post("/auth/password/reset", authHandler.ResetPassword)
+
+			testCase.setupMock(mockAccountStore)
+
+			status, respBody := postJSON(t, app, "/auth/password/reset", testCase.body)
+			assert.Equal(t, testCase.expectedStatus, status)
+
+			var actual fiber.Map
+			err := json.Unmarshal(respBody, &actual)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedBody, actual)
+
+			mockAccountStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_SetCredentials(t *testing.T) {
+	testCases := []struct {
+		description    string
+		role           string
+		current        *model.Credentials
+		body           credentialsRequest
+		storeError     error
+		expectedStatus int
+		storedRole     string
+		currentRole    string
+		expectedRole   string
+	}{
+		{
+			description:    "librarian sets member credentials",
+			role:           auth.RoleLibrarian,
+			body:           credentialsRequest{Login: "john", Password: "correct horse"},
+			expectedStatus: fiber.StatusOK,
+			storedRole:     auth.RoleMember,
+			currentRole:    auth.RoleMember,
+			expectedRole:   auth.RoleMember,
+		},
+		{
+			description:    "librarian replaces member password",
+			role:           auth.RoleLibrarian,
+			current:        &model.Credentials{MemberID: testMemberID, Login: "john", Role: auth.RoleMember},
+			body:           credentialsRequest{Login: "john", Password: "correct horse"},
+			expectedStatus: fiber.StatusOK,
+			storedRole:     auth.RoleMember,
+			currentRole:    auth.RoleMember,
+			expectedRole:   auth.RoleMember,
+		},
+		{
+			description:    "admin assigns librarian role",
+			role:           auth.RoleAdmin,
+			body:           credentialsRequest{Login: "emily", Password: "correct horse", Role: auth.RoleLibrarian},
+			expectedStatus: fiber.StatusOK,
+			storedRole:     auth.RoleLibrarian,
+			currentRole:    auth.RoleMember,
+			expectedRole:   auth.RoleLibrarian,
+		},
+		{
+			description:    "admin promotes existing member",
+			role:           auth.RoleAdmin,
+			current:        &model.Credentials{MemberID: testMemberID, Login: "emily", Role: auth.RoleMember},
+			body:           credentialsRequest{Login: "emily", Password: "correct horse", Role: auth.RoleLibrarian},
+			expectedStatus: fiber.StatusOK,
+			storedRole:     auth.RoleLibrarian,
+			currentRole:    auth.RoleMember,
+			expectedRole:   auth.RoleLibrarian,
+		},
+		{
+			description:    "admin keeps role when none is given",
+			role:           auth.RoleAdmin,
+			current:        &model.Credentials{MemberID: testMemberID, Login: "emily", Role: auth.RoleLibrarian},
+			body:           credentialsRequest{Login: "emily", Password: "correct horse"},
+			expectedStatus: fiber.StatusOK,
+			storedRole:     auth.RoleLibrarian,
+			currentRole:    auth.RoleLibrarian,
+			expectedRole:   auth.RoleLibrarian,
+		},
+		{
+			description:    "librarian cannot assign staff role",
+			role:           auth.RoleLibrarian,
+			body:           credentialsRequest{Login: "john", Password: "correct horse", Role: auth.RoleAdmin},
+			expectedStatus: fiber.StatusForbidden,
+		},
+		{
+			description:    "librarian cannot take over staff account",
+			role:           auth.RoleLibrarian,
+			current:        &model.Credentials{MemberID: testMemberID, Login: "root", Role: auth.RoleAdmin},
+			body:           credentialsRequest{Login: "root", Password: "correct horse"},
+			expectedStatus: fiber.StatusForbidden,
+		},
+		{
+			description:    "librarian cannot demote staff account",
+			role:           auth.RoleLibrarian,
+			current:        &model.Credentials{MemberID: testMemberID, Login: "root", Role: auth.RoleAdmin},
+			body:           credentialsRequest{Login: "root", Password: "correct horse", Role: auth.RoleMember},
+			expectedStatus: fiber.StatusForbidden,
+		},
+		{
+			description:    "unknown role",
+			role:           auth.RoleAdmin,
+			body:           credentialsRequest{Login: "john", Password: "correct horse", Role: "owner"},
+			expectedStatus: fiber.StatusBadRequest,
+		},
+		{
+			description:    "weak password",
+			role:           auth.RoleAdmin,
+			body:           credentialsRequest{Login: "john", Password: "short"},
+			expectedStatus: fiber.StatusBadRequest,
+		},
+		{
+			description:    "login taken",
+			role:           auth.RoleAdmin,
+			body:           credentialsRequest{Login: "john", Password: "correct horse"},
+			storeError:     errors.New("duplicate key"),
+			expectedStatus: fiber.StatusBadRequest,
+		},
+		{
+			description:    "role changed concurrently",
+			role:           auth.RoleLibrarian,
+			current:        &model.Credentials{MemberID: testMemberID, Login: "john", Role: auth.RoleMember},
+			body:           credentialsRequest{Login: "john", Password: "correct horse"},
+			storeError:     model.ErrVersionConflict,
+			expectedStatus: fiber.StatusConflict,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			authHandler, mockAccountStore, _ := newTestAuthHandler(t)
+			app.Put("/member/:id/credentials", func(c *fiber.Ctx) error {
+				auth.SetClaims(c, &auth.Claims{Role: testCase.role})
+				return c.Next()
+			}, authHandler.SetCredentials)
+
+			if testCase.current != nil {
+				mockAccountStore.On("GetCredentialsByMember", testMemberID).Return(testCase.current, nil)
+			} else {
+				mockAccountStore.On("GetCredentialsByMember", testMemberID).Return(nil, model.ErrNotFound)
+			}
+			var credentials any = mock.Anything
+			if testCase.storedRole != "" {
+				credentials = mock.MatchedBy(func(c *model.Credentials) bool {
+					return c.Role == testCase.storedRole
+				})
+			}
+			var currentRole any = mock.Anything
+			if testCase.currentRole != "" {
+				currentRole = testCase.currentRole
+			}
+			mockAccountStore.On("SetCredentials", credentials, currentRole).Return(testCase.storeError).Maybe()
+
+			body, err := json.Marshal(testCase.body)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(fiber.MethodPut, "/member/"+testMemberID+"/credentials", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+
+			if testCase.expectedStatus == fiber.StatusOK {
+				stored := mockAccountStore.Calls[1].Arguments.Get(0).(*model.Credentials)
+				assert.Equal(t, testMemberID, stored.MemberID)
+				assert.True(t, auth.CheckPassword(stored.PasswordHash, "correct horse"))
+
+				respBody, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.NotContains(t, string(respBody), stored.PasswordHash)
+
+				var actual model.Credentials
+				assert.NoError(t, json.Unmarshal(respBody, &actual))
+				assert.Equal(t, testCase.expectedRole, actual.Role)
+			}
+
+			if testCase.expectedStatus == fiber.StatusForbidden {
+				mockAccountStore.AssertNotCalled(t, "SetCredentials", mock.Anything, mock.Anything)
+			}
+
+			mockAccountStore.AssertExpectations(t)
+		})
+	}
+}
@@ -1,47 +1,50 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"library-api/internal/model"
-	"strings"
+	"library-api/internal/service"
+	"library-api/pkg/tracing"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
-type authorStore interface {
-	Create(author *model.Author) error
-	Get() ([]model.Author, error)
-	Exists(id string) error
-	Update(id string, author *model.Author) error
-	Delete(id string) error
-	GetAuthorsBooks(id string) ([]string, error)
+type authorService interface {
+	Authors(ctx context.Context) ([]model.Author, error)
+	Author(ctx context.Context, id string) (*model.Author, error)
+	AuthorBooks(ctx context.Context, id string) ([]string, error)
+	CreateAuthor(ctx context.Context, author *model.Author) error
+	UpdateAuthor(ctx context.Context, id string, author *model.Author, precondition service.Precondition) (*model.Author, error)
+	DeleteAuthor(ctx context.Context, id string, precondition service.Precondition) (*model.Author, error)
+	RestoreAuthor(ctx context.Context, id string) (*model.Author, error)
 }
 
 func (a *AuthorHandler) Create(c *fiber.Ctx) error {
 	var author model.Author
 	err := c.BodyParser(&author)
 	if err != nil {
-		a.logger.Error("author body parsing failed for create", "error", err.Error())
+		tracing.Logger(c.UserContext(), a.logger).Error("author body parsing failed for create", "error", err.Error())
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "author creation failed",
 		})
 	}
 
-	author.ID = uuid.New().String()
-	err = a.store.Create(&author)
+	err = a.service.CreateAuthor(c.UserContext(), &author)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "author creation failed",
 		})
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message": "author created",
-	})
+	c.Location("/author/" + author.ID)
+	c.Set(fiber.HeaderETag, etag(author.Version))
+
+	return c.Status(fiber.StatusCreated).JSON(author)
 }
 
 func (a *AuthorHandler) Get(c *fiber.Ctx) error {
-	authors, err := a.store.Get()
+	authors, err := a.service.Authors(c.UserContext())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "server error",
@@ -49,21 +52,55 @@ func (a *AuthorHandler) Get(c *fiber.Ctx) error {
 	}
 
 	if len(authors) == 0 {
-		a.logger.Info("", "info", "authors not found")
+		tracing.Logger(c.UserContext(), a.logger).Info("", "info", "authors not found")
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"message": "authors not found",
 		})
 	}
 
+	if wantsJSONLD(c) {
+		return sendJSONLD(c, fiber.StatusOK, newLDItemList(authors, func(author model.Author) any {
+			return newLDPerson(author)
+		}))
+	}
+
 	return c.Status(fiber.StatusOK).JSON(authors)
 }
 
+func (a *AuthorHandler) GetByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+	author, err := a.service.Author(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "author not found",
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	if notModified(c, author.Version) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	if wantsJSONLD(c) {
+		person := newLDPerson(*author)
+		person.Context = schemaContext
+		return sendJSONLD(c, fiber.StatusOK, person)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(author)
+}
+
 func (a *AuthorHandler) Update(c *fiber.Ctx) error {
 	var author model.Author
 
 	err := c.BodyParser(&author)
 	if err != nil {
-		a.logger.Error("author body parsing failed for update", "id", author.ID, "error", err.Error())
+		tracing.Logger(c.UserContext(), a.logger).Error("author body parsing failed for update", "id", author.ID, "error", err.Error())
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "author update failed",
 		})
@@ -71,30 +108,45 @@ func (a *AuthorHandler) Update(c *fiber.Ctx) error {
 
 	id := c.Params("id")
 
-	err = a.store.Exists(id)
+	_, err = a.service.UpdateAuthor(c.UserContext(), id, &author, precondition(c))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "author not found",
-		})
-	}
+		switch {
+		case errors.Is(err, model.ErrNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "author not found",
+			})
+		case errors.Is(err, service.ErrPreconditionFailed), errors.Is(err, model.ErrVersionConflict):
+			return preconditionFailed(c, "author was modified by another request")
+		case errors.Is(err, service.ErrRejected):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "author update failed",
+			})
+		}
 
-	err = a.store.Update(id, &author)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "author update failed",
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "author updated",
-	})
+	author.ID = id
+
+	c.Set(fiber.HeaderETag, etag(author.Version))
+
+	return c.Status(fiber.StatusOK).JSON(author)
 }
 
 func (a *AuthorHandler) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
-	err := a.store.Delete(id)
+	_, err := a.service.DeleteAuthor(c.UserContext(), id, precondition(c))
 	if err != nil {
-		if strings.Contains(err.Error(), "violates foreign key constraint") {
+		switch {
+		case errors.Is(err, model.ErrNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "author not found",
+			})
+		case errors.Is(err, service.ErrPreconditionFailed):
+			return preconditionFailed(c, "author was modified by another request")
+		case errors.Is(err, model.ErrReferenced):
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"message": "author has related recordings and cannot be deleted",
 			})
@@ -110,9 +162,29 @@ func (a *AuthorHandler) Delete(c *fiber.Ctx) error {
 	})
 }
 
+func (a *AuthorHandler) Restore(c *fiber.Ctx) error {
+	id := c.Params("id")
+	author, err := a.service.RestoreAuthor(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "deleted author not found",
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	c.Set(fiber.HeaderETag, etag(author.Version))
+
+	return c.Status(fiber.StatusOK).JSON(author)
+}
+
 func (a *AuthorHandler) GetAuthorBooks(c *fiber.Ctx) error {
 	id := c.Params("id")
-	books, err := a.store.GetAuthorsBooks(id)
+	books, err := a.service.AuthorBooks(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "server error",
@@ -120,7 +192,7 @@ func (a *AuthorHandler) GetAuthorBooks(c *fiber.Ctx) error {
 	}
 
 	if len(books) == 0 {
-		a.logger.Info("", "info", "no authors found")
+		tracing.Logger(c.UserContext(), a.logger).Info("", "info", "no authors found")
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "book not found",
 		})
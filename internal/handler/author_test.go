@@ -2,10 +2,12 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"library-api/internal/model"
+	"library-api/internal/service"
 	"net/http/httptest"
 	"testing"
 
@@ -19,32 +21,38 @@ type MockAuthorStore struct {
 	mock.Mock
 }
 
-func (m *MockAuthorStore) Create(author *model.Author) error {
+func (m *MockAuthorStore) Create(ctx context.Context, author *model.Author) error {
 	args := m.Called(author)
 	return args.Error(0)
 }
 
-func (m *MockAuthorStore) Get() ([]model.Author, error) {
+func (m *MockAuthorStore) Get(ctx context.Context) ([]model.Author, error) {
 	args := m.Called()
 	return args.Get(0).([]model.Author), args.Error(1)
 }
 
-func (m *MockAuthorStore) Exists(id string) error {
+func (m *MockAuthorStore) GetByID(ctx context.Context, id string) (*model.Author, error) {
 	args := m.Called(id)
-	return args.Error(0)
+	author, _ := args.Get(0).(*model.Author)
+	return author, args.Error(1)
 }
 
-func (m *MockAuthorStore) Update(id string, author *model.Author) error {
+func (m *MockAuthorStore) Update(ctx context.Context, id string, author *model.Author) error {
 	args := m.Called(id, author)
 	return args.Error(0)
 }
 
-func (m *MockAuthorStore) Delete(id string) error {
+func (m *MockAuthorStore) Delete(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockAuthorStore) Restore(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockAuthorStore) GetAuthorsBooks(id string) ([]string, error) {
+func (m *MockAuthorStore) GetAuthorsBooks(ctx context.Context, id string) ([]string, error) {
 	args := m.Called(id)
 	return args.Get(0).([]string), args.Error(1)
 }
@@ -67,7 +75,10 @@ func TestAuthorHandler_Create(t *testing.T) {
 			},
 			expectedStatus: fiber.StatusCreated,
 			expectedBody: fiber.Map{
-				"message": "author created",
+				"full_name":      "John Doe",
+				"nick_name":      "johndoe123",
+				"specialization": "Writer",
+				"version":        float64(1),
 			},
 		},
 		{
@@ -98,13 +109,15 @@ func TestAuthorHandler_Create(t *testing.T) {
 
 			mockAuthorStore := new(MockAuthorStore)
 			authorHandler := &AuthorHandler{
-				store:  mockAuthorStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewCatalogService(mockAuthorStore, nil, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Post("/author", authorHandler.Create)
 
-			mockAuthorStore.On("Create", mock.Anything).Return(testCase.expectedError).Once()
+			mockAuthorStore.On("Create", mock.Anything).Return(testCase.expectedError).Once().Run(func(args mock.Arguments) {
+				args.Get(0).(*model.Author).Version = 1
+			})
 
 			body, err := json.Marshal(testCase.body)
 			assert.NoError(t, err)
@@ -124,6 +137,12 @@ func TestAuthorHandler_Create(t *testing.T) {
 			err = json.Unmarshal(respBody, &actual)
 			assert.NoError(t, err)
 
+			if testCase.expectedStatus == fiber.StatusCreated {
+				assert.Equal(t, "/author/"+actual["id"].(string), resp.Header.Get(fiber.HeaderLocation))
+				assert.Equal(t, `"1"`, resp.Header.Get(fiber.HeaderETag))
+				delete(actual, "id")
+			}
+
 			assert.Equal(t, testCase.expectedBody, actual)
 		})
 	}
@@ -184,8 +203,8 @@ func TestAuthorHandler_Get(t *testing.T) {
 			var mockAuthorStore MockAuthorStore
 
 			authorHandler := &AuthorHandler{
-				store:  &mockAuthorStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewCatalogService(&mockAuthorStore, nil, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Get("/authors", authorHandler.Get)
@@ -220,15 +239,101 @@ func TestAuthorHandler_Get(t *testing.T) {
 	}
 }
 
+func TestAuthorHandler_GetByID(t *testing.T) {
+	testCases := []struct {
+		description    string
+		ifNoneMatch    string
+		getError       error
+		expectedStatus int
+		expectedETag   string
+		expectedBody   string
+	}{
+		{
+			description:    "author found",
+			expectedStatus: fiber.StatusOK,
+			expectedETag:   `"3"`,
+			expectedBody:   `{"id":"4dbec5df-c354-4c0a-8f33-7832dfbc12c0","full_name":"Jane Doe","version":3}`,
+		},
+		{
+			description:    "if-none-match matches current version",
+			ifNoneMatch:    `W/"3"`,
+			expectedStatus: fiber.StatusNotModified,
+			expectedETag:   `"3"`,
+		},
+		{
+			description:    "if-none-match is stale",
+			ifNoneMatch:    `"2"`,
+			expectedStatus: fiber.StatusOK,
+			expectedETag:   `"3"`,
+			expectedBody:   `{"id":"4dbec5df-c354-4c0a-8f33-7832dfbc12c0","full_name":"Jane Doe","version":3}`,
+		},
+		{
+			description:    "author not found",
+			getError:       model.ErrNotFound,
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   `{"error":"author not found"}`,
+		},
+		{
+			description:    "store error",
+			getError:       errors.New("select failed"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody:   `{"error":"server error"}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockAuthorStore := new(MockAuthorStore)
+			authorHandler := &AuthorHandler{
+				service: service.NewCatalogService(mockAuthorStore, nil, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
+			}
+
+			app.Get("/author/:id", authorHandler.GetByID)
+
+			mockAuthorStore.On("GetByID", "4dbec5df-c354-4c0a-8f33-7832dfbc12c0").Return(existingAuthor(testCase.getError), testCase.getError).Once()
+
+			req := httptest.NewRequest(fiber.MethodGet, "/author/4dbec5df-c354-4c0a-8f33-7832dfbc12c0", nil)
+			if testCase.ifNoneMatch != "" {
+				req.Header.Set(fiber.HeaderIfNoneMatch, testCase.ifNoneMatch)
+			}
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+			assert.Equal(t, testCase.expectedETag, resp.Header.Get(fiber.HeaderETag))
+
+			respBody, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedBody, string(respBody))
+		})
+	}
+}
+
+func existingAuthor(err error) *model.Author {
+	if err != nil {
+		return nil
+	}
+
+	fullName := "Jane Doe"
+	return &model.Author{ID: "4dbec5df-c354-4c0a-8f33-7832dfbc12c0", FullName: &fullName, Version: 3}
+}
+
 func TestAuthorHandler_Update(t *testing.T) {
 	fullName := "John Doe"
 	testCases := []struct {
 		description         string
 		body                any
+		ifMatch             string
 		expectedExistsError error
 		expectedStatus      int
 		expectedBody        any
 		expectedUpdateError error
+		expectedETag        string
 	}{
 		{
 			description: "author update success",
@@ -239,8 +344,49 @@ func TestAuthorHandler_Update(t *testing.T) {
 			},
 			expectedStatus: fiber.StatusOK,
 			expectedBody: fiber.Map{
-				"message": "author updated",
+				"id":             "4dbec5df-c354-4c0a-8f33-7832dfbc12c0",
+				"full_name":      "John Doe",
+				"nick_name":      "johndoe123",
+				"specialization": "Writer",
+				"version":        float64(3),
+			},
+			expectedETag: `"3"`,
+		},
+		{
+			description: "if-match matches current version",
+			body: model.Author{
+				FullName: &fullName,
+			},
+			ifMatch:        `"2", "3"`,
+			expectedStatus: fiber.StatusOK,
+			expectedBody: fiber.Map{
+				"id":        "4dbec5df-c354-4c0a-8f33-7832dfbc12c0",
+				"full_name": "John Doe",
+				"version":   float64(3),
 			},
+			expectedETag: `"3"`,
+		},
+		{
+			description: "if-match is stale",
+			body: model.Author{
+				FullName: &fullName,
+			},
+			ifMatch:        `"2"`,
+			expectedStatus: fiber.StatusPreconditionFailed,
+			expectedBody: fiber.Map{
+				"error": "author was modified by another request",
+			},
+		},
+		{
+			description: "version changed during update",
+			body: model.Author{
+				FullName: &fullName,
+			},
+			expectedStatus: fiber.StatusPreconditionFailed,
+			expectedBody: fiber.Map{
+				"error": "author was modified by another request",
+			},
+			expectedUpdateError: model.ErrVersionConflict,
 		},
 		{
 			description:    "body parser error",
@@ -257,7 +403,7 @@ func TestAuthorHandler_Update(t *testing.T) {
 				NickName:       "johndoe123",
 				Specialization: "Writer",
 			},
-			expectedExistsError: errors.New("author not found"),
+			expectedExistsError: model.ErrNotFound,
 			expectedStatus:      fiber.StatusNotFound,
 			expectedBody: fiber.Map{
 				"error": "author not found",
@@ -283,8 +429,8 @@ func TestAuthorHandler_Update(t *testing.T) {
 
 			mockAuthorStore := new(MockAuthorStore)
 			authorHandler := &AuthorHandler{
-				store:  mockAuthorStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewCatalogService(mockAuthorStore, nil, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Patch("/author/:id", authorHandler.Update)
@@ -292,17 +438,21 @@ func TestAuthorHandler_Update(t *testing.T) {
 			body, err := json.Marshal(testCase.body)
 			assert.NoError(t, err)
 
-			mockAuthorStore.On("Exists", mock.Anything).Return(testCase.expectedExistsError).Once()
+			mockAuthorStore.On("GetByID", "4dbec5df-c354-4c0a-8f33-7832dfbc12c0").Return(existingAuthor(testCase.expectedExistsError), testCase.expectedExistsError).Once()
 
 			mockAuthorStore.On("Update", mock.Anything, mock.Anything).Return(testCase.expectedUpdateError).Once()
 
 			req := httptest.NewRequest(fiber.MethodPatch, "/author/4dbec5df-c354-4c0a-8f33-7832dfbc12c0", bytes.NewReader(body))
 			req.Header.Set("Content-Type", "application/json")
+			if testCase.ifMatch != "" {
+				req.Header.Set(fiber.HeaderIfMatch, testCase.ifMatch)
+			}
 
 			resp, err := app.Test(req, -1)
 			assert.NoError(t, err)
 
 			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+			assert.Equal(t, testCase.expectedETag, resp.Header.Get(fiber.HeaderETag))
 
 			respBody, err := io.ReadAll(resp.Body)
 			assert.NoError(t, err)
@@ -319,6 +469,8 @@ func TestAuthorHandler_Update(t *testing.T) {
 func TestAuthorHandler_Delete(t *testing.T) {
 	testCases := []struct {
 		description    string
+		ifMatch        string
+		getError       error
 		expectedStatus int
 		expectedBody   any
 		expectedError  error
@@ -330,13 +482,29 @@ func TestAuthorHandler_Delete(t *testing.T) {
 				"message": "author deleted",
 			},
 		},
+		{
+			description:    "if-match is stale",
+			ifMatch:        `"2"`,
+			expectedStatus: fiber.StatusPreconditionFailed,
+			expectedBody: fiber.Map{
+				"error": "author was modified by another request",
+			},
+		},
+		{
+			description:    "author not found",
+			getError:       model.ErrNotFound,
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody: fiber.Map{
+				"error": "author not found",
+			},
+		},
 		{
 			description:    "author still has books, can't delete",
 			expectedStatus: fiber.StatusBadRequest,
 			expectedBody: fiber.Map{
 				"message": "author has related recordings and cannot be deleted",
 			},
-			expectedError: errors.New("violates foreign key constraint"),
+			expectedError: model.ErrReferenced,
 		},
 		{
 			description:    "store error",
@@ -354,16 +522,20 @@ func TestAuthorHandler_Delete(t *testing.T) {
 
 			mockAuthorStore := new(MockAuthorStore)
 			authorHandler := &AuthorHandler{
-				store:  mockAuthorStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewCatalogService(mockAuthorStore, nil, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Delete("/author/:id", authorHandler.Delete)
 
+			mockAuthorStore.On("GetByID", "4dbec5df-c354-4c0a-8f33-7832dfbc12c0").Return(existingAuthor(testCase.getError), testCase.getError).Once()
 			mockAuthorStore.On("Delete", mock.Anything).Return(testCase.expectedError).Once()
 
 			req := httptest.NewRequest(fiber.MethodDelete, "/author/4dbec5df-c354-4c0a-8f33-7832dfbc12c0", nil)
 			req.Header.Set("Content-Type", "application/json")
+			if testCase.ifMatch != "" {
+				req.Header.Set(fiber.HeaderIfMatch, testCase.ifMatch)
+			}
 
 			resp, err := app.Test(req, -1)
 			assert.NoError(t, err)
@@ -419,8 +591,8 @@ func TestAuthorHandler_GetAuthorBooks(t *testing.T) {
 
 			mockAuthorStore := new(MockAuthorStore)
 			authorHandler := &AuthorHandler{
-				store:  mockAuthorStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewCatalogService(mockAuthorStore, nil, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Get("/author/:id/books", authorHandler.GetAuthorBooks)
@@ -454,3 +626,83 @@ func TestAuthorHandler_GetAuthorBooks(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthorHandler_Restore(t *testing.T) {
+	testCases := []struct {
+		description    string
+		restoreError   error
+		expectedStatus int
+		expectedETag   string
+		expectedBody   any
+		expectedAudits int
+	}{
+		{
+			description:    "author restored",
+			expectedStatus: fiber.StatusOK,
+			expectedETag:   `"3"`,
+			expectedBody: fiber.Map{
+				"id":        "4dbec5df-c354-4c0a-8f33-7832dfbc12c0",
+				"full_name": "Jane Doe",
+				"version":   float64(3),
+			},
+			expectedAudits: 1,
+		},
+		{
+			description:    "no deleted author with that id",
+			restoreError:   model.ErrNotFound,
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody: fiber.Map{
+				"error": "deleted author not found",
+			},
+		},
+		{
+			description:    "store error",
+			restoreError:   errors.New("server error"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody: fiber.Map{
+				"error": "server error",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockAuthorStore := new(MockAuthorStore)
+			mockAuditor := new(MockAuditor)
+			authorHandler := &AuthorHandler{
+				service: service.NewCatalogService(mockAuthorStore, nil, testTransactor{}, mockAuditor, hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
+			}
+
+			app.Post("/author/:id/restore", authorHandler.Restore)
+
+			mockAuthorStore.On("Restore", "4dbec5df-c354-4c0a-8f33-7832dfbc12c0").Return(testCase.restoreError).Once()
+			if testCase.restoreError == nil {
+				mockAuthorStore.On("GetByID", "4dbec5df-c354-4c0a-8f33-7832dfbc12c0").Return(existingAuthor(nil), nil).Once()
+			}
+
+			req := httptest.NewRequest(fiber.MethodPost, "/author/4dbec5df-c354-4c0a-8f33-7832dfbc12c0/restore", nil)
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+			assert.Equal(t, testCase.expectedETag, resp.Header.Get(fiber.HeaderETag))
+
+			respBody, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			var actual fiber.Map
+			err = json.Unmarshal(respBody, &actual)
+			assert.NoError(t, err)
+
+			for key, value := range testCase.expectedBody.(fiber.Map) {
+				assert.Equal(t, value, actual[key])
+			}
+			assert.Len(t, mockAuditor.records, testCase.expectedAudits)
+			mockAuthorStore.AssertExpectations(t)
+		})
+	}
+}
@@ -1,47 +1,54 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"library-api/internal/model"
-	"strings"
+	"library-api/internal/service"
+	"library-api/pkg/tracing"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
-type bookStore interface {
-	Create(book *model.Book) error
-	Get() ([]model.Book, error)
-	Delete(id string) error
-	Update(id string, book *model.Book) error
-	Exists(id string) error
+type bookService interface {
+	Books(ctx context.Context) ([]model.Book, error)
+	Catalog(ctx context.Context) ([]model.Book, error)
+	BooksByID(ctx context.Context, ids []string) ([]model.Book, error)
+	CreateBook(ctx context.Context, book *model.Book) error
+	UpdateBook(ctx context.Context, id string, book *model.Book, precondition service.Precondition) (*model.Book, error)
+	DeleteBook(ctx context.Context, id string, precondition service.Precondition) (*model.Book, error)
+	RestoreBook(ctx context.Context, id string) (*model.Book, error)
 }
 
 func (b *BookHandler) Create(c *fiber.Ctx) error {
 	var book model.Book
 	err := c.BodyParser(&book)
 	if err != nil {
-		b.logger.Error("book body parsing failed for create", "error", err.Error())
+		tracing.Logger(c.UserContext(), b.logger).Error("book body parsing failed for create", "error", err.Error())
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "book creation failed",
 		})
 	}
 
-	book.ID = uuid.New().String()
-	err = b.store.Create(&book)
+	err = b.service.CreateBook(c.UserContext(), &book)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "book creation failed",
 		})
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"id":      book.ID,
-		"message": "book created",
-	})
+	c.Location("/book/" + book.ID)
+	c.Set(fiber.HeaderETag, etag(book.Version))
+
+	return c.Status(fiber.StatusCreated).JSON(book)
 }
 
 func (b *BookHandler) Get(c *fiber.Ctx) error {
-	books, err := b.store.Get()
+	if wantsJSONLD(c) {
+		return b.getJSONLD(c)
+	}
+
+	books, err := b.service.Books(c.UserContext())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "server error",
@@ -49,7 +56,7 @@ func (b *BookHandler) Get(c *fiber.Ctx) error {
 	}
 
 	if len(books) == 0 {
-		b.logger.Info("", "info", "no books found")
+		tracing.Logger(c.UserContext(), b.logger).Info("", "info", "no books found")
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"message": "no books found",
 		})
@@ -58,41 +65,105 @@ func (b *BookHandler) Get(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(books)
 }
 
-func (b *BookHandler) Update(c *fiber.Ctx) error {
-	var book model.Book
-	err := c.BodyParser(&book)
+func (b *BookHandler) getJSONLD(c *fiber.Ctx) error {
+	books, err := b.service.Catalog(c.UserContext())
 	if err != nil {
-		b.logger.Error("book body parsing failed for update", "id", book.ID, "error", err.Error())
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "book update failed",
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	if len(books) == 0 {
+		tracing.Logger(c.UserContext(), b.logger).Info("", "info", "no books found")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "no books found",
 		})
 	}
 
+	return sendJSONLD(c, fiber.StatusOK, newLDItemList(books, func(book model.Book) any {
+		return newLDBook(book)
+	}))
+}
+
+func (b *BookHandler) GetByID(c *fiber.Ctx) error {
 	id := c.Params("id")
-	err = b.store.Exists(id)
+	books, err := b.service.BooksByID(c.UserContext(), []string{id})
 	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	if len(books) == 0 {
+		tracing.Logger(c.UserContext(), b.logger).Info("book not found", "id", id)
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "book not found",
 		})
 	}
 
-	err = b.store.Update(id, &book)
+	if notModified(c, books[0].Version) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	if wantsJSONLD(c) {
+		book := newLDBook(books[0])
+		book.Context = schemaContext
+		return sendJSONLD(c, fiber.StatusOK, book)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(books[0])
+}
+
+func (b *BookHandler) Update(c *fiber.Ctx) error {
+	var book model.Book
+	err := c.BodyParser(&book)
 	if err != nil {
+		tracing.Logger(c.UserContext(), b.logger).Error("book body parsing failed for update", "id", book.ID, "error", err.Error())
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "book update failed",
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "book updated",
-	})
+	id := c.Params("id")
+	_, err = b.service.UpdateBook(c.UserContext(), id, &book, precondition(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "book not found",
+			})
+		case errors.Is(err, service.ErrPreconditionFailed), errors.Is(err, model.ErrVersionConflict):
+			return preconditionFailed(c, "book was modified by another request")
+		case errors.Is(err, service.ErrRejected):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "book update failed",
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	book.ID = id
+
+	c.Set(fiber.HeaderETag, etag(book.Version))
+
+	return c.Status(fiber.StatusOK).JSON(book)
 }
 
 func (b *BookHandler) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
-	err := b.store.Delete(id)
+	_, err := b.service.DeleteBook(c.UserContext(), id, precondition(c))
 	if err != nil {
-		if strings.Contains(err.Error(), "violates foreign key constraint") {
+		switch {
+		case errors.Is(err, model.ErrNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "book not found",
+			})
+		case errors.Is(err, service.ErrPreconditionFailed):
+			return preconditionFailed(c, "book was modified by another request")
+		case errors.Is(err, model.ErrReferenced):
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"message": "book has related recordings and cannot be deleted",
 			})
@@ -107,3 +178,29 @@ func (b *BookHandler) Delete(c *fiber.Ctx) error {
 		"message": "book deleted",
 	})
 }
+
+func (b *BookHandler) Restore(c *fiber.Ctx) error {
+	id := c.Params("id")
+	book, err := b.service.RestoreBook(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "deleted book not found",
+			})
+		}
+
+		if errors.Is(err, model.ErrParentDeleted) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "author of the book is deleted, restore the author first",
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	c.Set(fiber.HeaderETag, etag(book.Version))
+
+	return c.Status(fiber.StatusOK).JSON(book)
+}
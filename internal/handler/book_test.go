@@ -2,10 +2,12 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"library-api/internal/model"
+	"library-api/internal/service"
 	"net/http/httptest"
 	"testing"
 
@@ -19,31 +21,41 @@ type MockBookStore struct {
 	mock.Mock
 }
 
-func (m *MockBookStore) Create(book *model.Book) error {
+func (m *MockBookStore) Create(ctx context.Context, book *model.Book) error {
 	args := m.Called(book)
 	return args.Error(0)
 }
 
-func (m *MockBookStore) Get() ([]model.Book, error) {
+func (m *MockBookStore) Get(ctx context.Context) ([]model.Book, error) {
 	args := m.Called()
 	return args.Get(0).([]model.Book), args.Error(1)
 }
 
-func (m *MockBookStore) Exists(id string) error {
-	args := m.Called(id)
+func (m *MockBookStore) Update(ctx context.Context, id string, book *model.Book) error {
+	args := m.Called(id, book)
 	return args.Error(0)
 }
 
-func (m *MockBookStore) Update(id string, book *model.Book) error {
-	args := m.Called(id, book)
+func (m *MockBookStore) Delete(ctx context.Context, id string) error {
+	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockBookStore) Delete(id string) error {
+func (m *MockBookStore) Restore(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockBookStore) GetByIDs(ctx context.Context, ids []string) ([]model.Book, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]model.Book), args.Error(1)
+}
+
+func (m *MockBookStore) GetCatalog(ctx context.Context) ([]model.Book, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Book), args.Error(1)
+}
+
 func TestBookHandler_Create(t *testing.T) {
 	testCases := []struct {
 		description    string
@@ -62,8 +74,12 @@ func TestBookHandler_Create(t *testing.T) {
 			},
 			expectedStatus: fiber.StatusCreated,
 			expectedBody: fiber.Map{
-				"id":      "dynamic ...",
-				"message": "book created",
+				"authors_id": "c3690e20-5950-4a41-aa68-13f0791cdf98",
+				"title":      "perfect book title",
+				"genre":      "fantasy",
+				"isbn":       "978-3-16-148410-0",
+				"author":     map[string]any{"full_name": nil},
+				"version":    float64(1),
 			},
 		},
 		{
@@ -94,13 +110,15 @@ func TestBookHandler_Create(t *testing.T) {
 
 			mockBookStore := new(MockBookStore)
 			bookHandler := &BookHandler{
-				store:  mockBookStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewCatalogService(nil, mockBookStore, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Post("/book", bookHandler.Create)
 
-			mockBookStore.On("Create", mock.Anything).Return(testCase.expectedError).Once()
+			mockBookStore.On("Create", mock.Anything).Return(testCase.expectedError).Once().Run(func(args mock.Arguments) {
+				args.Get(0).(*model.Book).Version = 1
+			})
 
 			body, err := json.Marshal(testCase.body)
 			assert.NoError(t, err)
@@ -120,11 +138,12 @@ func TestBookHandler_Create(t *testing.T) {
 			err = json.Unmarshal(respBody, &actual)
 			assert.NoError(t, err)
 
-			if testCase.expectedStatus != fiber.StatusCreated {
-				assert.Equal(t, testCase.expectedBody, actual)
-			} else {
-				assert.Equal(t, testCase.expectedBody.(fiber.Map)["message"].(string), actual["message"].(string))
+			if testCase.expectedStatus == fiber.StatusCreated {
+				assert.Equal(t, "/book/"+actual["id"].(string), resp.Header.Get(fiber.HeaderLocation))
+				delete(actual, "id")
 			}
+
+			assert.Equal(t, testCase.expectedBody, actual)
 		})
 	}
 }
@@ -183,8 +202,8 @@ func TestBookHandler_Get(t *testing.T) {
 			var mockBookStore MockBookStore
 
 			bookHandler := &BookHandler{
-				store:  &mockBookStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewCatalogService(nil, &mockBookStore, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Get("/books", bookHandler.Get)
@@ -224,10 +243,12 @@ func TestBookHandler_Update(t *testing.T) {
 		description         string
 		id                  string
 		body                any
-		expectedExistsError error
+		ifMatch             string
+		existing            []model.Book
 		expectedStatus      int
 		expectedBody        any
 		expectedUpdateError error
+		expectedETag        string
 	}{
 		{
 			description: "book update success",
@@ -240,9 +261,41 @@ func TestBookHandler_Update(t *testing.T) {
 			},
 			expectedStatus: fiber.StatusOK,
 			expectedBody: fiber.Map{
-				"message": "book updated",
+				"id":         "235fcd0e-98af-4af5-b985-68dab66085e1",
+				"authors_id": "c3690e20-5950-4a41-aa68-13f0791cdf98",
+				"title":      "perfect book title",
+				"genre":      "fantasy",
+				"isbn":       "978-3-16-148410-0",
+				"author":     map[string]any{"full_name": nil},
+				"version":    float64(5),
+			},
+			expectedETag: `"5"`,
+		},
+		{
+			description: "if-match is stale",
+			body: model.Book{
+				AuthorsID: "c3690e20-5950-4a41-aa68-13f0791cdf98",
+				Title:     "perfect book title",
+			},
+			ifMatch:        `"4"`,
+			expectedStatus: fiber.StatusPreconditionFailed,
+			expectedBody: fiber.Map{
+				"error": "book was modified by another request",
 			},
 		},
+		{
+			description: "version changed during update",
+			body: model.Book{
+				AuthorsID: "c3690e20-5950-4a41-aa68-13f0791cdf98",
+				Title:     "perfect book title",
+			},
+			ifMatch:        "*",
+			expectedStatus: fiber.StatusPreconditionFailed,
+			expectedBody: fiber.Map{
+				"error": "book was modified by another request",
+			},
+			expectedUpdateError: model.ErrVersionConflict,
+		},
 		{
 			description:    "body parser error",
 			body:           '{',
@@ -259,8 +312,8 @@ func TestBookHandler_Update(t *testing.T) {
 				Genre:     "fantasy",
 				ISBN:      "978-3-16-148410-0",
 			},
-			expectedExistsError: errors.New("book not found"),
-			expectedStatus:      fiber.StatusNotFound,
+			existing:       []model.Book{},
+			expectedStatus: fiber.StatusNotFound,
 			expectedBody: fiber.Map{
 				"error": "book not found",
 			},
@@ -287,8 +340,8 @@ func TestBookHandler_Update(t *testing.T) {
 
 			mockBookStore := new(MockBookStore)
 			bookHandler := &BookHandler{
-				store:  mockBookStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewCatalogService(nil, mockBookStore, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Patch("/book/:id", bookHandler.Update)
@@ -296,17 +349,25 @@ func TestBookHandler_Update(t *testing.T) {
 			body, err := json.Marshal(testCase.body)
 			assert.NoError(t, err)
 
-			mockBookStore.On("Exists", mock.Anything).Return(testCase.expectedExistsError).Once()
+			existing := testCase.existing
+			if existing == nil {
+				existing = []model.Book{{ID: "235fcd0e-98af-4af5-b985-68dab66085e1", Version: 5}}
+			}
+			mockBookStore.On("GetByIDs", []string{"235fcd0e-98af-4af5-b985-68dab66085e1"}).Return(existing, nil).Maybe()
 
 			mockBookStore.On("Update", mock.Anything, mock.Anything).Return(testCase.expectedUpdateError).Once()
 
 			req := httptest.NewRequest(fiber.MethodPatch, "/book/235fcd0e-98af-4af5-b985-68dab66085e1", bytes.NewReader(body))
 			req.Header.Set("Content-Type", "application/json")
+			if testCase.ifMatch != "" {
+				req.Header.Set(fiber.HeaderIfMatch, testCase.ifMatch)
+			}
 
 			resp, err := app.Test(req, -1)
 			assert.NoError(t, err)
 
 			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+			assert.Equal(t, testCase.expectedETag, resp.Header.Get(fiber.HeaderETag))
 
 			respBody, err := io.ReadAll(resp.Body)
 			assert.NoError(t, err)
@@ -323,9 +384,11 @@ func TestBookHandler_Update(t *testing.T) {
 func TestBookHandler_Delete(t *testing.T) {
 	testCases := []struct {
 		description    string
+		existing       []model.Book
 		expectedStatus int
 		expectedBody   any
 		expectedError  error
+		expectedAudits int
 	}{
 		{
 			description:    "book delete success",
@@ -333,6 +396,15 @@ func TestBookHandler_Delete(t *testing.T) {
 			expectedBody: fiber.Map{
 				"message": "book deleted",
 			},
+			expectedAudits: 1,
+		},
+		{
+			description:    "book not found",
+			existing:       []model.Book{},
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody: fiber.Map{
+				"error": "book not found",
+			},
 		},
 		{
 			description:    "book still has books, can't delete",
@@ -340,7 +412,7 @@ func TestBookHandler_Delete(t *testing.T) {
 			expectedBody: fiber.Map{
 				"message": "book has related recordings and cannot be deleted",
 			},
-			expectedError: errors.New("violates foreign key constraint"),
+			expectedError: model.ErrReferenced,
 		},
 		{
 			description:    "store error",
@@ -357,14 +429,20 @@ func TestBookHandler_Delete(t *testing.T) {
 			app := fiber.New()
 
 			mockBookStore := new(MockBookStore)
+			mockAuditor := new(MockAuditor)
 			bookHandler := &BookHandler{
-				store:  mockBookStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewCatalogService(nil, mockBookStore, testTransactor{}, mockAuditor, hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Delete("/book/:id", bookHandler.Delete)
 
-			mockBookStore.On("Delete", mock.Anything).Return(testCase.expectedError).Once()
+			existing := testCase.existing
+			if existing == nil {
+				existing = []model.Book{{ID: "235fcd0e-98af-4af5-b985-68dab66085e1"}}
+			}
+			mockBookStore.On("GetByIDs", []string{"235fcd0e-98af-4af5-b985-68dab66085e1"}).Return(existing, nil).Once()
+			mockBookStore.On("Delete", mock.Anything).Return(testCase.expectedError).Maybe()
 
 			req := httptest.NewRequest(fiber.MethodDelete, "/book/235fcd0e-98af-4af5-b985-68dab66085e1", nil)
 			req.Header.Set("Content-Type", "application/json")
@@ -382,6 +460,96 @@ func TestBookHandler_Delete(t *testing.T) {
 			assert.NoError(t, err)
 
 			assert.Equal(t, testCase.expectedBody, actual)
+			assert.Len(t, mockAuditor.records, testCase.expectedAudits)
+		})
+	}
+}
+
+func TestBookHandler_Restore(t *testing.T) {
+	testCases := []struct {
+		description    string
+		restoreError   error
+		expectedStatus int
+		expectedETag   string
+		expectedBody   any
+		expectedAudits int
+	}{
+		{
+			description:    "book restored",
+			expectedStatus: fiber.StatusOK,
+			expectedETag:   `"2"`,
+			expectedBody: fiber.Map{
+				"id":      "235fcd0e-98af-4af5-b985-68dab66085e1",
+				"title":   "Desert Stars",
+				"version": float64(2),
+			},
+			expectedAudits: 1,
+		},
+		{
+			description:    "no deleted book with that id",
+			restoreError:   model.ErrNotFound,
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody: fiber.Map{
+				"error": "deleted book not found",
+			},
+		},
+		{
+			description:    "author of the book is deleted",
+			restoreError:   model.ErrParentDeleted,
+			expectedStatus: fiber.StatusConflict,
+			expectedBody: fiber.Map{
+				"error": "author of the book is deleted, restore the author first",
+			},
+		},
+		{
+			description:    "store error",
+			restoreError:   errors.New("server error"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody: fiber.Map{
+				"error": "server error",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockBookStore := new(MockBookStore)
+			mockAuditor := new(MockAuditor)
+			bookHandler := &BookHandler{
+				service: service.NewCatalogService(nil, mockBookStore, testTransactor{}, mockAuditor, hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
+			}
+
+			app.Post("/book/:id/restore", bookHandler.Restore)
+
+			mockBookStore.On("Restore", "235fcd0e-98af-4af5-b985-68dab66085e1").Return(testCase.restoreError).Once()
+			if testCase.restoreError == nil {
+				mockBookStore.On("GetByIDs", []string{"235fcd0e-98af-4af5-b985-68dab66085e1"}).
+					Return([]model.Book{{ID: "235fcd0e-98af-4af5-b985-68dab66085e1", Title: "Desert Stars", Version: 2}}, nil).Once()
+			}
+
+			req := httptest.NewRequest(fiber.MethodPost, "/book/235fcd0e-98af-4af5-b985-68dab66085e1/restore", nil)
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+			assert.Equal(t, testCase.expectedETag, resp.Header.Get(fiber.HeaderETag))
+
+			respBody, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			var actual fiber.Map
+			err = json.Unmarshal(respBody, &actual)
+			assert.NoError(t, err)
+
+			for key, value := range testCase.expectedBody.(fiber.Map) {
+				assert.Equal(t, value, actual[key])
+			}
+			assert.Len(t, mockAuditor.records, testCase.expectedAudits)
+			mockBookStore.AssertExpectations(t)
 		})
 	}
 }
@@ -1,43 +1,85 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"library-api/internal/model"
+	"library-api/internal/service"
+	"library-api/pkg/tracing"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-type borrowedStore interface {
-	Create(book *model.Borrowed) error
-	Get(id string) ([]model.Book, error)
-	Delete(memberId string, bookId string) error
-	DeleteList(memberId string, books []string) error
+type circulationService interface {
+	Borrow(ctx context.Context, loan *model.Borrowed) error
+	Loans(ctx context.Context, memberID string) ([]model.Book, error)
+	Return(ctx context.Context, memberID string, bookID string) error
+	ReturnAll(ctx context.Context, memberID string, bookIDs []string) error
 }
 
+const (
+	codeMemberNotFound  = "member_not_found"
+	codeMemberSuspended = "member_suspended"
+	codeMemberExpired   = "member_expired"
+	codeBookNotFound    = "book_not_found"
+	codeLoanLimit       = "loan_limit_reached"
+)
+
 func (b *BorrowedHandler) Create(c *fiber.Ctx) error {
 	var borrowed model.Borrowed
 	err := c.BodyParser(&borrowed)
 	if err != nil {
-		b.logger.Error("parsing borrowed data", "error", err)
+		tracing.Logger(c.UserContext(), b.logger).Error("parsing borrowed data", "error", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "borrowed book creation failed",
 		})
 	}
 
-	err = b.store.Create(&borrowed)
-	if err != nil {
+	err = b.service.Borrow(c.UserContext(), &borrowed)
+	switch {
+	case errors.Is(err, service.ErrMemberNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "member not found",
+			"code":  codeMemberNotFound,
+		})
+	case errors.Is(err, service.ErrMemberSuspended):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "member is suspended",
+			"code":  codeMemberSuspended,
+		})
+	case errors.Is(err, service.ErrMembershipExpired):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "membership has expired",
+			"code":  codeMemberExpired,
+		})
+	case errors.Is(err, service.ErrLoanLimitReached):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "member has reached the loan limit",
+			"code":  codeLoanLimit,
+		})
+	case errors.Is(err, service.ErrBookNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "book not found",
+			"code":  codeBookNotFound,
+		})
+	case errors.Is(err, service.ErrRejected):
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "borrowed book creation failed",
 		})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message": "borrowed book created",
-	})
+	c.Location("/member/" + borrowed.MemberID + "/borrowed")
+
+	return c.Status(fiber.StatusCreated).JSON(borrowed)
 }
 
 func (b *BorrowedHandler) Get(c *fiber.Ctx) error {
 	id := c.Params("id")
-	books, err := b.store.Get(id)
+	books, err := b.service.Loans(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "server error",
@@ -45,7 +87,7 @@ func (b *BorrowedHandler) Get(c *fiber.Ctx) error {
 	}
 
 	if len(books) == 0 {
-		b.logger.Info("no books found for this member", "id", id)
+		tracing.Logger(c.UserContext(), b.logger).Info("no books found for this member", "id", id)
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"message": "no books found for this member",
 		})
@@ -57,8 +99,14 @@ func (b *BorrowedHandler) Get(c *fiber.Ctx) error {
 func (b *BorrowedHandler) Delete(c *fiber.Ctx) error {
 	memberId := c.Params("id")
 	bookId := c.Params("book_id")
-	err := b.store.Delete(memberId, bookId)
+	err := b.service.Return(c.UserContext(), memberId, bookId)
 	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "borrowed book not found",
+			})
+		}
+
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "server error",
 		})
@@ -73,15 +121,21 @@ func (b *BorrowedHandler) DeleteList(c *fiber.Ctx) error {
 	var books []string
 	err := c.BodyParser(&books)
 	if err != nil {
-		b.logger.Error("parsing borrowed data", "error", err)
+		tracing.Logger(c.UserContext(), b.logger).Error("parsing borrowed data", "error", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "borrowed book delete failed",
 		})
 	}
 
 	id := c.Params("id")
-	err = b.store.DeleteList(id, books)
+	err = b.service.ReturnAll(c.UserContext(), id, books)
 	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "borrowed books not found",
+			})
+		}
+
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "server error",
 		})
@@ -2,10 +2,12 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"library-api/internal/model"
+	"library-api/internal/service"
 	"net/http/httptest"
 	"testing"
 
@@ -19,30 +21,52 @@ type MockBorrowedStore struct {
 	mock.Mock
 }
 
-func (m *MockBorrowedStore) Create(borrowed *model.Borrowed) error {
+func (m *MockBorrowedStore) Create(ctx context.Context, borrowed *model.Borrowed) error {
 	args := m.Called(borrowed)
 	return args.Error(0)
 }
 
-func (m *MockBorrowedStore) Get(id string) ([]model.Book, error) {
+func (m *MockBorrowedStore) Get(ctx context.Context, id string) ([]model.Book, error) {
 	args := m.Called(id)
 	return args.Get(0).([]model.Book), args.Error(1)
 }
 
-func (m *MockBorrowedStore) Delete(memberId string, bookId string) error {
+func (m *MockBorrowedStore) Delete(ctx context.Context, memberId string, bookId string) error {
 	args := m.Called(memberId, bookId)
 	return args.Error(0)
 }
 
-func (m *MockBorrowedStore) DeleteList(memberId string, books []string) error {
+func (m *MockBorrowedStore) DeleteList(ctx context.Context, memberId string, books []string) ([]string, error) {
 	args := m.Called(memberId, books)
-	return args.Error(0)
+	deleted, _ := args.Get(0).([]string)
+	return deleted, args.Error(1)
+}
+
+func (m *MockBorrowedStore) MemberStatus(ctx context.Context, memberId string) (string, error) {
+	args := m.Called(memberId)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockBorrowedStore) Count(ctx context.Context, memberId string) (int, error) {
+	args := m.Called(memberId)
+	return args.Int(0), args.Error(1)
+}
+
+// testTransactor runs fn directly, the store mocks have no transaction to
+// take part in.
+type testTransactor struct{}
+
+func (testTransactor) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
 }
 
 func TestBorrowedHandler_Create(t *testing.T) {
 	testCases := []struct {
 		description    string
 		body           any
+		memberStatus   string
+		statusError    error
+		maxLoans       int
 		expectedStatus int
 		expectedBody   any
 		expectedError  error
@@ -55,7 +79,8 @@ func TestBorrowedHandler_Create(t *testing.T) {
 			},
 			expectedStatus: fiber.StatusCreated,
 			expectedBody: fiber.Map{
-				"message": "borrowed book created",
+				"member_id": "3c864c77-39a5-4157-9fb6-39d72be81669",
+				"book_id":   "5dee5c81-5ee4-44a9-97e5-0eb7955792a4",
 			},
 		},
 		{
@@ -79,6 +104,83 @@ func TestBorrowedHandler_Create(t *testing.T) {
 			},
 			expectedError: errors.New("borrowed creation failed"),
 		},
+		{
+			description: "book deleted or missing",
+			body: model.Borrowed{
+				MemberID: "3c864c77-39a5-4157-9fb6-39d72be81669",
+				BookID:   "5dee5c81-5ee4-44a9-97e5-0eb7955792a4",
+			},
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody: fiber.Map{
+				"error": "book not found",
+				"code":  "book_not_found",
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "member suspended",
+			body: model.Borrowed{
+				MemberID: "3c864c77-39a5-4157-9fb6-39d72be81669",
+				BookID:   "5dee5c81-5ee4-44a9-97e5-0eb7955792a4",
+			},
+			memberStatus:   model.MemberStatusSuspended,
+			expectedStatus: fiber.StatusForbidden,
+			expectedBody: fiber.Map{
+				"error": "member is suspended",
+				"code":  "member_suspended",
+			},
+		},
+		{
+			description: "membership expired",
+			body: model.Borrowed{
+				MemberID: "3c864c77-39a5-4157-9fb6-39d72be81669",
+				BookID:   "5dee5c81-5ee4-44a9-97e5-0eb7955792a4",
+			},
+			memberStatus:   model.MemberStatusExpired,
+			expectedStatus: fiber.StatusForbidden,
+			expectedBody: fiber.Map{
+				"error": "membership has expired",
+				"code":  "member_expired",
+			},
+		},
+		{
+			description: "loan limit reached",
+			body: model.Borrowed{
+				MemberID: "3c864c77-39a5-4157-9fb6-39d72be81669",
+				BookID:   "5dee5c81-5ee4-44a9-97e5-0eb7955792a4",
+			},
+			maxLoans:       3,
+			expectedStatus: fiber.StatusForbidden,
+			expectedBody: fiber.Map{
+				"error": "member has reached the loan limit",
+				"code":  "loan_limit_reached",
+			},
+		},
+		{
+			description: "member not found",
+			body: model.Borrowed{
+				MemberID: "3c864c77-39a5-4157-9fb6-39d72be81669",
+				BookID:   "5dee5c81-5ee4-44a9-97e5-0eb7955792a4",
+			},
+			statusError:    model.ErrNotFound,
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody: fiber.Map{
+				"error": "member not found",
+				"code":  "member_not_found",
+			},
+		},
+		{
+			description: "member status lookup failed",
+			body: model.Borrowed{
+				MemberID: "3c864c77-39a5-4157-9fb6-39d72be81669",
+				BookID:   "5dee5c81-5ee4-44a9-97e5-0eb7955792a4",
+			},
+			statusError:    errors.New("server error"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody: fiber.Map{
+				"error": "server error",
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -87,12 +189,20 @@ func TestBorrowedHandler_Create(t *testing.T) {
 
 			mockBorrowedStore := new(MockBorrowedStore)
 			borrowedHandler := &BorrowedHandler{
-				store:  mockBorrowedStore,
+				service: service.NewCirculationService(mockBorrowedStore, testTransactor{}, new(MockAuditor),
+					service.LoanPolicy{MaxLoans: testCase.maxLoans}, hclog.NewNullLogger()),
 				logger: hclog.NewNullLogger(),
 			}
 
 			app.Post("/member/borrowed", borrowedHandler.Create)
 
+			memberStatus := testCase.memberStatus
+			if memberStatus == "" {
+				memberStatus = model.MemberStatusActive
+			}
+
+			mockBorrowedStore.On("MemberStatus", "3c864c77-39a5-4157-9fb6-39d72be81669").Return(memberStatus, testCase.statusError).Once()
+			mockBorrowedStore.On("Count", "3c864c77-39a5-4157-9fb6-39d72be81669").Return(testCase.maxLoans, nil).Maybe()
 			mockBorrowedStore.On("Create", mock.Anything).Return(testCase.expectedError).Once()
 
 			body, err := json.Marshal(testCase.body)
@@ -105,6 +215,9 @@ func TestBorrowedHandler_Create(t *testing.T) {
 			assert.NoError(t, err)
 
 			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+			if testCase.expectedStatus == fiber.StatusCreated {
+				assert.Equal(t, "/member/3c864c77-39a5-4157-9fb6-39d72be81669/borrowed", resp.Header.Get(fiber.HeaderLocation))
+			}
 
 			respBody, err := io.ReadAll(resp.Body)
 			assert.NoError(t, err)
@@ -172,8 +285,8 @@ func TestBorrowedHandler_Get(t *testing.T) {
 			var mockBorrowedStore MockBorrowedStore
 
 			borrowedHandler := &BorrowedHandler{
-				store:  &mockBorrowedStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewCirculationService(&mockBorrowedStore, testTransactor{}, new(MockAuditor), service.LoanPolicy{}, hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Get("/member/:id/borrowed", borrowedHandler.Get)
@@ -214,6 +327,7 @@ func TestBorrowedHandler_Delete(t *testing.T) {
 		expectedStatus int
 		expectedBody   any
 		expectedError  error
+		expectedAudits int
 	}{
 		{
 			description:    "borrowed delete success",
@@ -221,6 +335,15 @@ func TestBorrowedHandler_Delete(t *testing.T) {
 			expectedBody: fiber.Map{
 				"message": "borrowed book deleted",
 			},
+			expectedAudits: 1,
+		},
+		{
+			description:    "book not on loan",
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody: fiber.Map{
+				"error": "borrowed book not found",
+			},
+			expectedError: model.ErrNotFound,
 		},
 		{
 			description:    "store error",
@@ -237,9 +360,10 @@ func TestBorrowedHandler_Delete(t *testing.T) {
 			app := fiber.New()
 
 			mockBorrowedStore := new(MockBorrowedStore)
+			mockAuditor := new(MockAuditor)
 			borrowedHandler := &BorrowedHandler{
-				store:  mockBorrowedStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewCirculationService(mockBorrowedStore, testTransactor{}, mockAuditor, service.LoanPolicy{}, hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Delete("/member/:id/borrowed/:book_id", borrowedHandler.Delete)
@@ -262,6 +386,7 @@ func TestBorrowedHandler_Delete(t *testing.T) {
 			assert.NoError(t, err)
 
 			assert.Equal(t, testCase.expectedBody, actual)
+			assert.Len(t, mockAuditor.records, testCase.expectedAudits)
 		})
 	}
 }
@@ -270,17 +395,30 @@ func TestBorrowedHandler_DeleteList(t *testing.T) {
 	testCases := []struct {
 		description    string
 		body           any
+		deleted        []string
 		expectedStatus int
 		expectedBody   any
 		expectedError  error
+		expectedAudits int
 	}{
 		{
 			description:    "borrowed delete list success",
 			body:           []string{"1652979f-bc46-44ea-ba2b-51e08f608021", "81790db6-a440-48e2-9951-d5fcf359fd7c"},
+			deleted:        []string{"81790db6-a440-48e2-9951-d5fcf359fd7c"},
 			expectedStatus: fiber.StatusOK,
 			expectedBody: fiber.Map{
 				"message": "borrowed books deleted",
 			},
+			expectedAudits: 1,
+		},
+		{
+			description:    "none of the books on loan",
+			body:           []string{"1652979f-bc46-44ea-ba2b-51e08f608021"},
+			deleted:        []string{},
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody: fiber.Map{
+				"error": "borrowed books not found",
+			},
 		},
 		{
 			description:    "body parser error",
@@ -305,9 +443,10 @@ func TestBorrowedHandler_DeleteList(t *testing.T) {
 			app := fiber.New()
 
 			mockBorrowedStore := new(MockBorrowedStore)
+			mockAuditor := new(MockAuditor)
 			borrowedHandler := &BorrowedHandler{
-				store:  mockBorrowedStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewCirculationService(mockBorrowedStore, testTransactor{}, mockAuditor, service.LoanPolicy{}, hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Delete("/member/:id/borrowed", borrowedHandler.DeleteList)
@@ -315,7 +454,7 @@ func TestBorrowedHandler_DeleteList(t *testing.T) {
 			body, err := json.Marshal(testCase.body)
 			assert.NoError(t, err)
 
-			mockBorrowedStore.On("DeleteList", mock.Anything, mock.Anything).Return(testCase.expectedError).Once()
+			mockBorrowedStore.On("DeleteList", mock.Anything, mock.Anything).Return(testCase.deleted, testCase.expectedError).Once()
 
 			req := httptest.NewRequest(fiber.MethodDelete, "/member/1de94d3e-09b2-4f62-bfff-964012c649d3/borrowed", bytes.NewReader(body))
 			req.Header.Set("Content-Type", "application/json")
@@ -333,6 +472,7 @@ func TestBorrowedHandler_DeleteList(t *testing.T) {
 			assert.NoError(t, err)
 
 			assert.Equal(t, testCase.expectedBody, actual)
+			assert.Len(t, mockAuditor.records, testCase.expectedAudits)
 		})
 	}
 }
@@ -0,0 +1,306 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+	"strings"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	citationStyleBibTeX  = "bibtex"
+	citationStyleRIS     = "ris"
+	citationStyleCSLJSON = "csl-json"
+)
+
+// maxCitationIDs bounds the books a single unauthenticated citation request
+// can ask for.
+const maxCitationIDs = 100
+
+// risNewlines replaces line breaks, which would end an RIS tag early and let
+// a value start a tag of its own.
+var risNewlines = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+var citationContentTypes = map[string]string{
+	citationStyleBibTeX:  "application/x-bibtex; charset=utf-8",
+	citationStyleRIS:     "application/x-research-info-systems; charset=utf-8",
+	citationStyleCSLJSON: "application/vnd.citationstyles.csl+json; charset=utf-8",
+}
+
+type cslName struct {
+	Family  string `json:"family,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Literal string `json:"literal,omitempty"`
+}
+
+type cslItem struct {
+	ID     string    `json:"id"`
+	Type   string    `json:"type"`
+	Title  string    `json:"title"`
+	Author []cslName `json:"author,omitempty"`
+	ISBN   string    `json:"ISBN,omitempty"`
+	Genre  string    `json:"genre,omitempty"`
+}
+
+func (b *BookHandler) Cite(c *fiber.Ctx) error {
+	id := c.Params("id")
+	style := c.Query("style", citationStyleBibTeX)
+	if _, ok := citationContentTypes[style]; !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "unsupported citation style",
+		})
+	}
+
+	if uuid.Validate(id) != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid book id",
+		})
+	}
+
+	books, err := b.service.BooksByID(c.UserContext(), []string{id})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	if len(books) == 0 {
+		tracing.Logger(c.UserContext(), b.logger).Info("book not found for citation", "id", id)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "book not found",
+		})
+	}
+
+	return b.sendCitations(c, style, books)
+}
+
+func (b *BookHandler) CiteList(c *fiber.Ctx) error {
+	style := c.Query("style", citationStyleBibTeX)
+	if _, ok := citationContentTypes[style]; !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "unsupported citation style",
+		})
+	}
+
+	var ids []string
+	err := c.BodyParser(&ids)
+	if err != nil || len(ids) == 0 {
+		tracing.Logger(c.UserContext(), b.logger).Error("parsing book ids for citation failed", "error", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "book ids are required",
+		})
+	}
+
+	if len(ids) > maxCitationIDs {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("at most %d book ids can be cited at once", maxCitationIDs),
+		})
+	}
+
+	for _, id := range ids {
+		if uuid.Validate(id) != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid book id: " + id,
+			})
+		}
+	}
+
+	books, err := b.service.BooksByID(c.UserContext(), ids)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	if len(books) == 0 {
+		tracing.Logger(c.UserContext(), b.logger).Info("no books found for citation", "ids", ids)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "no books found",
+		})
+	}
+
+	return b.sendCitations(c, style, books)
+}
+
+func (b *BookHandler) sendCitations(c *fiber.Ctx, style string, books []model.Book) error {
+	var body string
+	switch style {
+	case citationStyleBibTeX:
+		body = formatBibTeX(books)
+	case citationStyleRIS:
+		body = formatRIS(books)
+	case citationStyleCSLJSON:
+		data, err := formatCSLJSON(books)
+		if err != nil {
+			tracing.Logger(c.UserContext(), b.logger).Error("csl-json marshalling failed", "error", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "server error",
+			})
+		}
+		body = string(data)
+	}
+
+	c.Set(fiber.HeaderContentType, citationContentTypes[style])
+	return c.Status(fiber.StatusOK).SendString(body)
+}
+
+func formatBibTeX(books []model.Book) string {
+	var sb strings.Builder
+	keys := make(map[string]int)
+
+	for i, book := range books {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+
+		key := bibTeXKey(book)
+		keys[key]++
+		if keys[key] > 1 {
+			key = fmt.Sprintf("%s%d", key, keys[key])
+		}
+
+		fmt.Fprintf(&sb, "@book{%s,\n", key)
+		if name := authorName(book.Author); name != "" {
+			fmt.Fprintf(&sb, "  author = {%s},\n", escapeBibTeX(invertName(name)))
+		}
+		fmt.Fprintf(&sb, "  title = {%s},\n", escapeBibTeX(book.Title))
+		if book.ISBN != "" {
+			fmt.Fprintf(&sb, "  isbn = {%s},\n", escapeBibTeX(book.ISBN))
+		}
+		if book.Genre != "" {
+			fmt.Fprintf(&sb, "  keywords = {%s},\n", escapeBibTeX(book.Genre))
+		}
+		sb.WriteString("}\n")
+	}
+
+	return sb.String()
+}
+
+func formatRIS(books []model.Book) string {
+	var sb strings.Builder
+
+	for _, book := range books {
+		sb.WriteString("TY  - BOOK\r\n")
+		if name := authorName(book.Author); name != "" {
+			fmt.Fprintf(&sb, "AU  - %s\r\n", risNewlines.Replace(invertName(name)))
+		}
+		fmt.Fprintf(&sb, "TI  - %s\r\n", risNewlines.Replace(book.Title))
+		if book.ISBN != "" {
+			fmt.Fprintf(&sb, "SN  - %s\r\n", risNewlines.Replace(book.ISBN))
+		}
+		if book.Genre != "" {
+			fmt.Fprintf(&sb, "KW  - %s\r\n", risNewlines.Replace(book.Genre))
+		}
+		fmt.Fprintf(&sb, "ID  - %s\r\n", book.ID)
+		sb.WriteString("ER  - \r\n")
+	}
+
+	return sb.String()
+}
+
+func formatCSLJSON(books []model.Book) ([]byte, error) {
+	items := make([]cslItem, 0, len(books))
+
+	for _, book := range books {
+		item := cslItem{
+			ID:    book.ID,
+			Type:  "book",
+			Title: book.Title,
+			ISBN:  book.ISBN,
+			Genre: book.Genre,
+		}
+
+		if name := authorName(book.Author); name != "" {
+			given, family := splitName(name)
+			if family == "" {
+				item.Author = []cslName{{Literal: given}}
+			} else {
+				item.Author = []cslName{{Family: family, Given: given}}
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	return json.Marshal(items)
+}
+
+func authorName(author model.Author) string {
+	if author.FullName == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(*author.FullName)
+}
+
+func splitName(name string) (string, string) {
+	i := strings.LastIndex(name, " ")
+	if i < 0 {
+		return name, ""
+	}
+
+	return strings.TrimSpace(name[:i]), name[i+1:]
+}
+
+func invertName(name string) string {
+	given, family := splitName(name)
+	if family == "" {
+		return given
+	}
+
+	return family + ", " + given
+}
+
+func bibTeXKey(book model.Book) string {
+	_, family := splitName(authorName(book.Author))
+	if family == "" {
+		family = authorName(book.Author)
+	}
+
+	var word string
+	for _, w := range strings.Fields(book.Title) {
+		lw := strings.ToLower(w)
+		if lw != "a" && lw != "an" && lw != "the" {
+			word = w
+			break
+		}
+	}
+
+	key := keyPart(family) + keyPart(word)
+	if key == "" {
+		return "book"
+	}
+
+	return key
+}
+
+func keyPart(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
+
+func escapeBibTeX(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\textbackslash{}`,
+		`{`, `\{`,
+		`}`, `\}`,
+		`&`, `\&`,
+		`%`, `\%`,
+		`$`, `\$`,
+		`#`, `\#`,
+		`_`, `\_`,
+	)
+
+	return replacer.Replace(s)
+}
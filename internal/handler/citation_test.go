@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"library-api/internal/model"
+	"library-api/internal/service"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func citationBooks() []model.Book {
+	fullName := "Stephen King"
+	return []model.Book{
+		{
+			ID:        "e11f8107-880b-49c2-85b2-c780e7929978",
+			AuthorsID: "4ce0ddc1-ed52-4173-8e82-e32926ddff2e",
+			Title:     "The Shining",
+			Genre:     "Horror",
+			ISBN:      "978-0-385-12167-5",
+			Author: model.Author{
+				ID:       "4ce0ddc1-ed52-4173-8e82-e32926ddff2e",
+				FullName: &fullName,
+			},
+		},
+	}
+}
+
+func TestFormatBibTeX(t *testing.T) {
+	books := citationBooks()
+	books = append(books, books[0])
+
+	expected := "@book{kingshining,\n" +
+		"  author = {King, Stephen},\n" +
+		"  title = {The Shining},\n" +
+		"  isbn = {978-0-385-12167-5},\n" +
+		"  keywords = {Horror},\n" +
+		"}\n" +
+		"\n" +
+		"@book{kingshining2,\n" +
+		"  author = {King, Stephen},\n" +
+		"  title = {The Shining},\n" +
+		"  isbn = {978-0-385-12167-5},\n" +
+		"  keywords = {Horror},\n" +
+		"}\n"
+
+	assert.Equal(t, expected, formatBibTeX(books))
+}
+
+func TestFormatRIS(t *testing.T) {
+	expected := "TY  - BOOK\r\n" +
+		"AU  - King, Stephen\r\n" +
+		"TI  - The Shining\r\n" +
+		"SN  - 978-0-385-12167-5\r\n" +
+		"KW  - Horror\r\n" +
+		"ID  - e11f8107-880b-49c2-85b2-c780e7929978\r\n" +
+		"ER  - \r\n"
+
+	assert.Equal(t, expected, formatRIS(citationBooks()))
+}
+
+func TestFormatRIS_Newlines(t *testing.T) {
+	books := citationBooks()
+	books[0].Title = "The Shining\r\nER  - \nTY  - JOUR"
+	books[0].Genre = "Horror\rFiction"
+
+	ris := formatRIS(books)
+
+	assert.Contains(t, ris, "TI  - The Shining ER  -  TY  - JOUR\r\n")
+	assert.Contains(t, ris, "KW  - Horror Fiction\r\n")
+	assert.Equal(t, 1, strings.Count(ris, "\r\nER  - "))
+}
+
+func TestFormatCSLJSON(t *testing.T) {
+	data, err := formatCSLJSON(citationBooks())
+	assert.NoError(t, err)
+
+	expected := `[{"id":"e11f8107-880b-49c2-85b2-c780e7929978","type":"book","title":"The Shining",` +
+		`"author":[{"family":"King","given":"Stephen"}],"ISBN":"978-0-385-12167-5","genre":"Horror"}]`
+
+	assert.JSONEq(t, expected, string(data))
+}
+
+func TestEscapeBibTeX(t *testing.T) {
+	assert.Equal(t, `Tom \& Jerry: 100\% \{fun\}`, escapeBibTeX("Tom & Jerry: 100% {fun}"))
+}
+
+func TestBookHandler_Cite(t *testing.T) {
+	testCases := []struct {
+		description         string
+		id                  string
+		style               string
+		books               []model.Book
+		expectedError       error
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        fiber.Map
+	}{
+		{
+			description:         "bibtex by default",
+			books:               citationBooks(),
+			expectedStatus:      fiber.StatusOK,
+			expectedContentType: "application/x-bibtex; charset=utf-8",
+		},
+		{
+			description:         "ris",
+			style:               "ris",
+			books:               citationBooks(),
+			expectedStatus:      fiber.StatusOK,
+			expectedContentType: "application/x-research-info-systems; charset=utf-8",
+		},
+		{
+			description:         "csl-json",
+			style:               "csl-json",
+			books:               citationBooks(),
+			expectedStatus:      fiber.StatusOK,
+			expectedContentType: "application/vnd.citationstyles.csl+json; charset=utf-8",
+		},
+		{
+			description:    "unsupported style",
+			style:          "apa",
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: fiber.Map{
+				"error": "unsupported citation style",
+			},
+		},
+		{
+			description:    "invalid id",
+			id:             "not-a-uuid",
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: fiber.Map{
+				"error": "invalid book id",
+			},
+		},
+		{
+			description:    "book not found",
+			books:          []model.Book{},
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody: fiber.Map{
+				"error": "book not found",
+			},
+		},
+		{
+			description:    "store error",
+			books:          []model.Book{},
+			expectedError:  errors.New("server error"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody: fiber.Map{
+				"error": "server error",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockBookStore := new(MockBookStore)
+			bookHandler := &BookHandler{
+				service: service.NewCatalogService(nil, mockBookStore, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
+			}
+
+			app.Get("/book/:id/cite", bookHandler.Cite)
+
+			mockBookStore.On("GetByIDs", []string{"e11f8107-880b-49c2-85b2-c780e7929978"}).
+				Return(testCase.books, testCase.expectedError).Once()
+
+			id := "e11f8107-880b-49c2-85b2-c780e7929978"
+			if testCase.id != "" {
+				id = testCase.id
+			}
+
+			target := "/book/" + id + "/cite"
+			if testCase.style != "" {
+				target += "?style=" + testCase.style
+			}
+
+			req := httptest.NewRequest(fiber.MethodGet, target, nil)
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+
+			respBody, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			if testCase.expectedStatus == fiber.StatusOK {
+				assert.Equal(t, testCase.expectedContentType, resp.Header.Get(fiber.HeaderContentType))
+				assert.NotEmpty(t, respBody)
+			} else {
+				var actual fiber.Map
+				err = json.Unmarshal(respBody, &actual)
+				assert.NoError(t, err)
+
+				assert.Equal(t, testCase.expectedBody, actual)
+			}
+		})
+	}
+}
+
+func TestBookHandler_CiteList(t *testing.T) {
+	testCases := []struct {
+		description    string
+		body           any
+		books          []model.Book
+		expectedError  error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			description:    "ris for several books",
+			body:           []string{"e11f8107-880b-49c2-85b2-c780e7929978"},
+			books:          citationBooks(),
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   formatRIS(citationBooks()),
+		},
+		{
+			description:    "empty list",
+			body:           []string{},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   `{"error":"book ids are required"}`,
+		},
+		{
+			description:    "invalid id",
+			body:           []string{"e11f8107-880b-49c2-85b2-c780e7929978", "1; DROP TABLE books"},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   `{"error":"invalid book id: 1; DROP TABLE books"}`,
+		},
+		{
+			description:    "too many ids",
+			body:           make([]string, maxCitationIDs+1),
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   `{"error":"at most 100 book ids can be cited at once"}`,
+		},
+		{
+			description:    "no books found",
+			body:           []string{"e11f8107-880b-49c2-85b2-c780e7929978"},
+			books:          []model.Book{},
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   `{"error":"no books found"}`,
+		},
+		{
+			description:    "store error",
+			body:           []string{"e11f8107-880b-49c2-85b2-c780e7929978"},
+			books:          []model.Book{},
+			expectedError:  errors.New("server error"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody:   `{"error":"server error"}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockBookStore := new(MockBookStore)
+			bookHandler := &BookHandler{
+				service: service.NewCatalogService(nil, mockBookStore, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
+			}
+
+			app.Post("/books/cite", bookHandler.CiteList)
+
+			mockBookStore.On("GetByIDs", mock.Anything).Return(testCase.books, testCase.expectedError).Once()
+
+			body, err := json.Marshal(testCase.body)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(fiber.MethodPost, "/books/cite?style=ris", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+
+			respBody, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedBody, string(respBody))
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"library-api/internal/service"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// etagMatches reports whether a list of entity tags from a conditional
+// header contains the tag of the given version. If-Match requires the strong
+// comparison, so weak tags only match when weak is set.
+func etagMatches(header string, version int, weak bool) bool {
+	tag := etag(version)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+
+		if candidate == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatch reports whether the request may modify the given version, which
+// is the case when it has no If-Match header or one that lists the version.
+func ifMatch(c *fiber.Ctx, version int) bool {
+	header := c.Get(fiber.HeaderIfMatch)
+	return header == "" || etagMatches(header, version, false)
+}
+
+func preconditionFailed(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+		"error": message,
+	})
+}
+
+// notModified sets the ETag of the current version and reports whether
+// If-None-Match allows a 304 response instead of the representation.
+func notModified(c *fiber.Ctx, version int) bool {
+	c.Set(fiber.HeaderETag, etag(version))
+
+	header := c.Get(fiber.HeaderIfNoneMatch)
+	return header != "" && etagMatches(header, version, true)
+}
+
+// precondition hands the If-Match check to a service, which applies it to
+// the version it reads inside its transaction.
+func precondition(c *fiber.Ctx) service.Precondition {
+	return func(version int) bool {
+		return ifMatch(c, version)
+	}
+}
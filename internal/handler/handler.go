@@ -1,51 +1,130 @@
 package handler
 
-import "github.com/hashicorp/go-hclog"
+import (
+	"library-api/internal/auth"
+	"library-api/internal/notify"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
 
 type AuthorHandler struct {
-	store  authorStore
-	logger hclog.Logger
+	service authorService
+	logger  hclog.Logger
 }
 
-func NewAuthorHandler(store authorStore, logger hclog.Logger) *AuthorHandler {
+func NewAuthorHandler(service authorService, logger hclog.Logger) *AuthorHandler {
 	return &AuthorHandler{
-		store:  store,
-		logger: logger,
+		service: service,
+		logger:  logger,
 	}
 }
 
 type BookHandler struct {
-	store  bookStore
-	logger hclog.Logger
+	service bookService
+	logger  hclog.Logger
 }
 
-func NewBookHandler(store bookStore, logger hclog.Logger) *BookHandler {
+func NewBookHandler(service bookService, logger hclog.Logger) *BookHandler {
 	return &BookHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+type BorrowedHandler struct {
+	service circulationService
+	logger  hclog.Logger
+}
+
+func NewBorrowedHandler(service circulationService, logger hclog.Logger) *BorrowedHandler {
+	return &BorrowedHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+type MemberHandler struct {
+	service memberService
+	logger  hclog.Logger
+}
+
+func NewMemberHandler(service memberService, logger hclog.Logger) *MemberHandler {
+	return &MemberHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+type SRUHandler struct {
+	store  sruStore
+	logger hclog.Logger
+}
+
+func NewSRUHandler(store sruStore, logger hclog.Logger) *SRUHandler {
+	return &SRUHandler{
 		store:  store,
 		logger: logger,
 	}
 }
 
-type BorrowedHandler struct {
-	store  borrowedStore
+type APIKeyHandler struct {
+	store  apiKeyStore
 	logger hclog.Logger
 }
 
-func NewBorrowedHandler(store borrowedStore, logger hclog.Logger) *BorrowedHandler {
-	return &BorrowedHandler{
+func NewAPIKeyHandler(store apiKeyStore, logger hclog.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
 		store:  store,
 		logger: logger,
 	}
 }
 
-type MemberHandler struct {
-	store  memberStore
+type AuthHandler struct {
+	store      accountStore
+	members    memberService
+	signer     *auth.Signer
+	notifier   notify.Notifier
+	refreshTTL time.Duration
+	resetTTL   time.Duration
+	logger     hclog.Logger
+}
+
+func NewAuthHandler(store accountStore, members memberService, signer *auth.Signer, notifier notify.Notifier,
+	refreshTTL time.Duration, resetTTL time.Duration, logger hclog.Logger) *AuthHandler {
+	return &AuthHandler{
+		store:      store,
+		members:    members,
+		signer:     signer,
+		notifier:   notifier,
+		refreshTTL: refreshTTL,
+		resetTTL:   resetTTL,
+		logger:     logger,
+	}
+}
+
+type AuditHandler struct {
+	store  auditStore
 	logger hclog.Logger
 }
 
-func NewMemberHandler(store memberStore, logger hclog.Logger) *MemberHandler {
-	return &MemberHandler{
+func NewAuditHandler(store auditStore, logger hclog.Logger) *AuditHandler {
+	return &AuditHandler{
 		store:  store,
 		logger: logger,
 	}
 }
+
+type PurgeHandler struct {
+	service   retentionService
+	retention time.Duration
+	logger    hclog.Logger
+}
+
+func NewPurgeHandler(service retentionService, retention time.Duration, logger hclog.Logger) *PurgeHandler {
+	return &PurgeHandler{
+		service:   service,
+		retention: retention,
+		logger:    logger,
+	}
+}
@@ -1,56 +1,130 @@
 package handler
 
 import (
+	"library-api/internal/auth"
+	"library-api/internal/service"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestNewAuthorHandler(t *testing.T) {
-	mockAuthorStore := new(MockAuthorStore)
-	actualAuthorHandler := NewAuthorHandler(mockAuthorStore, hclog.NewNullLogger())
+	catalog := service.NewCatalogService(new(MockAuthorStore), new(MockBookStore), testTransactor{}, new(MockAuditor), hclog.NewNullLogger())
+	actualAuthorHandler := NewAuthorHandler(catalog, hclog.NewNullLogger())
 
 	expectedAuthorHandler := &AuthorHandler{
-		store:  mockAuthorStore,
-		logger: hclog.NewNullLogger(),
+		service: catalog,
+		logger:  hclog.NewNullLogger(),
 	}
 
 	assert.Equal(t, expectedAuthorHandler, actualAuthorHandler)
 }
 
 func TestNewBookHandler(t *testing.T) {
-	mockBookStore := new(MockBookStore)
-	actualBookHandler := NewBookHandler(mockBookStore, hclog.NewNullLogger())
+	catalog := service.NewCatalogService(new(MockAuthorStore), new(MockBookStore), testTransactor{}, new(MockAuditor), hclog.NewNullLogger())
+	actualBookHandler := NewBookHandler(catalog, hclog.NewNullLogger())
 
 	expectedBookHandler := &BookHandler{
-		store:  mockBookStore,
-		logger: hclog.NewNullLogger(),
+		service: catalog,
+		logger:  hclog.NewNullLogger(),
 	}
 
 	assert.Equal(t, expectedBookHandler, actualBookHandler)
 }
 
 func TestNewMemberHandler(t *testing.T) {
-	mockMemberStore := new(MockMemberStore)
-	actualMemberHandler := NewMemberHandler(mockMemberStore, hclog.NewNullLogger())
+	membership := service.NewMembershipService(new(MockMemberStore), testTransactor{}, new(MockAuditor), hclog.NewNullLogger())
+	actualMemberHandler := NewMemberHandler(membership, hclog.NewNullLogger())
 
 	expectedMemberHandler := &MemberHandler{
-		store:  mockMemberStore,
-		logger: hclog.NewNullLogger(),
+		service: membership,
+		logger:  hclog.NewNullLogger(),
 	}
 
 	assert.Equal(t, expectedMemberHandler, actualMemberHandler)
 }
 
 func TestNewBorrowedHandler(t *testing.T) {
-	mockBorrowedStore := new(MockBorrowedStore)
-	actualBorrowedHandler := NewBorrowedHandler(mockBorrowedStore, hclog.NewNullLogger())
+	circulation := service.NewCirculationService(new(MockBorrowedStore), testTransactor{}, new(MockAuditor), service.LoanPolicy{}, hclog.NewNullLogger())
+	actualBorrowedHandler := NewBorrowedHandler(circulation, hclog.NewNullLogger())
 
 	expectedBorrowedHandler := &BorrowedHandler{
-		store:  mockBorrowedStore,
-		logger: hclog.NewNullLogger(),
+		service: circulation,
+		logger:  hclog.NewNullLogger(),
 	}
 
 	assert.Equal(t, expectedBorrowedHandler, actualBorrowedHandler)
 }
+
+func TestNewSRUHandler(t *testing.T) {
+	mockSRUStore := new(MockSRUStore)
+	actualSRUHandler := NewSRUHandler(mockSRUStore, hclog.NewNullLogger())
+
+	expectedSRUHandler := &SRUHandler{
+		store:  mockSRUStore,
+		logger: hclog.NewNullLogger(),
+	}
+
+	assert.Equal(t, expectedSRUHandler, actualSRUHandler)
+}
+
+func TestNewAPIKeyHandler(t *testing.T) {
+	mockAPIKeyStore := new(MockAPIKeyStore)
+	actualAPIKeyHandler := NewAPIKeyHandler(mockAPIKeyStore, hclog.NewNullLogger())
+
+	expectedAPIKeyHandler := &APIKeyHandler{
+		store:  mockAPIKeyStore,
+		logger: hclog.NewNullLogger(),
+	}
+
+	assert.Equal(t, expectedAPIKeyHandler, actualAPIKeyHandler)
+}
+
+func TestNewAuthHandler(t *testing.T) {
+	mockAccountStore := new(MockAccountStore)
+	mockNotifier := new(MockNotifier)
+	signer, err := auth.NewSigner("secret", "", "", "", time.Minute)
+	assert.NoError(t, err)
+
+	membership := service.NewMembershipService(new(MockMemberStore), testTransactor{}, new(MockAuditor), hclog.NewNullLogger())
+	actualAuthHandler := NewAuthHandler(mockAccountStore, membership, signer, mockNotifier, time.Hour, time.Minute, hclog.NewNullLogger())
+
+	expectedAuthHandler := &AuthHandler{
+		store:      mockAccountStore,
+		members:    membership,
+		signer:     signer,
+		notifier:   mockNotifier,
+		refreshTTL: time.Hour,
+		resetTTL:   time.Minute,
+		logger:     hclog.NewNullLogger(),
+	}
+
+	assert.Equal(t, expectedAuthHandler, actualAuthHandler)
+}
+
+func TestNewAuditHandler(t *testing.T) {
+	mockAuditStore := new(MockAuditStore)
+	actualAuditHandler := NewAuditHandler(mockAuditStore, hclog.NewNullLogger())
+
+	expectedAuditHandler := &AuditHandler{
+		store:  mockAuditStore,
+		logger: hclog.NewNullLogger(),
+	}
+
+	assert.Equal(t, expectedAuditHandler, actualAuditHandler)
+}
+
+func TestNewPurgeHandler(t *testing.T) {
+	retention := service.NewRetentionService(new(MockPurgeStore), testTransactor{}, new(MockAuditor), hclog.NewNullLogger())
+	actualPurgeHandler := NewPurgeHandler(retention, 720*time.Hour, hclog.NewNullLogger())
+
+	expectedPurgeHandler := &PurgeHandler{
+		service:   retention,
+		retention: 720 * time.Hour,
+		logger:    hclog.NewNullLogger(),
+	}
+
+	assert.Equal(t, expectedPurgeHandler, actualPurgeHandler)
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"library-api/internal/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	mimeApplicationLDJSON = "application/ld+json"
+	schemaContext         = "https://schema.org"
+	schemaInStock         = "https://schema.org/InStock"
+	schemaOutOfStock      = "https://schema.org/OutOfStock"
+)
+
+type ldPerson struct {
+	Context       string `json:"@context,omitempty"`
+	Type          string `json:"@type"`
+	ID            string `json:"@id,omitempty"`
+	Name          string `json:"name,omitempty"`
+	AlternateName string `json:"alternateName,omitempty"`
+	KnowsAbout    string `json:"knowsAbout,omitempty"`
+}
+
+type ldOffer struct {
+	Type         string `json:"@type"`
+	Availability string `json:"availability"`
+}
+
+type ldBook struct {
+	Context string    `json:"@context,omitempty"`
+	Type    string    `json:"@type"`
+	ID      string    `json:"@id,omitempty"`
+	Name    string    `json:"name"`
+	Author  *ldPerson `json:"author,omitempty"`
+	ISBN    string    `json:"isbn,omitempty"`
+	Genre   string    `json:"genre,omitempty"`
+	Offers  ldOffer   `json:"offers"`
+}
+
+type ldListItem struct {
+	Type     string `json:"@type"`
+	Position int    `json:"position"`
+	Item     any    `json:"item"`
+}
+
+type ldItemList struct {
+	Context         string       `json:"@context"`
+	Type            string       `json:"@type"`
+	NumberOfItems   int          `json:"numberOfItems"`
+	ItemListElement []ldListItem `json:"itemListElement"`
+}
+
+func wantsJSONLD(c *fiber.Ctx) bool {
+	c.Vary(fiber.HeaderAccept)
+	return c.Accepts(fiber.MIMEApplicationJSON, mimeApplicationLDJSON) == mimeApplicationLDJSON
+}
+
+func sendJSONLD(c *fiber.Ctx, status int, data any) error {
+	return c.Status(status).JSON(data, mimeApplicationLDJSON)
+}
+
+func newLDPerson(author model.Author) *ldPerson {
+	name := authorName(author)
+	if name == "" && author.ID == "" {
+		return nil
+	}
+
+	person := &ldPerson{
+		Type:          "Person",
+		Name:          name,
+		AlternateName: author.NickName,
+		KnowsAbout:    author.Specialization,
+	}
+	if author.ID != "" {
+		person.ID = "urn:uuid:" + author.ID
+	}
+
+	return person
+}
+
+func newLDBook(book model.Book) ldBook {
+	availability := schemaInStock
+	if book.Borrowed {
+		availability = schemaOutOfStock
+	}
+
+	ld := ldBook{
+		Type:   "Book",
+		Name:   book.Title,
+		Author: newLDPerson(book.Author),
+		ISBN:   book.ISBN,
+		Genre:  book.Genre,
+		Offers: ldOffer{
+			Type:         "Offer",
+			Availability: availability,
+		},
+	}
+	if book.ID != "" {
+		ld.ID = "urn:uuid:" + book.ID
+	}
+
+	return ld
+}
+
+func newLDItemList[T any](items []T, convert func(T) any) ldItemList {
+	list := ldItemList{
+		Context:         schemaContext,
+		Type:            "ItemList",
+		NumberOfItems:   len(items),
+		ItemListElement: make([]ldListItem, 0, len(items)),
+	}
+
+	for i, item := range items {
+		list.ItemListElement = append(list.ItemListElement, ldListItem{
+			Type:     "ListItem",
+			Position: i + 1,
+			Item:     convert(item),
+		})
+	}
+
+	return list
+}
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"library-api/internal/model"
+	"library-api/internal/service"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLDBook(t *testing.T) {
+	book := citationBooks()[0]
+	book.Author.NickName = "The King of Horror"
+
+	data, err := json.Marshal(newLDBook(book))
+	assert.NoError(t, err)
+
+	expected := `{
+		"@type": "Book",
+		"@id": "urn:uuid:e11f8107-880b-49c2-85b2-c780e7929978",
+		"name": "The Shining",
+		"author": {
+			"@type": "Person",
+			"@id": "urn:uuid:4ce0ddc1-ed52-4173-8e82-e32926ddff2e",
+			"name": "Stephen King",
+			"alternateName": "The King of Horror"
+		},
+		"isbn": "978-0-385-12167-5",
+		"genre": "Horror",
+		"offers": {"@type": "Offer", "availability": "https://schema.org/InStock"}
+	}`
+
+	assert.JSONEq(t, expected, string(data))
+
+	book.Borrowed = true
+	assert.Equal(t, schemaOutOfStock, newLDBook(book).Offers.Availability)
+}
+
+func TestBookHandler_GetJSONLD(t *testing.T) {
+	testCases := []struct {
+		description    string
+		books          []model.Book
+		expectedError  error
+		expectedStatus int
+	}{
+		{
+			description:    "catalog as item list",
+			books:          citationBooks(),
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			description:    "empty catalog",
+			books:          []model.Book{},
+			expectedStatus: fiber.StatusNotFound,
+		},
+		{
+			description:    "store error",
+			books:          []model.Book{},
+			expectedError:  errors.New("server error"),
+			expectedStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockBookStore := new(MockBookStore)
+			bookHandler := &BookHandler{
+				service: service.NewCatalogService(nil, mockBookStore, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
+			}
+
+			app.Get("/books", bookHandler.Get)
+
+			mockBookStore.On("GetCatalog").Return(testCase.books, testCase.expectedError).Once()
+
+			req := httptest.NewRequest(fiber.MethodGet, "/books", nil)
+			req.Header.Set("Accept", "application/ld+json")
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+			mockBookStore.AssertNotCalled(t, "Get")
+
+			if testCase.expectedStatus == fiber.StatusOK {
+				assert.Equal(t, "application/ld+json", resp.Header.Get(fiber.HeaderContentType))
+
+				respBody, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+
+				var actual ldItemList
+				err = json.Unmarshal(respBody, &actual)
+				assert.NoError(t, err)
+
+				assert.Equal(t, "ItemList", actual.Type)
+				assert.Equal(t, 1, actual.NumberOfItems)
+			}
+		})
+	}
+}
+
+func TestBookHandler_GetByID(t *testing.T) {
+	testCases := []struct {
+		description         string
+		accept              string
+		ifNoneMatch         string
+		books               []model.Book
+		expectedError       error
+		expectedStatus      int
+		expectedContentType string
+	}{
+		{
+			description:         "plain json",
+			accept:              "application/json",
+			books:               citationBooks(),
+			expectedStatus:      fiber.StatusOK,
+			expectedContentType: "application/json",
+		},
+		{
+			description:         "json-ld",
+			accept:              "application/ld+json",
+			books:               citationBooks(),
+			expectedStatus:      fiber.StatusOK,
+			expectedContentType: "application/ld+json",
+		},
+		{
+			description:    "not modified",
+			accept:         "application/ld+json",
+			ifNoneMatch:    `"0"`,
+			books:          citationBooks(),
+			expectedStatus: fiber.StatusNotModified,
+		},
+		{
+			description:         "book not found",
+			books:               []model.Book{},
+			expectedStatus:      fiber.StatusNotFound,
+			expectedContentType: "application/json",
+		},
+		{
+			description:         "store error",
+			books:               []model.Book{},
+			expectedError:       errors.New("server error"),
+			expectedStatus:      fiber.StatusInternalServerError,
+			expectedContentType: "application/json",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockBookStore := new(MockBookStore)
+			bookHandler := &BookHandler{
+				service: service.NewCatalogService(nil, mockBookStore, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
+			}
+
+			app.Get("/book/:id", bookHandler.GetByID)
+
+			mockBookStore.On("GetByIDs", []string{"e11f8107-880b-49c2-85b2-c780e7929978"}).
+				Return(testCase.books, testCase.expectedError).Once()
+
+			req := httptest.NewRequest(fiber.MethodGet, "/book/e11f8107-880b-49c2-85b2-c780e7929978", nil)
+			if testCase.accept != "" {
+				req.Header.Set("Accept", testCase.accept)
+			}
+			if testCase.ifNoneMatch != "" {
+				req.Header.Set(fiber.HeaderIfNoneMatch, testCase.ifNoneMatch)
+			}
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+			assert.Equal(t, testCase.expectedContentType, resp.Header.Get(fiber.HeaderContentType))
+		})
+	}
+}
+
+func TestAuthorHandler_GetJSONLD(t *testing.T) {
+	app := fiber.New()
+
+	fullName := "Stephen King"
+	mockAuthorStore := new(MockAuthorStore)
+	authorHandler := &AuthorHandler{
+		service: service.NewCatalogService(mockAuthorStore, nil, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+		logger:  hclog.NewNullLogger(),
+	}
+
+	app.Get("/authors", authorHandler.Get)
+
+	mockAuthorStore.On("Get").Return([]model.Author{
+		{
+			ID:             "4ce0ddc1-ed52-4173-8e82-e32926ddff2e",
+			FullName:       &fullName,
+			NickName:       "The King of Horror",
+			Specialization: "Horror Fiction",
+		},
+	}, nil).Once()
+
+	req := httptest.NewRequest(fiber.MethodGet, "/authors", nil)
+	req.Header.Set("Accept", "application/ld+json")
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/ld+json", resp.Header.Get(fiber.HeaderContentType))
+
+	respBody, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	expected := `{
+		"@context": "https://schema.org",
+		"@type": "ItemList",
+		"numberOfItems": 1,
+		"itemListElement": [{
+			"@type": "ListItem",
+			"position": 1,
+			"item": {
+				"@type": "Person",
+				"@id": "urn:uuid:4ce0ddc1-ed52-4173-8e82-e32926ddff2e",
+				"name": "Stephen King",
+				"alternateName": "The King of Horror",
+				"knowsAbout": "Horror Fiction"
+			}
+		}]
+	}`
+
+	assert.JSONEq(t, expected, string(respBody))
+}
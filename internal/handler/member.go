@@ -1,54 +1,92 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"library-api/internal/model"
+	"library-api/internal/service"
+	"library-api/pkg/tracing"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
-type memberStore interface {
-	Create(member *model.Member) error
-	Get() ([]model.Member, error)
-	Exists(id string) error
-	Update(id string, member *model.Member) error
-	Delete(id string) error
+type memberService interface {
+	Members(ctx context.Context, filter model.MemberFilter) ([]model.Member, error)
+	Member(ctx context.Context, id string) (*model.Member, error)
+	Register(ctx context.Context, member *model.Member) error
+	UpdateMember(ctx context.Context, id string, member *model.Member, precondition service.Precondition) (*model.Member, error)
+	DeleteMember(ctx context.Context, id string, precondition service.Precondition) (*model.Member, error)
+	RestoreMember(ctx context.Context, id string) (*model.Member, error)
+	Suspend(ctx context.Context, id string, suspension *model.Suspension) (*model.Member, *model.Member, error)
+	Reinstate(ctx context.Context, id string, expiresAt model.Date) (*model.Member, *model.Member, error)
+}
+
+type reinstateRequest struct {
+	ExpiresAt model.Date `json:"expires_at"`
 }
 
 func (m *MemberHandler) Create(c *fiber.Ctx) error {
 	var member model.Member
 	err := c.BodyParser(&member)
 	if err != nil {
-		m.logger.Error("member body parsing failed for create", "error", err.Error())
+		tracing.Logger(c.UserContext(), m.logger).Error("member body parsing failed for create", "error", err.Error())
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "member creation failed",
 		})
 	}
 
-	member.ID = uuid.New().String()
-	err = m.store.Create(&member)
+	err = m.service.Register(c.UserContext(), &member)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "member creation failed",
+		var invalid *service.ValidationError
+		switch {
+		case errors.As(err, &invalid):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": invalid.Message,
+			})
+		case errors.Is(err, service.ErrEmailInUse):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case errors.Is(err, service.ErrRejected):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "member creation failed",
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
 		})
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message": "member created",
-	})
+	c.Location("/member/" + member.ID)
+	c.Set(fiber.HeaderETag, etag(member.Version))
+
+	return c.Status(fiber.StatusCreated).JSON(member)
 }
 
 func (m *MemberHandler) Get(c *fiber.Ctx) error {
-	members, err := m.store.Get()
+	filter := model.MemberFilter{
+		CardNumber: strings.TrimSpace(c.Query("card_number")),
+		Email:      strings.TrimSpace(c.Query("email")),
+	}
+
+	members, err := m.service.Members(c.UserContext(), filter)
 	if err != nil {
+		var invalid *service.ValidationError
+		if errors.As(err, &invalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": invalid.Message,
+			})
+		}
+
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "server error",
 		})
 	}
 
 	if len(members) == 0 {
-		m.logger.Info("no members found")
+		tracing.Logger(c.UserContext(), m.logger).Info("no members found")
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"message": "no members found",
 		})
@@ -57,12 +95,25 @@ func (m *MemberHandler) Get(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(members)
 }
 
+func (m *MemberHandler) GetByID(c *fiber.Ctx) error {
+	member, err := m.service.Member(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return m.statusChangeError(c, err)
+	}
+
+	if notModified(c, member.Version) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(member)
+}
+
 func (m *MemberHandler) Update(c *fiber.Ctx) error {
 	var member model.Member
 
 	err := c.BodyParser(&member)
 	if err != nil {
-		m.logger.Error("member body parsing failed for update", "id", member.ID, "error", err.Error())
+		tracing.Logger(c.UserContext(), m.logger).Error("member body parsing failed for update", "id", member.ID, "error", err.Error())
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "member update failed",
 		})
@@ -70,43 +121,142 @@ func (m *MemberHandler) Update(c *fiber.Ctx) error {
 
 	id := c.Params("id")
 
-	err = m.store.Exists(id)
+	_, err = m.service.UpdateMember(c.UserContext(), id, &member, precondition(c))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"message": "member not found",
-		})
-	}
+		var invalid *service.ValidationError
+		switch {
+		case errors.As(err, &invalid):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": invalid.Message,
+			})
+		case errors.Is(err, service.ErrPreconditionFailed), errors.Is(err, model.ErrVersionConflict):
+			return preconditionFailed(c, "member was modified by another request")
+		case errors.Is(err, service.ErrEmailInUse):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case errors.Is(err, service.ErrRejected):
+			tracing.Logger(c.UserContext(), m.logger).Error("member update failed", "id", id, "error", err.Error())
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "member update failed",
+			})
+		}
 
-	err = m.store.Update(id, &member)
-	if err != nil {
-		m.logger.Error("member update failed", "id", id, "error", err.Error())
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "member update failed",
-		})
+		return m.statusChangeError(c, err)
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "member updated",
-	})
+	c.Set(fiber.HeaderETag, etag(member.Version))
+
+	return c.Status(fiber.StatusOK).JSON(member)
 }
 
 func (m *MemberHandler) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	err := m.store.Delete(id)
+	_, err := m.service.DeleteMember(c.UserContext(), id, precondition(c))
 	if err != nil {
-		if strings.Contains(err.Error(), "violates foreign key constraint") {
+		switch {
+		case errors.Is(err, service.ErrPreconditionFailed):
+			return preconditionFailed(c, "member was modified by another request")
+		case errors.Is(err, model.ErrReferenced):
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"message": "member still has books, all books must be returned",
 			})
 		}
 
+		return m.statusChangeError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "member deleted",
+	})
+}
+
+func (m *MemberHandler) Restore(c *fiber.Ctx) error {
+	id := c.Params("id")
+	member, err := m.service.RestoreMember(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "deleted member not found",
+			})
+		}
+
+		if errors.Is(err, service.ErrEmailInUse) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "server error",
 		})
 	}
 
+	c.Set(fiber.HeaderETag, etag(member.Version))
+
+	return c.Status(fiber.StatusOK).JSON(member)
+}
+
+func (m *MemberHandler) Suspend(c *fiber.Ctx) error {
+	var suspension model.Suspension
+	err := c.BodyParser(&suspension)
+	if err != nil {
+		tracing.Logger(c.UserContext(), m.logger).Error("suspension body parsing failed", "error", err.Error())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "member suspension failed",
+		})
+	}
+
+	id := c.Params("id")
+	_, _, err = m.service.Suspend(c.UserContext(), id, &suspension)
+	if err != nil {
+		var invalid *service.ValidationError
+		if errors.As(err, &invalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": invalid.Message,
+			})
+		}
+
+		return m.statusChangeError(c, err)
+	}
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "member deleted",
+		"message": "member suspended",
+	})
+}
+
+func (m *MemberHandler) Reinstate(c *fiber.Ctx) error {
+	var request reinstateRequest
+	if len(c.Body()) > 0 {
+		err := c.BodyParser(&request)
+		if err != nil {
+			tracing.Logger(c.UserContext(), m.logger).Error("reinstate body parsing failed", "error", err.Error())
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "member reinstatement failed",
+			})
+		}
+	}
+
+	id := c.Params("id")
+	_, _, err := m.service.Reinstate(c.UserContext(), id, request.ExpiresAt)
+	if err != nil {
+		return m.statusChangeError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "member reinstated",
+	})
+}
+
+func (m *MemberHandler) statusChangeError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, model.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "member not found",
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "server error",
 	})
 }
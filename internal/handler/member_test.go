@@ -2,15 +2,21 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"library-api/internal/model"
+	"library-api/internal/service"
+	"library-api/pkg/luhn"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/hashicorp/go-hclog"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -19,31 +25,59 @@ type MockMemberStore struct {
 	mock.Mock
 }
 
-func (m *MockMemberStore) Create(member *model.Member) error {
+func (m *MockMemberStore) Create(ctx context.Context, member *model.Member) error {
 	args := m.Called(member)
 	return args.Error(0)
 }
 
-func (m *MockMemberStore) Get() ([]model.Member, error) {
-	args := m.Called()
+func (m *MockMemberStore) Get(ctx context.Context, filter model.MemberFilter) ([]model.Member, error) {
+	args := m.Called(filter)
 	return args.Get(0).([]model.Member), args.Error(1)
 }
 
-func (m *MockMemberStore) Exists(id string) error {
+func (m *MockMemberStore) GetByID(ctx context.Context, id string) (*model.Member, error) {
 	args := m.Called(id)
-	return args.Error(0)
+	member, _ := args.Get(0).(*model.Member)
+	return member, args.Error(1)
 }
 
-func (m *MockMemberStore) Update(id string, member *model.Member) error {
+func (m *MockMemberStore) Update(ctx context.Context, id string, member *model.Member) error {
 	args := m.Called(id, member)
 	return args.Error(0)
 }
 
-func (m *MockMemberStore) Delete(id string) error {
+func (m *MockMemberStore) Delete(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockMemberStore) Restore(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockMemberStore) Suspend(ctx context.Context, id string, suspension *model.Suspension) error {
+	args := m.Called(id, suspension)
+	return args.Error(0)
+}
+
+func (m *MockMemberStore) Reinstate(ctx context.Context, id string, expiresAt model.Date) error {
+	args := m.Called(id, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockMemberStore) ExpireMemberships(ctx context.Context) ([]model.MemberChange, error) {
+	args := m.Called()
+	changes, _ := args.Get(0).([]model.MemberChange)
+	return changes, args.Error(1)
+}
+
+func (m *MockMemberStore) LiftSuspensions(ctx context.Context) ([]model.MemberChange, error) {
+	args := m.Called()
+	changes, _ := args.Get(0).([]model.MemberChange)
+	return changes, args.Error(1)
+}
+
 func TestMemberHandler_Create(t *testing.T) {
 	testCases := []struct {
 		description    string
@@ -59,7 +93,8 @@ func TestMemberHandler_Create(t *testing.T) {
 			},
 			expectedStatus: fiber.StatusCreated,
 			expectedBody: fiber.Map{
-				"message": "member created",
+				"full_name": "John Doe",
+				"status":    "active",
 			},
 		},
 		{
@@ -82,6 +117,40 @@ func TestMemberHandler_Create(t *testing.T) {
 			},
 			expectedError: errors.New("member creation failed"),
 		},
+		{
+			description: "email taken by another member",
+			body: model.Member{
+				FullName: "John Doe",
+				Email:    "john@example.com",
+			},
+			expectedStatus: fiber.StatusConflict,
+			expectedBody: fiber.Map{
+				"error": "email is already used by another member",
+			},
+			expectedError: &pq.Error{Code: "23505", Constraint: "members_email_key"},
+		},
+		{
+			description: "invalid email",
+			body: model.Member{
+				FullName: "John Doe",
+				Email:    "john at example",
+			},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: fiber.Map{
+				"error": "invalid email",
+			},
+		},
+		{
+			description: "date of birth in the future",
+			body: model.Member{
+				FullName:    "John Doe",
+				DateOfBirth: model.Today().AddDate(1, 0, 0),
+			},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: fiber.Map{
+				"error": "date_of_birth must be in the past",
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -90,8 +159,8 @@ func TestMemberHandler_Create(t *testing.T) {
 
 			mockMemberStore := new(MockMemberStore)
 			memberHandler := &MemberHandler{
-				store:  mockMemberStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewMembershipService(mockMemberStore, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Post("/member", memberHandler.Create)
@@ -116,7 +185,22 @@ func TestMemberHandler_Create(t *testing.T) {
 			err = json.Unmarshal(respBody, &actual)
 			assert.NoError(t, err)
 
-			assert.Equal(t, testCase.expectedBody, actual)
+			if testCase.expectedStatus != fiber.StatusCreated {
+				assert.Equal(t, testCase.expectedBody, actual)
+			} else {
+				for key, value := range testCase.expectedBody.(fiber.Map) {
+					assert.Equal(t, value, actual[key])
+				}
+
+				stored := mockMemberStore.Calls[0].Arguments.Get(0).(*model.Member)
+				assert.Equal(t, stored.CardNumber, actual["card_number"])
+				assert.Equal(t, "/member/"+stored.ID, resp.Header.Get(fiber.HeaderLocation))
+				assert.Regexp(t, `^29\d{12}$`, stored.CardNumber)
+				assert.True(t, luhn.Valid(stored.CardNumber))
+				assert.Equal(t, model.MemberStatusActive, stored.Status)
+				assert.Equal(t, model.Today(), stored.RegisteredAt)
+				assert.Equal(t, model.Today().AddDate(1, 0, 0), stored.ExpiresAt)
+			}
 		})
 	}
 }
@@ -169,13 +253,13 @@ func TestMemberHandler_Get(t *testing.T) {
 			var mockMemberStore MockMemberStore
 
 			memberHandler := &MemberHandler{
-				store:  &mockMemberStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewMembershipService(&mockMemberStore, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Get("/members", memberHandler.Get)
 
-			mockMemberStore.On("Get").Return(testCase.body, testCase.expectedError).Once()
+			mockMemberStore.On("Get", model.MemberFilter{}).Return(testCase.body, testCase.expectedError).Once()
 
 			req := httptest.NewRequest(fiber.MethodGet, "/members", nil)
 			req.Header.Set("Content-Type", "application/json")
@@ -205,14 +289,164 @@ func TestMemberHandler_Get(t *testing.T) {
 	}
 }
 
+func TestMemberHandler_GetFiltered(t *testing.T) {
+	testCases := []struct {
+		description    string
+		target         string
+		expectedFilter *model.MemberFilter
+		expectedStatus int
+	}{
+		{
+			description:    "search by card number",
+			target:         "/members?card_number=29000000000015",
+			expectedFilter: &model.MemberFilter{CardNumber: "29000000000015"},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			description:    "search by email",
+			target:         "/members?email=John@Example.com",
+			expectedFilter: &model.MemberFilter{Email: "John@Example.com"},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			description:    "card number with wrong check digit",
+			target:         "/members?card_number=29000000000016",
+			expectedStatus: fiber.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockMemberStore := new(MockMemberStore)
+			memberHandler := &MemberHandler{
+				service: service.NewMembershipService(mockMemberStore, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
+			}
+
+			app.Get("/members", memberHandler.Get)
+
+			if testCase.expectedFilter != nil {
+				mockMemberStore.On("Get", *testCase.expectedFilter).Return([]model.Member{
+					{ID: "d4cc2192-9316-4f34-952f-9a0504f154d4", FullName: "John Doe", CardNumber: "29000000000015"},
+				}, nil).Once()
+			}
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, testCase.target, nil), -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+
+			mockMemberStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMemberHandler_GetByID(t *testing.T) {
+	testCases := []struct {
+		description    string
+		ifNoneMatch    string
+		getError       error
+		expectedStatus int
+		expectedETag   string
+		expectedBody   any
+	}{
+		{
+			description:    "member found",
+			expectedStatus: fiber.StatusOK,
+			expectedETag:   `"3"`,
+			expectedBody: fiber.Map{
+				"id":        "1de94d3e-09b2-4f62-bfff-964012c649d3",
+				"full_name": "John Doe",
+				"version":   float64(3),
+			},
+		},
+		{
+			description:    "if-none-match matches current version",
+			ifNoneMatch:    `"1", "3"`,
+			expectedStatus: fiber.StatusNotModified,
+			expectedETag:   `"3"`,
+		},
+		{
+			description:    "member not found",
+			getError:       model.ErrNotFound,
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody: fiber.Map{
+				"message": "member not found",
+			},
+		},
+		{
+			description:    "store error",
+			getError:       errors.New("select failed"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody: fiber.Map{
+				"error": "server error",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockMemberStore := new(MockMemberStore)
+			memberHandler := &MemberHandler{
+				service: service.NewMembershipService(mockMemberStore, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
+			}
+
+			app.Get("/member/:id", memberHandler.GetByID)
+
+			mockMemberStore.On("GetByID", "1de94d3e-09b2-4f62-bfff-964012c649d3").Return(existingMember(testCase.getError), testCase.getError).Once()
+
+			req := httptest.NewRequest(fiber.MethodGet, "/member/1de94d3e-09b2-4f62-bfff-964012c649d3", nil)
+			if testCase.ifNoneMatch != "" {
+				req.Header.Set(fiber.HeaderIfNoneMatch, testCase.ifNoneMatch)
+			}
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+			assert.Equal(t, testCase.expectedETag, resp.Header.Get(fiber.HeaderETag))
+
+			if testCase.expectedBody == nil {
+				return
+			}
+
+			respBody, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			var actual fiber.Map
+			err = json.Unmarshal(respBody, &actual)
+			assert.NoError(t, err)
+
+			for key, value := range testCase.expectedBody.(fiber.Map) {
+				assert.Equal(t, value, actual[key])
+			}
+		})
+	}
+}
+
+func existingMember(err error) *model.Member {
+	if err != nil {
+		return nil
+	}
+
+	return &model.Member{ID: "1de94d3e-09b2-4f62-bfff-964012c649d3", FullName: "John Doe", Version: 3}
+}
+
 func TestMemberHandler_Update(t *testing.T) {
 	testCases := []struct {
 		description         string
 		body                any
-		expectedExistsError error
+		ifMatch             string
+		getError            error
 		expectedStatus      int
 		expectedBody        any
 		expectedUpdateError error
+		expectedETag        string
 	}{
 		{
 			description: "member update success",
@@ -221,7 +455,47 @@ func TestMemberHandler_Update(t *testing.T) {
 			},
 			expectedStatus: fiber.StatusOK,
 			expectedBody: fiber.Map{
-				"message": "member updated",
+				"id":              "1de94d3e-09b2-4f62-bfff-964012c649d3",
+				"full_name":       "John Doe",
+				"date_of_birth":   nil,
+				"registered_at":   nil,
+				"expires_at":      nil,
+				"suspended_until": nil,
+				"version":         float64(3),
+			},
+			expectedETag: `"3"`,
+		},
+		{
+			description: "if-match is stale",
+			body: model.Member{
+				FullName: "John Doe",
+			},
+			ifMatch:        `"2"`,
+			expectedStatus: fiber.StatusPreconditionFailed,
+			expectedBody: fiber.Map{
+				"error": "member was modified by another request",
+			},
+		},
+		{
+			description: "weak if-match never matches",
+			body: model.Member{
+				FullName: "John Doe",
+			},
+			ifMatch:        `W/"3"`,
+			expectedStatus: fiber.StatusPreconditionFailed,
+			expectedBody: fiber.Map{
+				"error": "member was modified by another request",
+			},
+		},
+		{
+			description: "version changed during update",
+			body: model.Member{
+				FullName: "John Doe",
+			},
+			expectedStatus:      fiber.StatusPreconditionFailed,
+			expectedUpdateError: model.ErrVersionConflict,
+			expectedBody: fiber.Map{
+				"error": "member was modified by another request",
 			},
 		},
 		{
@@ -237,8 +511,8 @@ func TestMemberHandler_Update(t *testing.T) {
 			body: model.Member{
 				FullName: "John Doe",
 			},
-			expectedExistsError: errors.New("member not found"),
-			expectedStatus:      fiber.StatusNotFound,
+			getError:       model.ErrNotFound,
+			expectedStatus: fiber.StatusNotFound,
 			expectedBody: fiber.Map{
 				"message": "member not found",
 			},
@@ -254,6 +528,17 @@ func TestMemberHandler_Update(t *testing.T) {
 				"error": "member update failed",
 			},
 		},
+		{
+			description: "email taken by another member",
+			body: model.Member{
+				Email: "jane@example.com",
+			},
+			expectedStatus:      fiber.StatusConflict,
+			expectedUpdateError: &pq.Error{Code: "23505", Constraint: "members_email_key"},
+			expectedBody: fiber.Map{
+				"error": "email is already used by another member",
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -262,8 +547,8 @@ func TestMemberHandler_Update(t *testing.T) {
 
 			mockMemberStore := new(MockMemberStore)
 			memberHandler := &MemberHandler{
-				store:  mockMemberStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewMembershipService(mockMemberStore, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Patch("/member/:id", memberHandler.Update)
@@ -271,17 +556,23 @@ func TestMemberHandler_Update(t *testing.T) {
 			body, err := json.Marshal(testCase.body)
 			assert.NoError(t, err)
 
-			mockMemberStore.On("Exists", mock.Anything).Return(testCase.expectedExistsError).Once()
+			mockMemberStore.On("GetByID", "1de94d3e-09b2-4f62-bfff-964012c649d3").Return(existingMember(testCase.getError), testCase.getError).Maybe()
 
-			mockMemberStore.On("Update", mock.Anything, mock.Anything).Return(testCase.expectedUpdateError).Once()
+			mockMemberStore.On("Update", mock.Anything, mock.Anything).Return(testCase.expectedUpdateError).Once().Run(func(args mock.Arguments) {
+				args.Get(1).(*model.Member).ID = args.String(0)
+			})
 
 			req := httptest.NewRequest(fiber.MethodPatch, "/member/1de94d3e-09b2-4f62-bfff-964012c649d3", bytes.NewReader(body))
 			req.Header.Set("Content-Type", "application/json")
+			if testCase.ifMatch != "" {
+				req.Header.Set(fiber.HeaderIfMatch, testCase.ifMatch)
+			}
 
 			resp, err := app.Test(req, -1)
 			assert.NoError(t, err)
 
 			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+			assert.Equal(t, testCase.expectedETag, resp.Header.Get(fiber.HeaderETag))
 
 			respBody, err := io.ReadAll(resp.Body)
 			assert.NoError(t, err)
@@ -298,9 +589,12 @@ func TestMemberHandler_Update(t *testing.T) {
 func TestMemberHandler_Delete(t *testing.T) {
 	testCases := []struct {
 		description    string
+		ifMatch        string
+		getError       error
 		expectedStatus int
 		expectedBody   any
 		expectedError  error
+		expectedAudits int
 	}{
 		{
 			description:    "member delete success",
@@ -308,6 +602,32 @@ func TestMemberHandler_Delete(t *testing.T) {
 			expectedBody: fiber.Map{
 				"message": "member deleted",
 			},
+			expectedAudits: 1,
+		},
+		{
+			description:    "if-match matches current version",
+			ifMatch:        `"3"`,
+			expectedStatus: fiber.StatusOK,
+			expectedBody: fiber.Map{
+				"message": "member deleted",
+			},
+			expectedAudits: 1,
+		},
+		{
+			description:    "if-match is stale",
+			ifMatch:        `"2"`,
+			expectedStatus: fiber.StatusPreconditionFailed,
+			expectedBody: fiber.Map{
+				"error": "member was modified by another request",
+			},
+		},
+		{
+			description:    "member not found",
+			getError:       model.ErrNotFound,
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody: fiber.Map{
+				"message": "member not found",
+			},
 		},
 		{
 			description:    "member still has books, can't delete",
@@ -315,7 +635,7 @@ func TestMemberHandler_Delete(t *testing.T) {
 			expectedBody: fiber.Map{
 				"message": "member still has books, all books must be returned",
 			},
-			expectedError: errors.New("violates foreign key constraint"),
+			expectedError: model.ErrReferenced,
 		},
 		{
 			description:    "store error",
@@ -332,17 +652,22 @@ func TestMemberHandler_Delete(t *testing.T) {
 			app := fiber.New()
 
 			mockMemberStore := new(MockMemberStore)
+			mockAuditor := new(MockAuditor)
 			memberHandler := &MemberHandler{
-				store:  mockMemberStore,
-				logger: hclog.NewNullLogger(),
+				service: service.NewMembershipService(mockMemberStore, testTransactor{}, mockAuditor, hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
 			}
 
 			app.Delete("/member/:id", memberHandler.Delete)
 
-			mockMemberStore.On("Delete", mock.Anything).Return(testCase.expectedError).Once()
+			mockMemberStore.On("GetByID", "1de94d3e-09b2-4f62-bfff-964012c649d3").Return(existingMember(testCase.getError), testCase.getError).Once()
+			mockMemberStore.On("Delete", mock.Anything).Return(testCase.expectedError).Maybe()
 
 			req := httptest.NewRequest(fiber.MethodDelete, "/member/1de94d3e-09b2-4f62-bfff-964012c649d3", nil)
 			req.Header.Set("Content-Type", "application/json")
+			if testCase.ifMatch != "" {
+				req.Header.Set(fiber.HeaderIfMatch, testCase.ifMatch)
+			}
 
 			resp, err := app.Test(req, -1)
 			assert.NoError(t, err)
@@ -357,6 +682,248 @@ func TestMemberHandler_Delete(t *testing.T) {
 			assert.NoError(t, err)
 
 			assert.Equal(t, testCase.expectedBody, actual)
+			assert.Len(t, mockAuditor.records, testCase.expectedAudits)
+		})
+	}
+}
+
+func TestMemberHandler_Suspend(t *testing.T) {
+	testCases := []struct {
+		description    string
+		body           string
+		storeError     error
+		expectedStatus int
+		expectedBody   fiber.Map
+	}{
+		{
+			description:    "member suspended",
+			body:           `{"reason":"lost books","until":"` + model.Today().AddDate(0, 1, 0).String() + `"}`,
+			expectedStatus: fiber.StatusOK,
+			expectedBody: fiber.Map{
+				"message": "member suspended",
+			},
+		},
+		{
+			description:    "suspended indefinitely",
+			body:           `{"reason":"lost books"}`,
+			expectedStatus: fiber.StatusOK,
+			expectedBody: fiber.Map{
+				"message": "member suspended",
+			},
+		},
+		{
+			description:    "missing reason",
+			body:           `{"reason":" "}`,
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: fiber.Map{
+				"error": "reason is required",
+			},
+		},
+		{
+			description:    "end date in the past",
+			body:           `{"reason":"lost books","until":"2020-01-01"}`,
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: fiber.Map{
+				"error": "until must not be in the past",
+			},
+		},
+		{
+			description:    "malformed date",
+			body:           `{"reason":"lost books","until":"01.01.2030"}`,
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: fiber.Map{
+				"error": "member suspension failed",
+			},
+		},
+		{
+			description:    "member not found",
+			body:           `{"reason":"lost books"}`,
+			storeError:     model.ErrNotFound,
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody: fiber.Map{
+				"message": "member not found",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockMemberStore := new(MockMemberStore)
+			memberHandler := &MemberHandler{
+				service: service.NewMembershipService(mockMemberStore, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
+			}
+
+			app.Post("/member/:id/suspend", memberHandler.Suspend)
+
+			mockMemberStore.On("GetByID", "1de94d3e-09b2-4f62-bfff-964012c649d3").Return(existingMember(nil), nil).Maybe()
+			mockMemberStore.On("Suspend", "1de94d3e-09b2-4f62-bfff-964012c649d3", mock.Anything).Return(testCase.storeError).Maybe()
+
+			req := httptest.NewRequest(fiber.MethodPost, "/member/1de94d3e-09b2-4f62-bfff-964012c649d3/suspend", strings.NewReader(testCase.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+
+			respBody, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			var actual fiber.Map
+			err = json.Unmarshal(respBody, &actual)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedBody, actual)
+		})
+	}
+}
+
+func TestMemberHandler_Reinstate(t *testing.T) {
+	renewal := model.NewDate(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	testCases := []struct {
+		description       string
+		body              string
+		expectedExpiresAt model.Date
+		storeError        error
+		expectedStatus    int
+	}{
+		{
+			description:    "reinstated without body",
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			description:       "reinstated with renewal",
+			body:              `{"expires_at":"2030-01-01"}`,
+			expectedExpiresAt: renewal,
+			expectedStatus:    fiber.StatusOK,
+		},
+		{
+			description:    "member not found",
+			storeError:     model.ErrNotFound,
+			expectedStatus: fiber.StatusNotFound,
+		},
+		{
+			description:    "store error",
+			storeError:     errors.New("server error"),
+			expectedStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockMemberStore := new(MockMemberStore)
+			memberHandler := &MemberHandler{
+				service: service.NewMembershipService(mockMemberStore, testTransactor{}, new(MockAuditor), hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
+			}
+
+			app.Post("/member/:id/reinstate", memberHandler.Reinstate)
+
+			mockMemberStore.On("GetByID", "1de94d3e-09b2-4f62-bfff-964012c649d3").Return(existingMember(nil), nil).Maybe()
+			mockMemberStore.On("Reinstate", "1de94d3e-09b2-4f62-bfff-964012c649d3", testCase.expectedExpiresAt).Return(testCase.storeError).Once()
+
+			req := httptest.NewRequest(fiber.MethodPost, "/member/1de94d3e-09b2-4f62-bfff-964012c649d3/reinstate", strings.NewReader(testCase.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+
+			mockMemberStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMemberHandler_Restore(t *testing.T) {
+	testCases := []struct {
+		description    string
+		restoreError   error
+		expectedStatus int
+		expectedETag   string
+		expectedBody   any
+		expectedAudits int
+	}{
+		{
+			description:    "member restored",
+			expectedStatus: fiber.StatusOK,
+			expectedETag:   `"3"`,
+			expectedBody: fiber.Map{
+				"id":        "1de94d3e-09b2-4f62-bfff-964012c649d3",
+				"full_name": "John Doe",
+				"version":   float64(3),
+			},
+			expectedAudits: 1,
+		},
+		{
+			description:    "no deleted member with that id",
+			restoreError:   model.ErrNotFound,
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody: fiber.Map{
+				"message": "deleted member not found",
+			},
+		},
+		{
+			description:    "email taken by another member",
+			restoreError:   &pq.Error{Code: "23505", Constraint: "members_email_key"},
+			expectedStatus: fiber.StatusConflict,
+			expectedBody: fiber.Map{
+				"error": "email is already used by another member",
+			},
+		},
+		{
+			description:    "store error",
+			restoreError:   errors.New("server error"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody: fiber.Map{
+				"error": "server error",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockMemberStore := new(MockMemberStore)
+			mockAuditor := new(MockAuditor)
+			memberHandler := &MemberHandler{
+				service: service.NewMembershipService(mockMemberStore, testTransactor{}, mockAuditor, hclog.NewNullLogger()),
+				logger:  hclog.NewNullLogger(),
+			}
+
+			app.Post("/member/:id/restore", memberHandler.Restore)
+
+			mockMemberStore.On("Restore", "1de94d3e-09b2-4f62-bfff-964012c649d3").Return(testCase.restoreError).Once()
+			if testCase.restoreError == nil {
+				mockMemberStore.On("GetByID", "1de94d3e-09b2-4f62-bfff-964012c649d3").Return(existingMember(nil), nil).Once()
+			}
+
+			req := httptest.NewRequest(fiber.MethodPost, "/member/1de94d3e-09b2-4f62-bfff-964012c649d3/restore", nil)
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+			assert.Equal(t, testCase.expectedETag, resp.Header.Get(fiber.HeaderETag))
+
+			respBody, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			var actual fiber.Map
+			err = json.Unmarshal(respBody, &actual)
+			assert.NoError(t, err)
+
+			for key, value := range testCase.expectedBody.(fiber.Map) {
+				assert.Equal(t, value, actual[key])
+			}
+			assert.Len(t, mockAuditor.records, testCase.expectedAudits)
+			mockMemberStore.AssertExpectations(t)
 		})
 	}
 }
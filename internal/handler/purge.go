@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"context"
+	"library-api/internal/model"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type retentionService interface {
+	Purge(ctx context.Context, before time.Time) (*model.PurgeResult, error)
+}
+
+// Purge permanently removes records that have been soft deleted for longer
+// than the retention window. The older_than query parameter overrides the
+// configured window for a single run.
+func (p *PurgeHandler) Purge(c *fiber.Ctx) error {
+	retention := p.retention
+	if value := c.Query("older_than"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "older_than must be a non-negative duration such as 720h",
+			})
+		}
+		retention = d
+	}
+
+	result, err := p.service.Purge(c.UserContext(), time.Now().Add(-retention))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"library-api/internal/model"
+	"library-api/internal/service"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPurgeStore struct {
+	mock.Mock
+}
+
+func (m *MockPurgeStore) Purge(ctx context.Context, before time.Time) (*model.PurgeResult, error) {
+	args := m.Called(before)
+	result, _ := args.Get(0).(*model.PurgeResult)
+	return result, args.Error(1)
+}
+
+func TestPurgeHandler_Purge(t *testing.T) {
+	purged := &model.PurgeResult{
+		Authors: []string{"4dbec5df-c354-4c0a-8f33-7832dfbc12c0"},
+		Books:   []string{"235fcd0e-98af-4af5-b985-68dab66085e1", "e11f8107-880b-49c2-85b2-c780e7929978"},
+		Members: []string{},
+	}
+
+	testCases := []struct {
+		description       string
+		target            string
+		expectedRetention time.Duration
+		result            *model.PurgeResult
+		storeError        error
+		expectedStatus    int
+		expectedAudits    int
+	}{
+		{
+			description:       "configured retention",
+			target:            "/purge",
+			expectedRetention: 720 * time.Hour,
+			result:            purged,
+			expectedStatus:    fiber.StatusOK,
+			expectedAudits:    3,
+		},
+		{
+			description:       "retention overridden",
+			target:            "/purge?older_than=0s",
+			expectedRetention: 0,
+			result:            &model.PurgeResult{Authors: []string{}, Books: []string{}, Members: []string{}},
+			expectedStatus:    fiber.StatusOK,
+		},
+		{
+			description:    "invalid retention",
+			target:         "/purge?older_than=-1h",
+			expectedStatus: fiber.StatusBadRequest,
+		},
+		{
+			description:       "store error",
+			target:            "/purge",
+			expectedRetention: 720 * time.Hour,
+			storeError:        errors.New("server error"),
+			expectedStatus:    fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockPurgeStore := new(MockPurgeStore)
+			mockAuditor := new(MockAuditor)
+			purgeHandler := &PurgeHandler{
+				service:   service.NewRetentionService(mockPurgeStore, testTransactor{}, mockAuditor, hclog.NewNullLogger()),
+				retention: 720 * time.Hour,
+				logger:    hclog.NewNullLogger(),
+			}
+
+			app.Post("/purge", purgeHandler.Purge)
+
+			if testCase.expectedStatus != fiber.StatusBadRequest {
+				mockPurgeStore.On("Purge", mock.MatchedBy(func(before time.Time) bool {
+					return time.Since(before)-testCase.expectedRetention < time.Minute
+				})).Return(testCase.result, testCase.storeError).Once()
+			}
+
+			req := httptest.NewRequest(fiber.MethodPost, testCase.target, nil)
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+
+			if testCase.result != nil {
+				respBody, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+
+				var actual model.PurgeResult
+				err = json.Unmarshal(respBody, &actual)
+				assert.NoError(t, err)
+				assert.Equal(t, *testCase.result, actual)
+			}
+
+			assert.Len(t, mockAuditor.records, testCase.expectedAudits)
+			mockPurgeStore.AssertExpectations(t)
+		})
+	}
+}
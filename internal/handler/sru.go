@@ -0,0 +1,557 @@
+package handler
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"library-api/internal/cql"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	sruVersion            = "1.2"
+	sruNamespace          = "http://www.loc.gov/zing/srw/"
+	sruDiagnosticNS       = "http://www.loc.gov/zing/srw/diagnostic/"
+	sruDiagnosticPrefix   = "info:srw/diagnostic/1/"
+	sruDefaultRecords     = 10
+	sruMaximumRecords     = 100
+	sruDefaultTerms       = 20
+	sruMaximumTerms       = 100
+	schemaDublinCore      = "info:srw/schema/1/dc-v1.1"
+	schemaMARCXML         = "info:srw/schema/1/marcxml-v1.1"
+	mimeApplicationSRUXML = "application/xml; charset=utf-8"
+)
+
+const (
+	diagGeneralSystemError       = 1
+	diagUnsupportedVersion       = 5
+	diagUnsupportedParameter     = 6
+	diagMandatoryParameter       = 7
+	diagUnsupportedOperation     = 4
+	diagFirstRecordOutOfRange    = 61
+	diagUnknownSchema            = 66
+	diagUnsupportedRecordPacking = 71
+)
+
+var sruDiagnosticMessages = map[int]string{
+	diagGeneralSystemError:       "General system error",
+	diagUnsupportedOperation:     "Unsupported operation",
+	diagUnsupportedVersion:       "Unsupported version",
+	diagUnsupportedParameter:     "Unsupported parameter value",
+	diagMandatoryParameter:       "Mandatory parameter not supplied",
+	diagFirstRecordOutOfRange:    "First record position out of range",
+	diagUnknownSchema:            "Unknown schema for retrieval",
+	diagUnsupportedRecordPacking: "Record packing not supported",
+}
+
+var sruSchemas = map[string]string{
+	"dc":             schemaDublinCore,
+	schemaDublinCore: schemaDublinCore,
+	"marcxml":        schemaMARCXML,
+	schemaMARCXML:    schemaMARCXML,
+}
+
+type sruStore interface {
+	Search(ctx context.Context, query cql.Node, offset int, limit int) ([]model.Book, int, error)
+	ScanIndex(ctx context.Context, index string, from string, limit int) ([]model.Term, error)
+}
+
+type sruDiagnostic struct {
+	XMLName xml.Name `xml:"diag:diagnostic"`
+	XmlnsNS string   `xml:"xmlns:diag,attr"`
+	URI     string   `xml:"diag:uri"`
+	Details string   `xml:"diag:details,omitempty"`
+	Message string   `xml:"diag:message"`
+}
+
+type sruDiagnostics struct {
+	Items []sruDiagnostic `xml:"diag:diagnostic"`
+}
+
+type sruRecords struct {
+	Items []sruRecord `xml:"zs:record"`
+}
+
+type sruTerms struct {
+	Items []sruTerm `xml:"zs:term"`
+}
+
+type sruRecordData struct {
+	InnerXML string `xml:",innerxml"`
+	Text     string `xml:",chardata"`
+}
+
+type sruRecord struct {
+	Schema   string        `xml:"zs:recordSchema"`
+	Packing  string        `xml:"zs:recordPacking"`
+	Data     sruRecordData `xml:"zs:recordData"`
+	Position int           `xml:"zs:recordPosition,omitempty"`
+}
+
+type sruSearchRetrieveResponse struct {
+	XMLName            xml.Name        `xml:"zs:searchRetrieveResponse"`
+	XmlnsZS            string          `xml:"xmlns:zs,attr"`
+	Version            string          `xml:"zs:version"`
+	NumberOfRecords    int             `xml:"zs:numberOfRecords"`
+	Records            *sruRecords     `xml:"zs:records"`
+	NextRecordPosition int             `xml:"zs:nextRecordPosition,omitempty"`
+	Diagnostics        *sruDiagnostics `xml:"zs:diagnostics"`
+}
+
+type sruTerm struct {
+	Value           string `xml:"zs:value"`
+	NumberOfRecords int    `xml:"zs:numberOfRecords"`
+}
+
+type sruScanResponse struct {
+	XMLName     xml.Name        `xml:"zs:scanResponse"`
+	XmlnsZS     string          `xml:"xmlns:zs,attr"`
+	Version     string          `xml:"zs:version"`
+	Terms       *sruTerms       `xml:"zs:terms"`
+	Diagnostics *sruDiagnostics `xml:"zs:diagnostics"`
+}
+
+type sruExplainResponse struct {
+	XMLName     xml.Name        `xml:"zs:explainResponse"`
+	XmlnsZS     string          `xml:"xmlns:zs,attr"`
+	Version     string          `xml:"zs:version"`
+	Record      sruRecord       `xml:"zs:record"`
+	Diagnostics *sruDiagnostics `xml:"zs:diagnostics"`
+}
+
+type sruError struct {
+	code    int
+	details string
+}
+
+func (e *sruError) Error() string {
+	return fmt.Sprintf("%s: %s", sruDiagnosticMessages[e.code], e.details)
+}
+
+func newSRUDiagnostics(err error) *sruDiagnostics {
+	return &sruDiagnostics{Items: []sruDiagnostic{newSRUDiagnostic(err)}}
+}
+
+func newSRUDiagnostic(err error) sruDiagnostic {
+	diagnostic := sruDiagnostic{XmlnsNS: sruDiagnosticNS}
+
+	var cqlErr *cql.Error
+	var srErr *sruError
+	switch {
+	case errors.As(err, &cqlErr):
+		diagnostic.URI = sruDiagnosticPrefix + strconv.Itoa(cqlErr.Code)
+		diagnostic.Message = cqlErr.Message
+		diagnostic.Details = cqlErr.Details
+	case errors.As(err, &srErr):
+		diagnostic.URI = sruDiagnosticPrefix + strconv.Itoa(srErr.code)
+		diagnostic.Message = sruDiagnosticMessages[srErr.code]
+		diagnostic.Details = srErr.details
+	default:
+		diagnostic.URI = sruDiagnosticPrefix + strconv.Itoa(diagGeneralSystemError)
+		diagnostic.Message = sruDiagnosticMessages[diagGeneralSystemError]
+	}
+
+	return diagnostic
+}
+
+func (s *SRUHandler) Handle(c *fiber.Ctx) error {
+	if version := c.Query("version"); version != "" && version != sruVersion && version != "1.1" {
+		return s.sendExplain(c, &sruError{code: diagUnsupportedVersion, details: sruVersion})
+	}
+
+	operation := c.Query("operation")
+	if operation == "" && c.Query("query") != "" {
+		operation = "searchRetrieve"
+	}
+
+	switch operation {
+	case "", "explain":
+		return s.sendExplain(c, nil)
+	case "searchRetrieve":
+		return s.searchRetrieve(c)
+	case "scan":
+		return s.scan(c)
+	default:
+		return s.sendExplain(c, &sruError{code: diagUnsupportedOperation, details: operation})
+	}
+}
+
+func (s *SRUHandler) searchRetrieve(c *fiber.Ctx) error {
+	response := sruSearchRetrieveResponse{XmlnsZS: sruNamespace, Version: sruVersion}
+
+	records, err := s.retrieve(c, &response)
+	if err != nil {
+		response.Diagnostics = newSRUDiagnostics(err)
+	} else if len(records) > 0 {
+		response.Records = &sruRecords{Items: records}
+	}
+
+	return sendXML(c, response)
+}
+
+func (s *SRUHandler) retrieve(c *fiber.Ctx, response *sruSearchRetrieveResponse) ([]sruRecord, error) {
+	query := c.Query("query")
+	if query == "" {
+		return nil, &sruError{code: diagMandatoryParameter, details: "query"}
+	}
+
+	start, err := intParam(c, "startRecord", 1, 1, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	maximum, err := intParam(c, "maximumRecords", sruDefaultRecords, 0, sruMaximumRecords)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaName := c.Query("recordSchema", "dc")
+	schema, ok := sruSchemas[schemaName]
+	if !ok {
+		return nil, &sruError{code: diagUnknownSchema, details: schemaName}
+	}
+
+	packing := c.Query("recordPacking", "xml")
+	if packing != "xml" && packing != "string" {
+		return nil, &sruError{code: diagUnsupportedRecordPacking, details: packing}
+	}
+
+	node, err := cql.Parse(query)
+	if err != nil {
+		tracing.Logger(c.UserContext(), s.logger).Info("cql parsing failed", "query", query, "error", err.Error())
+		return nil, err
+	}
+
+	books, total, err := s.store.Search(c.UserContext(), node, start-1, maximum)
+	if err != nil {
+		return nil, err
+	}
+	response.NumberOfRecords = total
+
+	if total > 0 && start > total {
+		return nil, &sruError{code: diagFirstRecordOutOfRange, details: strconv.Itoa(start)}
+	}
+
+	records := make([]sruRecord, 0, len(books))
+	for i, book := range books {
+		data, err := marshalRecord(schema, book)
+		if err != nil {
+			tracing.Logger(c.UserContext(), s.logger).Error("record marshalling failed", "id", book.ID, "error", err.Error())
+			return nil, err
+		}
+
+		record := sruRecord{Schema: schema, Packing: packing, Position: start + i}
+		if packing == "xml" {
+			record.Data.InnerXML = string(data)
+		} else {
+			record.Data.Text = string(data)
+		}
+		records = append(records, record)
+	}
+
+	if next := start + len(books); len(books) > 0 && next <= total {
+		response.NextRecordPosition = next
+	}
+
+	return records, nil
+}
+
+func (s *SRUHandler) scan(c *fiber.Ctx) error {
+	response := sruScanResponse{XmlnsZS: sruNamespace, Version: sruVersion}
+
+	terms, err := s.scanTerms(c)
+	if err != nil {
+		response.Diagnostics = newSRUDiagnostics(err)
+	} else {
+		response.Terms = &sruTerms{Items: terms}
+	}
+
+	return sendXML(c, response)
+}
+
+func (s *SRUHandler) scanTerms(c *fiber.Ctx) ([]sruTerm, error) {
+	scanClause := c.Query("scanClause")
+	if scanClause == "" {
+		return nil, &sruError{code: diagMandatoryParameter, details: "scanClause"}
+	}
+
+	maximum, err := intParam(c, "maximumTerms", sruDefaultTerms, 1, sruMaximumTerms)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = intParam(c, "responsePosition", 1, 1, 1); err != nil {
+		return nil, err
+	}
+
+	node, err := cql.Parse(scanClause)
+	if err != nil {
+		return nil, err
+	}
+
+	clause, ok := node.(*cql.Clause)
+	if !ok {
+		return nil, &sruError{code: diagUnsupportedParameter, details: "scanClause"}
+	}
+
+	terms, err := s.store.ScanIndex(c.UserContext(), clause.Index, clause.Term, maximum)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]sruTerm, 0, len(terms))
+	for _, term := range terms {
+		result = append(result, sruTerm{Value: term.Value, NumberOfRecords: term.NumberOfRecords})
+	}
+
+	return result, nil
+}
+
+func (s *SRUHandler) sendExplain(c *fiber.Ctx, diagnostic error) error {
+	data, err := xml.Marshal(newExplain(c.Hostname()))
+	if err != nil {
+		tracing.Logger(c.UserContext(), s.logger).Error("explain marshalling failed", "error", err.Error())
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	response := sruExplainResponse{
+		XmlnsZS: sruNamespace,
+		Version: sruVersion,
+		Record: sruRecord{
+			Schema:  "http://explain.z3950.org/dtd/2.0/",
+			Packing: "xml",
+			Data:    sruRecordData{InnerXML: string(data)},
+		},
+	}
+
+	if diagnostic != nil {
+		response.Diagnostics = newSRUDiagnostics(diagnostic)
+	}
+
+	return sendXML(c, response)
+}
+
+func sendXML(c *fiber.Ctx, response any) error {
+	data, err := xml.Marshal(response)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "server error",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, mimeApplicationSRUXML)
+	return c.Status(fiber.StatusOK).Send(append([]byte(xml.Header), data...))
+}
+
+func intParam(c *fiber.Ctx, name string, fallback int, minimum int, maximum int) (int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < minimum || (maximum >= 0 && value > maximum) {
+		return 0, &sruError{code: diagUnsupportedParameter, details: name}
+	}
+
+	return value, nil
+}
+
+type dcRecord struct {
+	XMLName    xml.Name `xml:"srw_dc:dc"`
+	XmlnsSRWDC string   `xml:"xmlns:srw_dc,attr"`
+	XmlnsDC    string   `xml:"xmlns:dc,attr"`
+	Title      string   `xml:"dc:title"`
+	Creator    string   `xml:"dc:creator,omitempty"`
+	Subject    string   `xml:"dc:subject,omitempty"`
+	Type       string   `xml:"dc:type"`
+	Identifier []string `xml:"dc:identifier"`
+}
+
+type marcSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+type marcControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type marcDataField struct {
+	Tag       string         `xml:"tag,attr"`
+	Ind1      string         `xml:"ind1,attr"`
+	Ind2      string         `xml:"ind2,attr"`
+	Subfields []marcSubfield `xml:"subfield"`
+}
+
+type marcRecord struct {
+	XMLName       xml.Name           `xml:"record"`
+	Xmlns         string             `xml:"xmlns,attr"`
+	Leader        string             `xml:"leader"`
+	ControlFields []marcControlField `xml:"controlfield"`
+	DataFields    []marcDataField    `xml:"datafield"`
+}
+
+func marshalRecord(schema string, book model.Book) ([]byte, error) {
+	if schema == schemaMARCXML {
+		return xml.Marshal(newMARCRecord(book))
+	}
+
+	return xml.Marshal(newDCRecord(book))
+}
+
+func newDCRecord(book model.Book) dcRecord {
+	record := dcRecord{
+		XmlnsSRWDC: "info:srw/schema/1/dc-schema",
+		XmlnsDC:    "http://purl.org/dc/elements/1.1/",
+		Title:      book.Title,
+		Creator:    authorName(book.Author),
+		Subject:    book.Genre,
+		Type:       "Text",
+		Identifier: []string{"urn:uuid:" + book.ID},
+	}
+
+	if book.ISBN != "" {
+		record.Identifier = append(record.Identifier, "urn:isbn:"+book.ISBN)
+	}
+
+	return record
+}
+
+func newMARCRecord(book model.Book) marcRecord {
+	record := marcRecord{
+		Xmlns:         "http://www.loc.gov/MARC21/slim",
+		Leader:        "00000nam a2200000 a 4500",
+		ControlFields: []marcControlField{{Tag: "001", Value: book.ID}},
+	}
+
+	if book.ISBN != "" {
+		record.DataFields = append(record.DataFields, marcDataField{
+			Tag: "020", Ind1: " ", Ind2: " ",
+			Subfields: []marcSubfield{{Code: "a", Value: book.ISBN}},
+		})
+	}
+
+	titleInd1 := "0"
+	if name := authorName(book.Author); name != "" {
+		titleInd1 = "1"
+		record.DataFields = append(record.DataFields, marcDataField{
+			Tag: "100", Ind1: "1", Ind2: " ",
+			Subfields: []marcSubfield{{Code: "a", Value: invertName(name)}},
+		})
+	}
+
+	record.DataFields = append(record.DataFields, marcDataField{
+		Tag: "245", Ind1: titleInd1, Ind2: strconv.Itoa(nonFilingCharacters(book.Title)),
+		Subfields: []marcSubfield{{Code: "a", Value: book.Title}},
+	})
+
+	if book.Genre != "" {
+		record.DataFields = append(record.DataFields, marcDataField{
+			Tag: "655", Ind1: " ", Ind2: "4",
+			Subfields: []marcSubfield{{Code: "a", Value: book.Genre}},
+		})
+	}
+
+	return record
+}
+
+func nonFilingCharacters(title string) int {
+	lower := strings.ToLower(title)
+	for _, article := range []string{"the ", "an ", "a "} {
+		if strings.HasPrefix(lower, article) {
+			return len(article)
+		}
+	}
+
+	return 0
+}
+
+type explainIndexName struct {
+	Set   string `xml:"set,attr"`
+	Value string `xml:",chardata"`
+}
+
+type explainIndex struct {
+	Title string           `xml:"title"`
+	Name  explainIndexName `xml:"map>name"`
+}
+
+type explainSet struct {
+	Name       string `xml:"name,attr"`
+	Identifier string `xml:"identifier,attr"`
+}
+
+type explainSchema struct {
+	Name       string `xml:"name,attr"`
+	Identifier string `xml:"identifier,attr"`
+	Title      string `xml:"title"`
+}
+
+type explainSetting struct {
+	Type  string `xml:"type,attr"`
+	Value int    `xml:",chardata"`
+}
+
+type explainRecord struct {
+	XMLName    xml.Name `xml:"explain"`
+	Xmlns      string   `xml:"xmlns,attr"`
+	ServerInfo struct {
+		Protocol string `xml:"protocol,attr"`
+		Version  string `xml:"version,attr"`
+		Host     string `xml:"host"`
+		Database string `xml:"database"`
+	} `xml:"serverInfo"`
+	DatabaseTitle string           `xml:"databaseInfo>title"`
+	Sets          []explainSet     `xml:"indexInfo>set"`
+	Indexes       []explainIndex   `xml:"indexInfo>index"`
+	Schemas       []explainSchema  `xml:"schemaInfo>schema"`
+	Defaults      []explainSetting `xml:"configInfo>default"`
+	Settings      []explainSetting `xml:"configInfo>setting"`
+}
+
+func newExplain(host string) explainRecord {
+	record := explainRecord{
+		Xmlns:         "http://explain.z3950.org/dtd/2.0/",
+		DatabaseTitle: "Library catalog",
+		Sets: []explainSet{
+			{Name: "cql", Identifier: "info:srw/cql-context-set/1/cql-v1.2"},
+			{Name: "dc", Identifier: "info:srw/cql-context-set/1/dc-v1.1"},
+			{Name: "bath", Identifier: "http://zing.z3950.org/cql/bath/2.0/"},
+		},
+		Indexes: []explainIndex{
+			{Title: "title", Name: explainIndexName{Set: "dc", Value: "title"}},
+			{Title: "author", Name: explainIndexName{Set: "dc", Value: "creator"}},
+			{Title: "genre", Name: explainIndexName{Set: "dc", Value: "subject"}},
+			{Title: "ISBN", Name: explainIndexName{Set: "bath", Value: "isbn"}},
+			{Title: "any", Name: explainIndexName{Set: "cql", Value: "serverChoice"}},
+		},
+		Schemas: []explainSchema{
+			{Name: "dc", Identifier: schemaDublinCore, Title: "Dublin Core"},
+			{Name: "marcxml", Identifier: schemaMARCXML, Title: "MARCXML"},
+		},
+		Defaults: []explainSetting{
+			{Type: "numberOfRecords", Value: sruDefaultRecords},
+		},
+		Settings: []explainSetting{
+			{Type: "maximumRecords", Value: sruMaximumRecords},
+			{Type: "maximumTerms", Value: sruMaximumTerms},
+		},
+	}
+
+	record.ServerInfo.Protocol = "SRU"
+	record.ServerInfo.Version = sruVersion
+	record.ServerInfo.Host = host
+	record.ServerInfo.Database = "sru"
+
+	return record
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"library-api/internal/cql"
+	"library-api/internal/model"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSRUStore struct {
+	mock.Mock
+}
+
+func (m *MockSRUStore) Search(ctx context.Context, query cql.Node, offset int, limit int) ([]model.Book, int, error) {
+	args := m.Called(query, offset, limit)
+	return args.Get(0).([]model.Book), args.Int(1), args.Error(2)
+}
+
+func (m *MockSRUStore) ScanIndex(ctx context.Context, index string, from string, limit int) ([]model.Term, error) {
+	args := m.Called(index, from, limit)
+	return args.Get(0).([]model.Term), args.Error(1)
+}
+
+func TestSRUHandler_Handle(t *testing.T) {
+	testCases := []struct {
+		description  string
+		target       string
+		setupMock    func(store *MockSRUStore)
+		expectedBody []string
+	}{
+		{
+			description: "explain by default",
+			target:      "/sru",
+			setupMock:   func(store *MockSRUStore) {},
+			expectedBody: []string{
+				"<zs:explainResponse",
+				`<set name="bath" identifier="http://zing.z3950.org/cql/bath/2.0/"></set>`,
+				`<schema name="marcxml" identifier="info:srw/schema/1/marcxml-v1.1">`,
+			},
+		},
+		{
+			description: "unsupported operation",
+			target:      "/sru?operation=update",
+			setupMock:   func(store *MockSRUStore) {},
+			expectedBody: []string{
+				"<diag:uri>info:srw/diagnostic/1/4</diag:uri>",
+			},
+		},
+		{
+			description: "search retrieve dublin core",
+			target:      "/sru?operation=searchRetrieve&version=1.2&query=dc.creator%3Dking&maximumRecords=1",
+			setupMock: func(store *MockSRUStore) {
+				store.On("Search", &cql.Clause{Index: "dc.creator", Relation: "=", Term: "king"}, 0, 1).
+					Return(citationBooks(), 2, nil).Once()
+			},
+			expectedBody: []string{
+				"<zs:numberOfRecords>2</zs:numberOfRecords>",
+				"<zs:recordSchema>info:srw/schema/1/dc-v1.1</zs:recordSchema>",
+				"<dc:title>The Shining</dc:title><dc:creator>Stephen King</dc:creator>",
+				"<dc:identifier>urn:isbn:978-0-385-12167-5</dc:identifier>",
+				"<zs:recordPosition>1</zs:recordPosition>",
+				"<zs:nextRecordPosition>2</zs:nextRecordPosition>",
+			},
+		},
+		{
+			description: "search retrieve marcxml as string",
+			target:      "/sru?query=shining&recordSchema=marcxml&recordPacking=string",
+			setupMock: func(store *MockSRUStore) {
+				store.On("Search", mock.Anything, 0, 10).Return(citationBooks(), 1, nil).Once()
+			},
+			expectedBody: []string{
+				"<zs:recordPacking>string</zs:recordPacking>",
+				`&lt;datafield tag=&#34;245&#34; ind1=&#34;1&#34; ind2=&#34;4&#34;&gt;`,
+			},
+		},
+		{
+			description: "query syntax error",
+			target:      "/sru?operation=searchRetrieve&query=%28dc.title%3Dit",
+			setupMock:   func(store *MockSRUStore) {},
+			expectedBody: []string{
+				"<zs:numberOfRecords>0</zs:numberOfRecords>",
+				"<diag:uri>info:srw/diagnostic/1/10</diag:uri>",
+			},
+		},
+		{
+			description: "unknown schema",
+			target:      "/sru?query=it&recordSchema=mods",
+			setupMock:   func(store *MockSRUStore) {},
+			expectedBody: []string{
+				"<diag:uri>info:srw/diagnostic/1/66</diag:uri>",
+			},
+		},
+		{
+			description: "store error",
+			target:      "/sru?query=it",
+			setupMock: func(store *MockSRUStore) {
+				store.On("Search", mock.Anything, 0, 10).Return([]model.Book{}, 0, errors.New("server error")).Once()
+			},
+			expectedBody: []string{
+				"<diag:uri>info:srw/diagnostic/1/1</diag:uri>",
+			},
+		},
+		{
+			description: "scan",
+			target:      "/sru?operation=scan&scanClause=dc.title%3Dh&maximumTerms=2",
+			setupMock: func(store *MockSRUStore) {
+				store.On("ScanIndex", "dc.title", "h", 2).Return([]model.Term{
+					{Value: "Harry Potter and the Chamber of Secrets", NumberOfRecords: 1},
+				}, nil).Once()
+			},
+			expectedBody: []string{
+				"<zs:scanResponse",
+				"<zs:term><zs:value>Harry Potter and the Chamber of Secrets</zs:value><zs:numberOfRecords>1</zs:numberOfRecords></zs:term>",
+			},
+		},
+		{
+			description: "scan without clause",
+			target:      "/sru?operation=scan",
+			setupMock:   func(store *MockSRUStore) {},
+			expectedBody: []string{
+				"<diag:uri>info:srw/diagnostic/1/7</diag:uri>",
+				"<diag:details>scanClause</diag:details>",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			app := fiber.New()
+
+			mockSRUStore := new(MockSRUStore)
+			sruHandler := &SRUHandler{
+				store:  mockSRUStore,
+				logger: hclog.NewNullLogger(),
+			}
+
+			app.Get("/sru", sruHandler.Handle)
+
+			testCase.setupMock(mockSRUStore)
+
+			req := httptest.NewRequest(fiber.MethodGet, testCase.target, nil)
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+			assert.Equal(t, "application/xml; charset=utf-8", resp.Header.Get(fiber.HeaderContentType))
+
+			respBody, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			for _, expected := range testCase.expectedBody {
+				assert.Contains(t, string(respBody), expected)
+			}
+
+			mockSRUStore.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"library-api/internal/auth"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+type store interface {
+	Reserve(ctx context.Context, record *model.IdempotencyRecord) (*model.IdempotencyRecord, error)
+	Complete(ctx context.Context, record *model.IdempotencyRecord) error
+	Release(ctx context.Context, owner string, key string) error
+}
+
+type Middleware struct {
+	store  store
+	ttl    time.Duration
+	logger hclog.Logger
+}
+
+func New(store store, ttl time.Duration, logger hclog.Logger) *Middleware {
+	return &Middleware{
+		store:  store,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+// Handle makes a request with an Idempotency-Key header safe to retry. The
+// first request with a key runs the handler and stores its response, later
+// requests with the same key and payload get that response replayed. Keys are
+// scoped to the authenticated subject.
+func (m *Middleware) Handle(c *fiber.Ctx) error {
+	key := c.Get(Header)
+	if key == "" {
+		return c.Next()
+	}
+
+	if len(key) > maxKeyLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Idempotency-Key header is too long"})
+	}
+
+	record := &model.IdempotencyRecord{
+		Owner:       owner(c),
+		Key:         key,
+		RequestHash: requestHash(c),
+		ExpiresAt:   time.Now().Add(m.ttl),
+	}
+
+	existing, err := m.store.Reserve(c.UserContext(), record)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return inProgress(c)
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "server error"})
+	}
+
+	if existing != nil {
+		return replay(c, existing, record)
+	}
+
+	err = c.Next()
+	if err != nil || c.Response().StatusCode() >= fiber.StatusInternalServerError {
+		_ = m.store.Release(context.WithoutCancel(c.UserContext()), record.Owner, record.Key)
+		return err
+	}
+
+	record.StatusCode = c.Response().StatusCode()
+	record.ContentType = string(c.Response().Header.ContentType())
+	record.Location = string(c.Response().Header.Peek(fiber.HeaderLocation))
+	record.ETag = string(c.Response().Header.Peek(fiber.HeaderETag))
+	record.Body = append([]byte(nil), c.Response().Body()...)
+
+	err = m.store.Complete(context.WithoutCancel(c.UserContext()), record)
+	if err != nil {
+		tracing.Logger(c.UserContext(), m.logger).Error("idempotent response was not stored", "owner", record.Owner, "error", err.Error())
+	}
+
+	return nil
+}
+
+func replay(c *fiber.Ctx, existing *model.IdempotencyRecord, record *model.IdempotencyRecord) error {
+	if existing.RequestHash != record.RequestHash {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "Idempotency-Key was already used for a different request",
+		})
+	}
+
+	if !existing.Completed() {
+		return inProgress(c)
+	}
+
+	c.Set(ReplayedHeader, "true")
+	c.Set(fiber.HeaderContentType, existing.ContentType)
+	if existing.Location != "" {
+		c.Location(existing.Location)
+	}
+	if existing.ETag != "" {
+		c.Set(fiber.HeaderETag, existing.ETag)
+	}
+
+	return c.Status(existing.StatusCode).Send(existing.Body)
+}
+
+func inProgress(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"error": "a request with this Idempotency-Key is still being processed",
+	})
+}
+
+func owner(c *fiber.Ctx) string {
+	claims, ok := auth.ClaimsFrom(c)
+	if !ok {
+		return ""
+	}
+
+	return claims.Subject
+}
+
+func requestHash(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.OriginalURL()))
+	hash.Write([]byte{0})
+	hash.Write(c.Body())
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
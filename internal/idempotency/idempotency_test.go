@@ -0,0 +1,178 @@
+package idempotency
+
+import (
+	"context"
+	"io"
+	"library-api/internal/model"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStore struct {
+	records  map[string]*model.IdempotencyRecord
+	pending  bool
+	released int
+}
+
+func (s *testStore) Reserve(ctx context.Context, record *model.IdempotencyRecord) (*model.IdempotencyRecord, error) {
+	if s.pending {
+		return &model.IdempotencyRecord{RequestHash: record.RequestHash}, nil
+	}
+
+	if existing, ok := s.records[record.Owner+record.Key]; ok {
+		return existing, nil
+	}
+
+	reserved := *record
+	s.records[record.Owner+record.Key] = &reserved
+	return nil, nil
+}
+
+func (s *testStore) Complete(ctx context.Context, record *model.IdempotencyRecord) error {
+	completed := *record
+	s.records[record.Owner+record.Key] = &completed
+	return nil
+}
+
+func (s *testStore) Release(ctx context.Context, owner string, key string) error {
+	delete(s.records, owner+key)
+	s.released++
+	return nil
+}
+
+type request struct {
+	key            string
+	body           string
+	expectedStatus int
+	expectedBody   string
+	replayed       bool
+}
+
+func TestMiddleware_Handle(t *testing.T) {
+	testCases := []struct {
+		description   string
+		status        int
+		pending       bool
+		requests      []request
+		expectedCalls int
+		expectedFreed int
+	}{
+		{
+			description: "without key every request runs",
+			status:      fiber.StatusCreated,
+			requests: []request{
+				{body: `{"title":"It"}`, expectedStatus: fiber.StatusCreated, expectedBody: `{"call":1}`},
+				{body: `{"title":"It"}`, expectedStatus: fiber.StatusCreated, expectedBody: `{"call":2}`},
+			},
+			expectedCalls: 2,
+		},
+		{
+			description: "retry replays the first response",
+			status:      fiber.StatusCreated,
+			requests: []request{
+				{key: "a1", body: `{"title":"It"}`, expectedStatus: fiber.StatusCreated, expectedBody: `{"call":1}`},
+				{key: "a1", body: `{"title":"It"}`, expectedStatus: fiber.StatusCreated, expectedBody: `{"call":1}`, replayed: true},
+				{key: "a2", body: `{"title":"It"}`, expectedStatus: fiber.StatusCreated, expectedBody: `{"call":2}`},
+			},
+			expectedCalls: 2,
+		},
+		{
+			description: "client errors are replayed as well",
+			status:      fiber.StatusBadRequest,
+			requests: []request{
+				{key: "a1", body: `{}`, expectedStatus: fiber.StatusBadRequest, expectedBody: `{"call":1}`},
+				{key: "a1", body: `{}`, expectedStatus: fiber.StatusBadRequest, expectedBody: `{"call":1}`, replayed: true},
+			},
+			expectedCalls: 1,
+		},
+		{
+			description: "key reused with another payload",
+			status:      fiber.StatusCreated,
+			requests: []request{
+				{key: "a1", body: `{"title":"It"}`, expectedStatus: fiber.StatusCreated, expectedBody: `{"call":1}`},
+				{key: "a1", body: `{"title":"Carrie"}`, expectedStatus: fiber.StatusUnprocessableEntity,
+					expectedBody: `{"error":"Idempotency-Key was already used for a different request"}`},
+			},
+			expectedCalls: 1,
+		},
+		{
+			description: "server errors release the key",
+			status:      fiber.StatusInternalServerError,
+			requests: []request{
+				{key: "a1", body: `{"title":"It"}`, expectedStatus: fiber.StatusInternalServerError, expectedBody: `{"call":1}`},
+				{key: "a1", body: `{"title":"It"}`, expectedStatus: fiber.StatusInternalServerError, expectedBody: `{"call":2}`},
+			},
+			expectedCalls: 2,
+			expectedFreed: 2,
+		},
+		{
+			description: "original request still running",
+			status:      fiber.StatusCreated,
+			pending:     true,
+			requests: []request{
+				{key: "a1", body: `{"title":"It"}`, expectedStatus: fiber.StatusConflict,
+					expectedBody: `{"error":"a request with this Idempotency-Key is still being processed"}`},
+			},
+		},
+		{
+			description: "key too long",
+			status:      fiber.StatusCreated,
+			requests: []request{
+				{key: strings.Repeat("k", 256), body: `{}`, expectedStatus: fiber.StatusBadRequest,
+					expectedBody: `{"error":"Idempotency-Key header is too long"}`},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			store := &testStore{records: make(map[string]*model.IdempotencyRecord), pending: testCase.pending}
+
+			middleware := New(store, time.Hour, hclog.NewNullLogger())
+
+			calls := 0
+			app := fiber.New()
+			app.Post("/book", middleware.Handle, func(c *fiber.Ctx) error {
+				calls++
+				c.Location("/book/" + strconv.Itoa(calls))
+				c.Set(fiber.HeaderETag, `"`+strconv.Itoa(calls)+`"`)
+				return c.Status(testCase.status).JSON(fiber.Map{"call": calls})
+			})
+
+			for _, r := range testCase.requests {
+				req := httptest.NewRequest(fiber.MethodPost, "/book", strings.NewReader(r.body))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				if r.key != "" {
+					req.Header.Set(Header, r.key)
+				}
+
+				resp, err := app.Test(req, -1)
+				assert.NoError(t, err)
+
+				assert.Equal(t, r.expectedStatus, resp.StatusCode)
+				assert.Equal(t, fiber.MIMEApplicationJSON, resp.Header.Get(fiber.HeaderContentType))
+				if r.replayed {
+					assert.Equal(t, "true", resp.Header.Get(ReplayedHeader))
+					assert.Equal(t, "/book/1", resp.Header.Get(fiber.HeaderLocation))
+					assert.Equal(t, `"1"`, resp.Header.Get(fiber.HeaderETag))
+				} else {
+					assert.Empty(t, resp.Header.Get(ReplayedHeader))
+				}
+
+				respBody, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, r.expectedBody, string(respBody))
+			}
+
+			assert.Equal(t, testCase.expectedCalls, calls)
+			assert.Equal(t, testCase.expectedFreed, store.released)
+		})
+	}
+}
@@ -5,4 +5,5 @@ type Author struct {
 	FullName       *string `json:"full_name"`
 	NickName       string  `json:"nick_name,omitempty"`
 	Specialization string  `json:"specialization,omitempty"`
+	Version        int     `json:"version,omitempty"`
 }
@@ -7,4 +7,6 @@ type Book struct {
 	Genre     string `json:"genre"`
 	ISBN      string `json:"isbn"`
 	Author    Author `json:"author,omitempty"`
+	Version   int    `json:"version,omitempty"`
+	Borrowed  bool   `json:"-"`
 }
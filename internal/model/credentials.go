@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type Credentials struct {
+	MemberID     string    `json:"member_id"`
+	Login        string    `json:"login"`
+	PasswordHash string    `json:"-"`
+	Role         string    `json:"role"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const DateLayout = "2006-01-02"
+
+// Date is a calendar day without a time of day, serialized as YYYY-MM-DD in
+// JSON and stored in DATE columns.
+type Date struct {
+	time.Time
+}
+
+func NewDate(t time.Time) Date {
+	year, month, day := t.Date()
+	return Date{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+func Today() Date {
+	return NewDate(time.Now())
+}
+
+func (d Date) AddDate(years, months, days int) Date {
+	return NewDate(d.Time.AddDate(years, months, days))
+}
+
+func (d Date) String() string {
+	return d.Format(DateLayout)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		*d = Date{}
+		return nil
+	}
+
+	t, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return fmt.Errorf("date must be formatted as %s: %w", DateLayout, err)
+	}
+
+	*d = NewDate(t)
+
+	return nil
+}
+
+func (d *Date) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = NewDate(v)
+	case string:
+		return d.UnmarshalJSON([]byte(v))
+	case []byte:
+		return d.UnmarshalJSON(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Date", src)
+	}
+
+	return nil
+}
+
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+
+	return d.String(), nil
+}
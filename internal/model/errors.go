@@ -0,0 +1,11 @@
+package model
+
+import "errors"
+
+var (
+	ErrNotFound        = errors.New("record not found")
+	ErrReferenced      = errors.New("record is still referenced")
+	ErrParentDeleted   = errors.New("record belongs to a deleted record")
+	ErrVersionConflict = errors.New("record was modified concurrently")
+	ErrCardNumberTaken = errors.New("card number is already issued")
+)
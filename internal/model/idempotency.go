@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// IdempotencyRecord is a reserved Idempotency-Key together with the response
+// it produced. A zero StatusCode means the original request is still running.
+type IdempotencyRecord struct {
+	Owner       string
+	Key         string
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Location    string
+	ETag        string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
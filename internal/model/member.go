@@ -1,6 +1,42 @@
 package model
 
+const (
+	MemberStatusActive    = "active"
+	MemberStatusSuspended = "suspended"
+	MemberStatusExpired   = "expired"
+)
+
 type Member struct {
-	ID       string `json:"id"`
-	FullName string `json:"full_name"`
+	ID           string `json:"id"`
+	FullName     string `json:"full_name"`
+	Email        string `json:"email,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	Address      string `json:"address,omitempty"`
+	DateOfBirth  Date   `json:"date_of_birth"`
+	CardNumber   string `json:"card_number,omitempty"`
+	RegisteredAt Date   `json:"registered_at"`
+	ExpiresAt    Date   `json:"expires_at"`
+	Status       string `json:"status,omitempty"`
+
+	SuspensionReason string `json:"suspension_reason,omitempty"`
+	SuspendedUntil   Date   `json:"suspended_until"`
+
+	Version int `json:"version,omitempty"`
+}
+
+// MemberChange is a member before and after a change applied in bulk, such
+// as the daily status sweep.
+type MemberChange struct {
+	Before Member
+	After  Member
+}
+
+type MemberFilter struct {
+	CardNumber string
+	Email      string
+}
+
+type Suspension struct {
+	Reason string `json:"reason"`
+	Until  Date   `json:"until"`
 }
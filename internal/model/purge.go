@@ -0,0 +1,9 @@
+package model
+
+// PurgeResult lists the IDs of the records removed by a purge, grouped by
+// entity.
+type PurgeResult struct {
+	Authors []string `json:"authors"`
+	Books   []string `json:"books"`
+	Members []string `json:"members"`
+}
@@ -0,0 +1,6 @@
+package model
+
+type Term struct {
+	Value           string `json:"value"`
+	NumberOfRecords int    `json:"number_of_records"`
+}
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"library-api/pkg/tracing"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+const webhookTimeout = 10 * time.Second
+
+type webhookPayload struct {
+	Kind     string `json:"kind"`
+	MemberID string `json:"member_id"`
+	To       string `json:"to"`
+	Subject  string `json:"subject"`
+	Body     string `json:"body"`
+}
+
+// WebhookNotifier delivers messages by POSTing them as JSON to a URL, behind
+// which a mail or SMS gateway does the actual sending.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+	logger hclog.Logger
+}
+
+func NewWebhookNotifier(url string, logger hclog.Logger) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+		logger: logger,
+	}
+}
+
+// Send posts the message and fails unless the webhook answers with a 2xx
+// status. As with LogNotifier, the body is never logged.
+func (w *WebhookNotifier) Send(ctx context.Context, message Message) error {
+	payload, err := json.Marshal(webhookPayload{
+		Kind:     message.Kind,
+		MemberID: message.MemberID,
+		To:       message.To,
+		Subject:  message.Subject,
+		Body:     message.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("notification webhook answered %s", response.Status)
+	}
+
+	tracing.Logger(ctx, w.logger).Info("notification sent", "member_id", message.MemberID, "to", message.To, "kind", message.Kind)
+
+	return nil
+}
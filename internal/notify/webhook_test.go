@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookNotifier_Send(t *testing.T) {
+	message := Message{
+		Kind:     KindPasswordReset,
+		MemberID: "1de94d3e-09b2-4f62-bfff-964012c649d3",
+		To:       "ada@example.org",
+		Subject:  "Password reset",
+		Body:     "Use this token to reset your password: secret",
+	}
+
+	testCases := []struct {
+		description   string
+		status        int
+		expectedError string
+	}{
+		{
+			description: "delivered",
+			status:      http.StatusAccepted,
+		},
+		{
+			description:   "webhook refused the message",
+			status:        http.StatusBadGateway,
+			expectedError: "notification webhook answered 502 Bad Gateway",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			var received webhookPayload
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+				w.WriteHeader(testCase.status)
+			}))
+			defer server.Close()
+
+			notifier := NewWebhookNotifier(server.URL, hclog.NewNullLogger())
+
+			err := notifier.Send(context.Background(), message)
+			if testCase.expectedError != "" {
+				assert.EqualError(t, err, testCase.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, webhookPayload{
+				Kind:     message.Kind,
+				MemberID: message.MemberID,
+				To:       message.To,
+				Subject:  message.Subject,
+				Body:     message.Body,
+			}, received)
+		})
+	}
+}
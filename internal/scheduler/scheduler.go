@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+type Task struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs every task once on start and then on its interval until
+// Stop is called. Failures are logged and retried on the next tick. The
+// context passed to a task is cancelled by Stop.
+type Scheduler struct {
+	tasks  []Task
+	logger hclog.Logger
+	ctx    context.Context
+	stop   context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(logger hclog.Logger, tasks ...Task) *Scheduler {
+	ctx, stop := context.WithCancel(context.Background())
+
+	return &Scheduler{
+		tasks:  tasks,
+		logger: logger,
+		ctx:    ctx,
+		stop:   stop,
+	}
+}
+
+func (s *Scheduler) Start() {
+	for _, task := range s.tasks {
+		s.wg.Add(1)
+		go s.loop(task)
+	}
+}
+
+func (s *Scheduler) Stop() {
+	s.stop()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(task Task) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(task.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.run(task)
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) run(task Task) {
+	start := time.Now()
+
+	err := task.Run(s.ctx)
+	if err != nil {
+		s.logger.Error("scheduled task failed", "task", task.Name, "error", err.Error())
+		return
+	}
+
+	s.logger.Debug("scheduled task finished", "task", task.Name, "duration", time.Since(start).String())
+}
@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler(t *testing.T) {
+	var runs, failures atomic.Int32
+
+	s := New(hclog.NewNullLogger(),
+		Task{
+			Name:     "counter",
+			Interval: 10 * time.Millisecond,
+			Run: func(ctx context.Context) error {
+				runs.Add(1)
+				return nil
+			},
+		},
+		Task{
+			Name:     "failing",
+			Interval: 10 * time.Millisecond,
+			Run: func(ctx context.Context) error {
+				failures.Add(1)
+				return errors.New("error")
+			},
+		},
+	)
+
+	s.Start()
+	assert.Eventually(t, func() bool {
+		return runs.Load() >= 3 && failures.Load() >= 3
+	}, time.Second, 5*time.Millisecond)
+	s.Stop()
+
+	stopped := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, stopped, runs.Load())
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+
+	"github.com/google/uuid"
+)
+
+type authorStore interface {
+	Create(ctx context.Context, author *model.Author) error
+	Get(ctx context.Context) ([]model.Author, error)
+	GetByID(ctx context.Context, id string) (*model.Author, error)
+	Update(ctx context.Context, id string, author *model.Author) error
+	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) error
+	GetAuthorsBooks(ctx context.Context, id string) ([]string, error)
+}
+
+type bookStore interface {
+	Create(ctx context.Context, book *model.Book) error
+	Get(ctx context.Context) ([]model.Book, error)
+	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) error
+	Update(ctx context.Context, id string, book *model.Book) error
+	GetByIDs(ctx context.Context, ids []string) ([]model.Book, error)
+	GetCatalog(ctx context.Context) ([]model.Book, error)
+}
+
+func (s *CatalogService) Authors(ctx context.Context) ([]model.Author, error) {
+	return s.authors.Get(ctx)
+}
+
+func (s *CatalogService) Author(ctx context.Context, id string) (*model.Author, error) {
+	return s.authors.GetByID(ctx, id)
+}
+
+func (s *CatalogService) AuthorBooks(ctx context.Context, id string) ([]string, error) {
+	return s.authors.GetAuthorsBooks(ctx, id)
+}
+
+func (s *CatalogService) CreateAuthor(ctx context.Context, author *model.Author) error {
+	author.ID = uuid.New().String()
+	return s.addAuthor(ctx, author)
+}
+
+// ImportAuthor adds an author under the id it already has, or under a new
+// one when it has none. Catalogue imports need this because their books
+// refer to the author by id.
+func (s *CatalogService) ImportAuthor(ctx context.Context, author *model.Author) error {
+	if author.ID == "" {
+		author.ID = uuid.New().String()
+	}
+
+	return s.addAuthor(ctx, author)
+}
+
+func (s *CatalogService) addAuthor(ctx context.Context, author *model.Author) error {
+	return s.tx.Run(ctx, func(ctx context.Context) error {
+		err := s.authors.Create(ctx, author)
+		if err != nil {
+			return rejected(err)
+		}
+
+		return s.auditor.Record(ctx, model.AuditActionCreate, model.AuditEntityAuthor, author.ID, nil, author)
+	})
+}
+
+// UpdateAuthor replaces the author and returns the state it had before.
+func (s *CatalogService) UpdateAuthor(ctx context.Context, id string, author *model.Author,
+	precondition Precondition) (*model.Author, error) {
+	var before *model.Author
+	err := s.tx.Run(ctx, func(ctx context.Context) error {
+		var err error
+		before, err = s.authors.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if !precondition(before.Version) {
+			return ErrPreconditionFailed
+		}
+
+		author.Version = before.Version
+		err = s.authors.Update(ctx, id, author)
+		if err != nil {
+			return rejected(err)
+		}
+
+		return s.auditor.Record(ctx, model.AuditActionUpdate, model.AuditEntityAuthor, id, before, author)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return before, nil
+}
+
+// DeleteAuthor soft deletes an author without books and returns the
+// deleted state.
+func (s *CatalogService) DeleteAuthor(ctx context.Context, id string, precondition Precondition) (*model.Author, error) {
+	var before *model.Author
+	err := s.tx.Run(ctx, func(ctx context.Context) error {
+		var err error
+		before, err = s.authors.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if !precondition(before.Version) {
+			return ErrPreconditionFailed
+		}
+
+		err = s.authors.Delete(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return s.auditor.Record(ctx, model.AuditActionDelete, model.AuditEntityAuthor, id, before, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return before, nil
+}
+
+// RestoreAuthor brings back a soft deleted author and returns it.
+func (s *CatalogService) RestoreAuthor(ctx context.Context, id string) (*model.Author, error) {
+	var after *model.Author
+	err := s.tx.Run(ctx, func(ctx context.Context) error {
+		err := s.authors.Restore(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		after, err = s.authors.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return s.auditor.Record(ctx, model.AuditActionRestore, model.AuditEntityAuthor, id, nil, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+func (s *CatalogService) Books(ctx context.Context) ([]model.Book, error) {
+	return s.books.Get(ctx)
+}
+
+// Catalog lists every live book with its author details.
+func (s *CatalogService) Catalog(ctx context.Context) ([]model.Book, error) {
+	return s.books.GetCatalog(ctx)
+}
+
+func (s *CatalogService) BooksByID(ctx context.Context, ids []string) ([]model.Book, error) {
+	return s.books.GetByIDs(ctx, ids)
+}
+
+func (s *CatalogService) Book(ctx context.Context, id string) (*model.Book, error) {
+	books, err := s.books.GetByIDs(ctx, []string{id})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(books) == 0 {
+		tracing.Logger(ctx, s.logger).Info("book not found", "id", id)
+		return nil, model.ErrNotFound
+	}
+
+	return &books[0], nil
+}
+
+func (s *CatalogService) CreateBook(ctx context.Context, book *model.Book) error {
+	book.ID = uuid.New().String()
+	return s.addBook(ctx, book)
+}
+
+// ImportBook adds a book under the id it already has, or under a new one when
+// it has none.
+func (s *CatalogService) ImportBook(ctx context.Context, book *model.Book) error {
+	if book.ID == "" {
+		book.ID = uuid.New().String()
+	}
+
+	return s.addBook(ctx, book)
+}
+
+func (s *CatalogService) addBook(ctx context.Context, book *model.Book) error {
+	return s.tx.Run(ctx, func(ctx context.Context) error {
+		err := s.books.Create(ctx, book)
+		if err != nil {
+			return rejected(err)
+		}
+
+		return s.auditor.Record(ctx, model.AuditActionCreate, model.AuditEntityBook, book.ID, nil, bookSnapshot(*book))
+	})
+}
+
+// UpdateBook replaces the book and returns the state it had before.
+func (s *CatalogService) UpdateBook(ctx context.Context, id string, book *model.Book,
+	precondition Precondition) (*model.Book, error) {
+	var before *model.Book
+	err := s.tx.Run(ctx, func(ctx context.Context) error {
+		var err error
+		before, err = s.Book(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if !precondition(before.Version) {
+			return ErrPreconditionFailed
+		}
+
+		book.Version = before.Version
+		err = s.books.Update(ctx, id, book)
+		if err != nil {
+			return rejected(err)
+		}
+
+		return s.auditor.Record(ctx, model.AuditActionUpdate, model.AuditEntityBook, id, bookSnapshot(*before), bookSnapshot(*book))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return before, nil
+}
+
+// DeleteBook soft deletes a book that is not on loan and returns the
+// deleted state.
+func (s *CatalogService) DeleteBook(ctx context.Context, id string, precondition Precondition) (*model.Book, error) {
+	var before *model.Book
+	err := s.tx.Run(ctx, func(ctx context.Context) error {
+		var err error
+		before, err = s.Book(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if !precondition(before.Version) {
+			return ErrPreconditionFailed
+		}
+
+		err = s.books.Delete(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return s.auditor.Record(ctx, model.AuditActionDelete, model.AuditEntityBook, id, bookSnapshot(*before), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return before, nil
+}
+
+// RestoreBook brings back a soft deleted book and returns it.
+func (s *CatalogService) RestoreBook(ctx context.Context, id string) (*model.Book, error) {
+	var after *model.Book
+	err := s.tx.Run(ctx, func(ctx context.Context) error {
+		err := s.books.Restore(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		after, err = s.Book(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return s.auditor.Record(ctx, model.AuditActionRestore, model.AuditEntityBook, id, nil, bookSnapshot(*after))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// bookSnapshot keeps only the columns of the books table so that joined
+// author details do not show up as changes in the audit log.
+func bookSnapshot(book model.Book) model.Book {
+	book.Author = model.Author{}
+	return book
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"library-api/internal/model"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuthorStore struct {
+	mock.Mock
+}
+
+func (m *MockAuthorStore) Create(ctx context.Context, author *model.Author) error {
+	args := m.Called(author)
+	return args.Error(0)
+}
+
+func (m *MockAuthorStore) Get(ctx context.Context) ([]model.Author, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Author), args.Error(1)
+}
+
+func (m *MockAuthorStore) GetByID(ctx context.Context, id string) (*model.Author, error) {
+	args := m.Called(id)
+	author, _ := args.Get(0).(*model.Author)
+	return author, args.Error(1)
+}
+
+func (m *MockAuthorStore) Update(ctx context.Context, id string, author *model.Author) error {
+	args := m.Called(id, author)
+	return args.Error(0)
+}
+
+func (m *MockAuthorStore) Delete(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockAuthorStore) Restore(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockAuthorStore) GetAuthorsBooks(ctx context.Context, id string) ([]string, error) {
+	args := m.Called(id)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+type MockBookStore struct {
+	mock.Mock
+}
+
+func (m *MockBookStore) Create(ctx context.Context, book *model.Book) error {
+	args := m.Called(book)
+	return args.Error(0)
+}
+
+func (m *MockBookStore) Get(ctx context.Context) ([]model.Book, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Book), args.Error(1)
+}
+
+func (m *MockBookStore) Delete(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockBookStore) Restore(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockBookStore) Update(ctx context.Context, id string, book *model.Book) error {
+	args := m.Called(id, book)
+	return args.Error(0)
+}
+
+func (m *MockBookStore) GetByIDs(ctx context.Context, ids []string) ([]model.Book, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]model.Book), args.Error(1)
+}
+
+func (m *MockBookStore) GetCatalog(ctx context.Context) ([]model.Book, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Book), args.Error(1)
+}
+
+const authorID = "4dbec5df-c354-4c0a-8f33-7832dfbc12c0"
+
+func TestCatalogService_UpdateAuthor(t *testing.T) {
+	testCases := []struct {
+		description    string
+		precondition   Precondition
+		getError       error
+		updateError    error
+		expectedUpdate bool
+		expectedError  error
+	}{
+		{
+			description:    "updated with the current version",
+			precondition:   Unconditional,
+			expectedUpdate: true,
+		},
+		{
+			description:   "author not found",
+			precondition:  Unconditional,
+			getError:      model.ErrNotFound,
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description:   "precondition failed",
+			precondition:  func(version int) bool { return version == 2 },
+			expectedError: ErrPreconditionFailed,
+		},
+		{
+			description:    "version conflict is passed through",
+			precondition:   Unconditional,
+			updateError:    model.ErrVersionConflict,
+			expectedUpdate: true,
+			expectedError:  model.ErrVersionConflict,
+		},
+		{
+			description:    "store refused the update",
+			precondition:   Unconditional,
+			updateError:    errors.New("value too long"),
+			expectedUpdate: true,
+			expectedError:  ErrRejected,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			authors := new(MockAuthorStore)
+			tx := new(testTransactor)
+			s := NewCatalogService(authors, nil, tx, new(testAuditor), hclog.NewNullLogger())
+
+			current := &model.Author{ID: authorID, NickName: "Stephen King", Version: 3}
+			if testCase.getError != nil {
+				current = nil
+			}
+			authors.On("GetByID", authorID).Return(current, testCase.getError).Once()
+			if testCase.expectedUpdate {
+				authors.On("Update", authorID, mock.MatchedBy(func(author *model.Author) bool {
+					return author.Version == 3
+				})).Return(testCase.updateError).Once()
+			}
+
+			author := &model.Author{NickName: "Richard Bachman"}
+			before, err := s.UpdateAuthor(context.Background(), authorID, author, testCase.precondition)
+			if testCase.expectedError != nil {
+				assert.ErrorIs(t, err, testCase.expectedError)
+				assert.Nil(t, before)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, current, before)
+			}
+
+			assert.Equal(t, 1, tx.runs)
+			authors.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCatalogService_DeleteBook(t *testing.T) {
+	testCases := []struct {
+		description    string
+		precondition   Precondition
+		books          []model.Book
+		deleteError    error
+		expectedDelete bool
+		expectedError  error
+	}{
+		{
+			description:    "deleted",
+			precondition:   Unconditional,
+			books:          []model.Book{{ID: "235fcd0e-98af-4af5-b985-68dab66085e1", Version: 5}},
+			expectedDelete: true,
+		},
+		{
+			description:   "book not found",
+			precondition:  Unconditional,
+			books:         []model.Book{},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description:   "precondition failed",
+			precondition:  func(version int) bool { return false },
+			books:         []model.Book{{ID: "235fcd0e-98af-4af5-b985-68dab66085e1", Version: 5}},
+			expectedError: ErrPreconditionFailed,
+		},
+		{
+			description:    "book still on loan",
+			precondition:   Unconditional,
+			books:          []model.Book{{ID: "235fcd0e-98af-4af5-b985-68dab66085e1", Version: 5}},
+			deleteError:    model.ErrReferenced,
+			expectedDelete: true,
+			expectedError:  model.ErrReferenced,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			books := new(MockBookStore)
+			auditor := new(testAuditor)
+			s := NewCatalogService(nil, books, new(testTransactor), auditor, hclog.NewNullLogger())
+
+			books.On("GetByIDs", []string{"235fcd0e-98af-4af5-b985-68dab66085e1"}).Return(testCase.books, nil).Once()
+			if testCase.expectedDelete {
+				books.On("Delete", "235fcd0e-98af-4af5-b985-68dab66085e1").Return(testCase.deleteError).Once()
+			}
+
+			before, err := s.DeleteBook(context.Background(), "235fcd0e-98af-4af5-b985-68dab66085e1", testCase.precondition)
+			assert.Equal(t, testCase.expectedError, err)
+			if testCase.expectedError == nil {
+				assert.Equal(t, &testCase.books[0], before)
+				assert.Len(t, auditor.records, 1)
+			} else {
+				assert.Empty(t, auditor.records)
+			}
+
+			books.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCatalogService_CreateBook(t *testing.T) {
+	books := new(MockBookStore)
+	tx := new(testTransactor)
+	auditor := new(testAuditor)
+	s := NewCatalogService(nil, books, tx, auditor, hclog.NewNullLogger())
+
+	books.On("Create", mock.Anything).Return(nil).Twice()
+	books.On("Create", mock.Anything).Return(errors.New("foreign key violation")).Once()
+
+	book := &model.Book{Title: "It"}
+	assert.NoError(t, s.CreateBook(context.Background(), book))
+	assert.Len(t, book.ID, 36)
+	assert.Equal(t, []testRecord{{model.AuditActionCreate, model.AuditEntityBook, book.ID}}, auditor.records)
+
+	auditor.err = errors.New("audit log unavailable")
+	err := s.CreateBook(context.Background(), &model.Book{Title: "It"})
+	assert.EqualError(t, err, "audit log unavailable")
+
+	auditor.err = nil
+	err = s.CreateBook(context.Background(), &model.Book{Title: "It"})
+	assert.ErrorIs(t, err, ErrRejected)
+	assert.Len(t, auditor.records, 1)
+	assert.Equal(t, 3, tx.runs)
+}
+
+func TestCatalogService_RestoreAuthor(t *testing.T) {
+	authors := new(MockAuthorStore)
+	s := NewCatalogService(authors, nil, new(testTransactor), new(testAuditor), hclog.NewNullLogger())
+
+	restored := &model.Author{ID: authorID, NickName: "Stephen King", Version: 4}
+	authors.On("Restore", authorID).Return(nil).Twice()
+	authors.On("GetByID", authorID).Return(restored, nil).Once()
+	authors.On("GetByID", authorID).Return(nil, errors.New("connection reset")).Once()
+
+	after, err := s.RestoreAuthor(context.Background(), authorID)
+	assert.NoError(t, err)
+	assert.Equal(t, restored, after)
+
+	after, err = s.RestoreAuthor(context.Background(), authorID)
+	assert.EqualError(t, err, "connection reset")
+	assert.Nil(t, after)
+
+	authors.On("Restore", "unknown").Return(model.ErrNotFound).Once()
+	_, err = s.RestoreAuthor(context.Background(), "unknown")
+	assert.Equal(t, model.ErrNotFound, err)
+
+	authors.AssertExpectations(t)
+}
+
+func TestCatalogService_ImportAuthor(t *testing.T) {
+	authors := new(MockAuthorStore)
+	auditor := new(testAuditor)
+	s := NewCatalogService(authors, nil, new(testTransactor), auditor, hclog.NewNullLogger())
+
+	authors.On("Create", mock.Anything).Return(nil).Twice()
+
+	author := &model.Author{ID: authorID, NickName: "Stephen King"}
+	assert.NoError(t, s.ImportAuthor(context.Background(), author))
+	assert.Equal(t, authorID, author.ID)
+
+	author = &model.Author{NickName: "Richard Bachman"}
+	assert.NoError(t, s.ImportAuthor(context.Background(), author))
+	assert.Len(t, author.ID, 36)
+
+	assert.Equal(t, []testRecord{
+		{model.AuditActionCreate, model.AuditEntityAuthor, authorID},
+		{model.AuditActionCreate, model.AuditEntityAuthor, author.ID},
+	}, auditor.records)
+	authors.AssertExpectations(t)
+}
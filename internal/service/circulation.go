@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+)
+
+type loanStore interface {
+	Create(ctx context.Context, book *model.Borrowed) error
+	Get(ctx context.Context, id string) ([]model.Book, error)
+	Delete(ctx context.Context, memberId string, bookId string) error
+	DeleteList(ctx context.Context, memberId string, books []string) ([]string, error)
+	MemberStatus(ctx context.Context, memberId string) (string, error)
+	Count(ctx context.Context, memberId string) (int, error)
+}
+
+// Borrow lends a book to a member whose membership is active and who is below
+// the loan limit. The checks and the loan share a transaction so that a
+// suspension landing in between cannot let the loan through.
+func (s *CirculationService) Borrow(ctx context.Context, loan *model.Borrowed) error {
+	policy := s.policy.Load()
+	return s.tx.Run(ctx, func(ctx context.Context) error {
+		status, err := s.store.MemberStatus(ctx, loan.MemberID)
+		if err != nil {
+			if errors.Is(err, model.ErrNotFound) {
+				return ErrMemberNotFound
+			}
+
+			return err
+		}
+
+		switch status {
+		case model.MemberStatusSuspended:
+			tracing.Logger(ctx, s.logger).Info("loan refused for suspended member", "member_id", loan.MemberID)
+			return ErrMemberSuspended
+		case model.MemberStatusExpired:
+			tracing.Logger(ctx, s.logger).Info("loan refused for expired membership", "member_id", loan.MemberID)
+			return ErrMembershipExpired
+		}
+
+		if policy.MaxLoans > 0 {
+			count, err := s.store.Count(ctx, loan.MemberID)
+			if err != nil {
+				return err
+			}
+
+			if count >= policy.MaxLoans {
+				tracing.Logger(ctx, s.logger).Info("loan refused at the loan limit", "member_id", loan.MemberID, "limit", policy.MaxLoans)
+				return ErrLoanLimitReached
+			}
+		}
+
+		err = s.store.Create(ctx, loan)
+		if errors.Is(err, model.ErrNotFound) {
+			return ErrBookNotFound
+		}
+
+		if err != nil {
+			return rejected(err)
+		}
+
+		return s.auditor.Record(ctx, model.AuditActionCreate, model.AuditEntityLoan, loanID(*loan), nil, loan)
+	})
+}
+
+// Loans lists the books a member has borrowed.
+func (s *CirculationService) Loans(ctx context.Context, memberID string) ([]model.Book, error) {
+	return s.store.Get(ctx, memberID)
+}
+
+// Return ends a loan. It returns model.ErrNotFound when the member does not
+// have the book on loan.
+func (s *CirculationService) Return(ctx context.Context, memberID string, bookID string) error {
+	return s.tx.Run(ctx, func(ctx context.Context) error {
+		err := s.store.Delete(ctx, memberID, bookID)
+		if err != nil {
+			return err
+		}
+
+		loan := model.Borrowed{MemberID: memberID, BookID: bookID}
+		return s.auditor.Record(ctx, model.AuditActionDelete, model.AuditEntityLoan, loanID(loan), loan, nil)
+	})
+}
+
+// ReturnAll ends the loans of the given books and audits those that were
+// on loan to the member. It returns model.ErrNotFound when none of them was.
+func (s *CirculationService) ReturnAll(ctx context.Context, memberID string, bookIDs []string) error {
+	return s.tx.Run(ctx, func(ctx context.Context) error {
+		returned, err := s.store.DeleteList(ctx, memberID, bookIDs)
+		if err != nil {
+			return err
+		}
+
+		if len(returned) == 0 {
+			return model.ErrNotFound
+		}
+
+		for _, bookID := range returned {
+			loan := model.Borrowed{MemberID: memberID, BookID: bookID}
+			err = s.auditor.Record(ctx, model.AuditActionDelete, model.AuditEntityLoan, loanID(loan), loan, nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func loanID(loan model.Borrowed) string {
+	return loan.MemberID + "/" + loan.BookID
+}
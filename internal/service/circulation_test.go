@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"library-api/internal/model"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLoanStore struct {
+	mock.Mock
+}
+
+func (m *MockLoanStore) Create(ctx context.Context, loan *model.Borrowed) error {
+	args := m.Called(loan)
+	return args.Error(0)
+}
+
+func (m *MockLoanStore) Get(ctx context.Context, id string) ([]model.Book, error) {
+	args := m.Called(id)
+	return args.Get(0).([]model.Book), args.Error(1)
+}
+
+func (m *MockLoanStore) Delete(ctx context.Context, memberId string, bookId string) error {
+	args := m.Called(memberId, bookId)
+	return args.Error(0)
+}
+
+func (m *MockLoanStore) DeleteList(ctx context.Context, memberId string, books []string) ([]string, error) {
+	args := m.Called(memberId, books)
+	deleted, _ := args.Get(0).([]string)
+	return deleted, args.Error(1)
+}
+
+func (m *MockLoanStore) MemberStatus(ctx context.Context, memberId string) (string, error) {
+	args := m.Called(memberId)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockLoanStore) Count(ctx context.Context, memberId string) (int, error) {
+	args := m.Called(memberId)
+	return args.Int(0), args.Error(1)
+}
+
+func TestCirculationService_Borrow(t *testing.T) {
+	testCases := []struct {
+		description    string
+		status         string
+		statusError    error
+		maxLoans       int
+		loans          int
+		createError    error
+		expectedCreate bool
+		expectedError  error
+	}{
+		{
+			description:    "book lent",
+			status:         model.MemberStatusActive,
+			expectedCreate: true,
+		},
+		{
+			description:   "member not found",
+			statusError:   model.ErrNotFound,
+			expectedError: ErrMemberNotFound,
+		},
+		{
+			description:   "member status lookup failed",
+			statusError:   errors.New("connection reset"),
+			expectedError: errors.New("connection reset"),
+		},
+		{
+			description:   "member suspended",
+			status:        model.MemberStatusSuspended,
+			expectedError: ErrMemberSuspended,
+		},
+		{
+			description:   "membership expired",
+			status:        model.MemberStatusExpired,
+			expectedError: ErrMembershipExpired,
+		},
+		{
+			description:    "book lent below the loan limit",
+			status:         model.MemberStatusActive,
+			maxLoans:       3,
+			loans:          2,
+			expectedCreate: true,
+		},
+		{
+			description:   "loan limit reached",
+			status:        model.MemberStatusActive,
+			maxLoans:      3,
+			loans:         3,
+			expectedError: ErrLoanLimitReached,
+		},
+		{
+			description:    "book not found",
+			status:         model.MemberStatusActive,
+			createError:    model.ErrNotFound,
+			expectedCreate: true,
+			expectedError:  ErrBookNotFound,
+		},
+		{
+			description:    "store refused the loan",
+			status:         model.MemberStatusActive,
+			createError:    errors.New("duplicate key"),
+			expectedCreate: true,
+			expectedError:  ErrRejected,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			store := new(MockLoanStore)
+			tx := new(testTransactor)
+			s := NewCirculationService(store, tx, new(testAuditor), LoanPolicy{MaxLoans: testCase.maxLoans}, hclog.NewNullLogger())
+
+			loan := &model.Borrowed{MemberID: memberID, BookID: "5dee5c81-5ee4-44a9-97e5-0eb7955792a4"}
+			store.On("MemberStatus", memberID).Return(testCase.status, testCase.statusError).Once()
+			if testCase.maxLoans > 0 {
+				store.On("Count", memberID).Return(testCase.loans, nil).Once()
+			}
+			if testCase.expectedCreate {
+				store.On("Create", loan).Return(testCase.createError).Once()
+			}
+
+			err := s.Borrow(context.Background(), loan)
+			switch {
+			case testCase.expectedError == nil:
+				assert.NoError(t, err)
+			case errors.Is(testCase.expectedError, ErrRejected):
+				assert.ErrorIs(t, err, ErrRejected)
+			default:
+				assert.Equal(t, testCase.expectedError, err)
+			}
+
+			assert.Equal(t, 1, tx.runs)
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCirculationService_ReturnAll(t *testing.T) {
+	books := []string{"5dee5c81-5ee4-44a9-97e5-0eb7955792a4", "81790db6-a440-48e2-9951-d5fcf359fd7c"}
+
+	testCases := []struct {
+		description     string
+		deleted         []string
+		deleteError     error
+		expectedRecords []testRecord
+		expectedError   error
+	}{
+		{
+			description: "only returned loans are audited",
+			deleted:     []string{"81790db6-a440-48e2-9951-d5fcf359fd7c"},
+			expectedRecords: []testRecord{
+				{model.AuditActionDelete, model.AuditEntityLoan, memberID + "/81790db6-a440-48e2-9951-d5fcf359fd7c"},
+			},
+		},
+		{
+			description:   "none of the books on loan",
+			deleted:       []string{},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description:   "store error",
+			deleteError:   errors.New("connection reset"),
+			expectedError: errors.New("connection reset"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			store := new(MockLoanStore)
+			tx := new(testTransactor)
+			auditor := new(testAuditor)
+			s := NewCirculationService(store, tx, auditor, LoanPolicy{}, hclog.NewNullLogger())
+
+			store.On("DeleteList", memberID, books).Return(testCase.deleted, testCase.deleteError).Once()
+
+			err := s.ReturnAll(context.Background(), memberID, books)
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedRecords, auditor.records)
+			assert.Equal(t, 1, tx.runs)
+			store.AssertExpectations(t)
+		})
+	}
+}
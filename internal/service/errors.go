@@ -0,0 +1,62 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"library-api/internal/model"
+
+	"github.com/lib/pq"
+)
+
+const codeUniqueViolation pq.ErrorCode = "23505"
+
+var (
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrRejected           = errors.New("rejected by the database")
+	ErrMemberNotFound     = errors.New("member not found")
+	ErrBookNotFound       = errors.New("book not found")
+	ErrMemberSuspended    = errors.New("member is suspended")
+	ErrMembershipExpired  = errors.New("membership has expired")
+	ErrLoanLimitReached   = errors.New("member has reached the loan limit")
+	ErrEmailInUse         = errors.New("email is already used by another member")
+)
+
+// ValidationError is returned for input that breaks a domain rule. The
+// message is meant for the client.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func invalid(message string) error {
+	return &ValidationError{Message: message}
+}
+
+// Precondition decides whether a change may be applied to the current
+// version of a record.
+type Precondition func(version int) bool
+
+// Unconditional applies a change to whatever version is current.
+func Unconditional(int) bool {
+	return true
+}
+
+// uniqueViolation reports whether err is a unique constraint violation.
+func uniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == codeUniqueViolation
+}
+
+// rejected marks a failed write so that callers can tell it from a failed
+// lookup. Errors the stores already classify are passed through.
+func rejected(err error) error {
+	if err == nil || errors.Is(err, model.ErrNotFound) || errors.Is(err, model.ErrVersionConflict) ||
+		errors.Is(err, model.ErrReferenced) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", ErrRejected, err)
+}
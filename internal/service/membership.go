@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"library-api/internal/model"
+	"library-api/pkg/luhn"
+	"library-api/pkg/tracing"
+	"math/big"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memberStore interface {
+	Create(ctx context.Context, member *model.Member) error
+	Get(ctx context.Context, filter model.MemberFilter) ([]model.Member, error)
+	GetByID(ctx context.Context, id string) (*model.Member, error)
+	Update(ctx context.Context, id string, member *model.Member) error
+	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) error
+	Suspend(ctx context.Context, id string, suspension *model.Suspension) error
+	Reinstate(ctx context.Context, id string, expiresAt model.Date) error
+	ExpireMemberships(ctx context.Context) ([]model.MemberChange, error)
+	LiftSuspensions(ctx context.Context) ([]model.MemberChange, error)
+}
+
+func (s *MembershipService) Members(ctx context.Context, filter model.MemberFilter) ([]model.Member, error) {
+	if filter.CardNumber != "" && !luhn.Valid(filter.CardNumber) {
+		return nil, invalid("invalid card number")
+	}
+
+	return s.store.Get(ctx, filter)
+}
+
+func (s *MembershipService) Member(ctx context.Context, id string) (*model.Member, error) {
+	return s.store.GetByID(ctx, id)
+}
+
+// Register enrols a new member with a fresh card number. The membership runs
+// for a year unless the request sets its own expiry date. An email that
+// another member already uses gives ErrEmailInUse.
+func (s *MembershipService) Register(ctx context.Context, member *model.Member) error {
+	err := validateMember(member)
+	if err != nil {
+		return err
+	}
+
+	member.ID = uuid.New().String()
+	member.Status = model.MemberStatusActive
+	member.RegisteredAt = model.Today()
+	if member.ExpiresAt.IsZero() {
+		member.ExpiresAt = member.RegisteredAt.AddDate(1, 0, 0)
+	}
+
+	err = s.tx.Run(ctx, func(ctx context.Context) error {
+		err := s.create(ctx, member)
+		if err != nil {
+			return err
+		}
+
+		return s.auditor.Record(ctx, model.AuditActionCreate, model.AuditEntityMember, member.ID, nil, member)
+	})
+	if uniqueViolation(err) {
+		return ErrEmailInUse
+	}
+
+	return err
+}
+
+// create inserts the member under a fresh card number, drawing another one
+// while the number drawn is already issued.
+func (s *MembershipService) create(ctx context.Context, member *model.Member) error {
+	var err error
+	for attempt := 1; attempt <= maxCardNumberAttempts; attempt++ {
+		member.CardNumber, err = newCardNumber()
+		if err != nil {
+			tracing.Logger(ctx, s.logger).Error("card number generation failed", "error", err.Error())
+			return err
+		}
+
+		err = s.store.Create(ctx, member)
+		if !errors.Is(err, model.ErrCardNumberTaken) {
+			return rejected(err)
+		}
+	}
+
+	tracing.Logger(ctx, s.logger).Error("no free card number found", "attempts", maxCardNumberAttempts)
+	return err
+}
+
+// UpdateMember replaces the member details and returns the state they had
+// before. An email that another member already uses gives ErrEmailInUse.
+func (s *MembershipService) UpdateMember(ctx context.Context, id string, member *model.Member,
+	precondition Precondition) (*model.Member, error) {
+	err := validateMember(member)
+	if err != nil {
+		return nil, err
+	}
+
+	var before *model.Member
+	err = s.tx.Run(ctx, func(ctx context.Context) error {
+		var err error
+		before, err = s.store.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if !precondition(before.Version) {
+			return ErrPreconditionFailed
+		}
+
+		member.Version = before.Version
+		err = s.store.Update(ctx, id, member)
+		if err != nil {
+			return rejected(err)
+		}
+
+		return s.auditor.Record(ctx, model.AuditActionUpdate, model.AuditEntityMember, id, before, member)
+	})
+	if err != nil {
+		if uniqueViolation(err) {
+			return nil, ErrEmailInUse
+		}
+
+		return nil, err
+	}
+
+	return before, nil
+}
+
+// DeleteMember soft deletes a member without loans and returns the deleted
+// state.
+func (s *MembershipService) DeleteMember(ctx context.Context, id string, precondition Precondition) (*model.Member, error) {
+	var before *model.Member
+	err := s.tx.Run(ctx, func(ctx context.Context) error {
+		var err error
+		before, err = s.store.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if !precondition(before.Version) {
+			return ErrPreconditionFailed
+		}
+
+		err = s.store.Delete(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return s.auditor.Record(ctx, model.AuditActionDelete, model.AuditEntityMember, id, before, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return before, nil
+}
+
+// RestoreMember brings back a soft deleted member unless their email has
+// been taken in the meantime, and returns the restored member.
+func (s *MembershipService) RestoreMember(ctx context.Context, id string) (*model.Member, error) {
+	var after *model.Member
+	err := s.tx.Run(ctx, func(ctx context.Context) error {
+		err := s.store.Restore(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		after, err = s.store.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return s.auditor.Record(ctx, model.AuditActionRestore, model.AuditEntityMember, id, nil, after)
+	})
+	if err != nil {
+		if uniqueViolation(err) {
+			return nil, ErrEmailInUse
+		}
+
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// Suspend blocks a member from borrowing, until the given date or
+// indefinitely. It returns the member before and after the change.
+func (s *MembershipService) Suspend(ctx context.Context, id string,
+	suspension *model.Suspension) (*model.Member, *model.Member, error) {
+	suspension.Reason = strings.TrimSpace(suspension.Reason)
+	if suspension.Reason == "" {
+		return nil, nil, invalid("reason is required")
+	}
+
+	if !suspension.Until.IsZero() && suspension.Until.Before(model.Today().Time) {
+		return nil, nil, invalid("until must not be in the past")
+	}
+
+	var before, after *model.Member
+	err := s.tx.Run(ctx, func(ctx context.Context) error {
+		var err error
+		before, err = s.store.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		err = s.store.Suspend(ctx, id, suspension)
+		if err != nil {
+			return err
+		}
+
+		after, err = s.store.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return s.auditor.Record(ctx, model.AuditActionUpdate, model.AuditEntityMember, id, before, after)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return before, after, nil
+}
+
+// Reinstate lifts a suspension and optionally moves the expiry date. It
+// returns the member before and after the change.
+func (s *MembershipService) Reinstate(ctx context.Context, id string,
+	expiresAt model.Date) (*model.Member, *model.Member, error) {
+	var before, after *model.Member
+	err := s.tx.Run(ctx, func(ctx context.Context) error {
+		var err error
+		before, err = s.store.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		err = s.store.Reinstate(ctx, id, expiresAt)
+		if err != nil {
+			return err
+		}
+
+		after, err = s.store.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return s.auditor.Record(ctx, model.AuditActionUpdate, model.AuditEntityMember, id, before, after)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return before, after, nil
+}
+
+// SweepStatuses expires the memberships that have run out and lifts the
+// suspensions that have ended, auditing every member it changes. It returns
+// how many members were expired and how many suspensions were lifted.
+func (s *MembershipService) SweepStatuses(ctx context.Context) (int, int, error) {
+	var expired, lifted []model.MemberChange
+	err := s.tx.Run(ctx, func(ctx context.Context) error {
+		var err error
+		expired, err = s.store.ExpireMemberships(ctx)
+		if err != nil {
+			return err
+		}
+
+		lifted, err = s.store.LiftSuspensions(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range append(expired, lifted...) {
+			err = s.auditor.Record(ctx, model.AuditActionUpdate, model.AuditEntityMember, change.After.ID, change.Before, change.After)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return len(expired), len(lifted), nil
+}
+
+func validateMember(member *model.Member) error {
+	member.Email = strings.TrimSpace(member.Email)
+	if member.Email != "" {
+		address, err := mail.ParseAddress(member.Email)
+		if err != nil || address.Address != member.Email {
+			return invalid("invalid email")
+		}
+	}
+
+	if !member.DateOfBirth.IsZero() && member.DateOfBirth.After(time.Now()) {
+		return invalid("date_of_birth must be in the past")
+	}
+
+	return nil
+}
+
+// maxCardNumberAttempts bounds how often Register draws a new card number
+// after a collision.
+const maxCardNumberAttempts = 5
+
+// newCardNumber issues a 14 digit library card number: the 29 prefix used
+// for member cards, eleven random digits and a Luhn check digit.
+func newCardNumber() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(100_000_000_000))
+	if err != nil {
+		return "", err
+	}
+
+	payload := fmt.Sprintf("29%011d", n)
+
+	checkDigit, err := luhn.CheckDigit(payload)
+	if err != nil {
+		return "", err
+	}
+
+	return payload + string(checkDigit), nil
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"library-api/internal/model"
+	"library-api/pkg/luhn"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMemberStore struct {
+	mock.Mock
+}
+
+func (m *MockMemberStore) Create(ctx context.Context, member *model.Member) error {
+	args := m.Called(member)
+	return args.Error(0)
+}
+
+func (m *MockMemberStore) Get(ctx context.Context, filter model.MemberFilter) ([]model.Member, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.Member), args.Error(1)
+}
+
+func (m *MockMemberStore) GetByID(ctx context.Context, id string) (*model.Member, error) {
+	args := m.Called(id)
+	member, _ := args.Get(0).(*model.Member)
+	return member, args.Error(1)
+}
+
+func (m *MockMemberStore) Update(ctx context.Context, id string, member *model.Member) error {
+	args := m.Called(id, member)
+	return args.Error(0)
+}
+
+func (m *MockMemberStore) Delete(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockMemberStore) Restore(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockMemberStore) Suspend(ctx context.Context, id string, suspension *model.Suspension) error {
+	args := m.Called(id, suspension)
+	return args.Error(0)
+}
+
+func (m *MockMemberStore) Reinstate(ctx context.Context, id string, expiresAt model.Date) error {
+	args := m.Called(id, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockMemberStore) ExpireMemberships(ctx context.Context) ([]model.MemberChange, error) {
+	args := m.Called()
+	changes, _ := args.Get(0).([]model.MemberChange)
+	return changes, args.Error(1)
+}
+
+func (m *MockMemberStore) LiftSuspensions(ctx context.Context) ([]model.MemberChange, error) {
+	args := m.Called()
+	changes, _ := args.Get(0).([]model.MemberChange)
+	return changes, args.Error(1)
+}
+
+const memberID = "1de94d3e-09b2-4f62-bfff-964012c649d3"
+
+func TestMembershipService_Register(t *testing.T) {
+	expiresAt := model.Today().AddDate(0, 6, 0)
+	testCases := []struct {
+		description       string
+		member            model.Member
+		collisions        int
+		createError       error
+		expectedExpiresAt model.Date
+		expectedError     error
+	}{
+		{
+			description:       "membership runs for a year",
+			member:            model.Member{Email: " jane@example.com "},
+			expectedExpiresAt: model.Today().AddDate(1, 0, 0),
+		},
+		{
+			description:       "requested expiry date is kept",
+			member:            model.Member{ExpiresAt: expiresAt},
+			expectedExpiresAt: expiresAt,
+		},
+		{
+			description:   "invalid email",
+			member:        model.Member{Email: "jane at example"},
+			expectedError: &ValidationError{Message: "invalid email"},
+		},
+		{
+			description:   "date of birth in the future",
+			member:        model.Member{DateOfBirth: model.Today().AddDate(0, 0, 1)},
+			expectedError: &ValidationError{Message: "date_of_birth must be in the past"},
+		},
+		{
+			description:       "store refused the member",
+			member:            model.Member{Email: "jane@example.com"},
+			createError:       errors.New("duplicate key value violates unique constraint"),
+			expectedExpiresAt: model.Today().AddDate(1, 0, 0),
+			expectedError:     ErrRejected,
+		},
+		{
+			description:       "email taken by another member",
+			member:            model.Member{Email: "jane@example.com"},
+			createError:       &pq.Error{Code: "23505", Constraint: "members_email_key"},
+			expectedExpiresAt: model.Today().AddDate(1, 0, 0),
+			expectedError:     ErrEmailInUse,
+		},
+		{
+			description:       "card number collision draws another",
+			collisions:        2,
+			expectedExpiresAt: model.Today().AddDate(1, 0, 0),
+		},
+		{
+			description:       "no free card number",
+			collisions:        maxCardNumberAttempts,
+			expectedExpiresAt: model.Today().AddDate(1, 0, 0),
+			expectedError:     model.ErrCardNumberTaken,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			store := new(MockMemberStore)
+			s := NewMembershipService(store, new(testTransactor), new(testAuditor), hclog.NewNullLogger())
+
+			if testCase.collisions > 0 {
+				store.On("Create", mock.Anything).Return(model.ErrCardNumberTaken).Times(testCase.collisions)
+			}
+			store.On("Create", mock.Anything).Return(testCase.createError).Maybe()
+
+			member := testCase.member
+			err := s.Register(context.Background(), &member)
+
+			var validation *ValidationError
+			if errors.As(testCase.expectedError, &validation) {
+				assert.Equal(t, testCase.expectedError, err)
+				store.AssertNotCalled(t, "Create", mock.Anything)
+				return
+			}
+
+			assert.ErrorIs(t, err, testCase.expectedError)
+			assert.Len(t, member.ID, 36)
+			assert.Regexp(t, `^29\d{12}$`, member.CardNumber)
+			assert.True(t, luhn.Valid(member.CardNumber))
+			assert.Equal(t, model.MemberStatusActive, member.Status)
+			assert.Equal(t, model.Today(), member.RegisteredAt)
+			assert.Equal(t, testCase.expectedExpiresAt, member.ExpiresAt)
+			assert.NotContains(t, member.Email, " ")
+			store.AssertNumberOfCalls(t, "Create", min(testCase.collisions+1, maxCardNumberAttempts))
+		})
+	}
+}
+
+func TestMembershipService_Members(t *testing.T) {
+	store := new(MockMemberStore)
+	s := NewMembershipService(store, new(testTransactor), new(testAuditor), hclog.NewNullLogger())
+
+	_, err := s.Members(context.Background(), model.MemberFilter{CardNumber: "29000000000001"})
+	assert.Equal(t, &ValidationError{Message: "invalid card number"}, err)
+
+	filter := model.MemberFilter{CardNumber: "29000000000007"}
+	store.On("Get", filter).Return([]model.Member{{ID: memberID}}, nil).Once()
+
+	members, err := s.Members(context.Background(), filter)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Member{{ID: memberID}}, members)
+
+	store.AssertExpectations(t)
+}
+
+func TestMembershipService_RestoreMember(t *testing.T) {
+	testCases := []struct {
+		description   string
+		restoreError  error
+		expectedAfter *model.Member
+		expectedError error
+	}{
+		{
+			description:   "restored",
+			expectedAfter: &model.Member{ID: memberID, Version: 2},
+		},
+		{
+			description:   "email taken in the meantime",
+			restoreError:  &pq.Error{Code: "23505", Constraint: "members_email_key"},
+			expectedError: ErrEmailInUse,
+		},
+		{
+			description:   "other error mentioning a duplicate key",
+			restoreError:  errors.New("duplicate key"),
+			expectedError: errors.New("duplicate key"),
+		},
+		{
+			description:   "not deleted",
+			restoreError:  model.ErrNotFound,
+			expectedError: model.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			store := new(MockMemberStore)
+			s := NewMembershipService(store, new(testTransactor), new(testAuditor), hclog.NewNullLogger())
+
+			store.On("Restore", memberID).Return(testCase.restoreError).Once()
+			if testCase.expectedAfter != nil {
+				store.On("GetByID", memberID).Return(testCase.expectedAfter, nil).Once()
+			}
+
+			after, err := s.RestoreMember(context.Background(), memberID)
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedAfter, after)
+
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMembershipService_Suspend(t *testing.T) {
+	testCases := []struct {
+		description    string
+		suspension     model.Suspension
+		expectedStored bool
+		expectedError  error
+	}{
+		{
+			description:    "suspended",
+			suspension:     model.Suspension{Reason: "  overdue books "},
+			expectedStored: true,
+		},
+		{
+			description:   "reason is required",
+			suspension:    model.Suspension{Reason: "   "},
+			expectedError: &ValidationError{Message: "reason is required"},
+		},
+		{
+			description:   "until in the past",
+			suspension:    model.Suspension{Reason: "overdue books", Until: model.Today().AddDate(0, 0, -1)},
+			expectedError: &ValidationError{Message: "until must not be in the past"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			store := new(MockMemberStore)
+			tx := new(testTransactor)
+			s := NewMembershipService(store, tx, new(testAuditor), hclog.NewNullLogger())
+
+			active := &model.Member{ID: memberID, Status: model.MemberStatusActive, Version: 1}
+			suspended := &model.Member{ID: memberID, Status: model.MemberStatusSuspended, Version: 2}
+			if testCase.expectedStored {
+				store.On("GetByID", memberID).Return(active, nil).Once()
+				store.On("Suspend", memberID, mock.MatchedBy(func(suspension *model.Suspension) bool {
+					return suspension.Reason == "overdue books"
+				})).Return(nil).Once()
+				store.On("GetByID", memberID).Return(suspended, nil).Once()
+			}
+
+			suspension := testCase.suspension
+			before, after, err := s.Suspend(context.Background(), memberID, &suspension)
+			assert.Equal(t, testCase.expectedError, err)
+			if testCase.expectedStored {
+				assert.Equal(t, active, before)
+				assert.Equal(t, suspended, after)
+				assert.Equal(t, 1, tx.runs)
+			}
+
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMembershipService_Reinstate(t *testing.T) {
+	testCases := []struct {
+		description    string
+		reinstateError error
+		expectedAfter  *model.Member
+		expectedError  error
+	}{
+		{
+			description:   "reinstated",
+			expectedAfter: &model.Member{ID: memberID, Status: model.MemberStatusActive, Version: 3},
+		},
+		{
+			description:    "not suspended",
+			reinstateError: model.ErrNotFound,
+			expectedError:  model.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			store := new(MockMemberStore)
+			tx := new(testTransactor)
+			s := NewMembershipService(store, tx, new(testAuditor), hclog.NewNullLogger())
+
+			suspended := &model.Member{ID: memberID, Status: model.MemberStatusSuspended, Version: 2}
+			expiresAt := model.Today().AddDate(1, 0, 0)
+			store.On("GetByID", memberID).Return(suspended, nil).Once()
+			store.On("Reinstate", memberID, expiresAt).Return(testCase.reinstateError).Once()
+			if testCase.expectedAfter != nil {
+				store.On("GetByID", memberID).Return(testCase.expectedAfter, nil).Once()
+			}
+
+			before, after, err := s.Reinstate(context.Background(), memberID, expiresAt)
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, 1, tx.runs)
+			if testCase.expectedError == nil {
+				assert.Equal(t, suspended, before)
+				assert.Equal(t, testCase.expectedAfter, after)
+			}
+
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMembershipService_SweepStatuses(t *testing.T) {
+	expiredMember := model.Member{ID: memberID, Status: model.MemberStatusExpired}
+	liftedMember := model.Member{ID: "5d574a92-4b78-46eb-8ab0-02709b710b15", Status: model.MemberStatusActive}
+	expired := []model.MemberChange{{Before: model.Member{ID: memberID, Status: model.MemberStatusActive}, After: expiredMember}}
+	lifted := []model.MemberChange{{
+		Before: model.Member{ID: liftedMember.ID, Status: model.MemberStatusSuspended, SuspensionReason: "overdue books"},
+		After:  liftedMember,
+	}}
+
+	testCases := []struct {
+		description     string
+		liftError       error
+		auditError      error
+		expectedExpired int
+		expectedLifted  int
+		expectedRecords []testRecord
+		expectedError   error
+	}{
+		{
+			description:     "every changed member is audited",
+			expectedExpired: 1,
+			expectedLifted:  1,
+			expectedRecords: []testRecord{
+				{model.AuditActionUpdate, model.AuditEntityMember, memberID},
+				{model.AuditActionUpdate, model.AuditEntityMember, liftedMember.ID},
+			},
+		},
+		{
+			description:   "store error",
+			liftError:     errors.New("connection reset"),
+			expectedError: errors.New("connection reset"),
+		},
+		{
+			description:   "audit error fails the sweep",
+			auditError:    errors.New("audit log unavailable"),
+			expectedError: errors.New("audit log unavailable"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			store := new(MockMemberStore)
+			tx := new(testTransactor)
+			auditor := &testAuditor{err: testCase.auditError}
+			s := NewMembershipService(store, tx, auditor, hclog.NewNullLogger())
+
+			store.On("ExpireMemberships").Return(expired, nil).Once()
+			if testCase.liftError != nil {
+				store.On("LiftSuspensions").Return(nil, testCase.liftError).Once()
+			} else {
+				store.On("LiftSuspensions").Return(lifted, nil).Once()
+			}
+
+			actualExpired, actualLifted, err := s.SweepStatuses(context.Background())
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedExpired, actualExpired)
+			assert.Equal(t, testCase.expectedLifted, actualLifted)
+			assert.Equal(t, testCase.expectedRecords, auditor.records)
+			assert.Equal(t, 1, tx.runs)
+			store.AssertExpectations(t)
+		})
+	}
+}
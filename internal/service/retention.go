@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+	"time"
+)
+
+type purgeStore interface {
+	Purge(ctx context.Context, before time.Time) (*model.PurgeResult, error)
+}
+
+// Purge permanently removes records that were soft deleted before the given
+// time and audits each of them in the same transaction.
+func (s *RetentionService) Purge(ctx context.Context, before time.Time) (*model.PurgeResult, error) {
+	var result *model.PurgeResult
+	err := s.tx.Run(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = s.store.Purge(ctx, before)
+		if err != nil {
+			return err
+		}
+
+		purged := []struct {
+			entityType string
+			ids        []string
+		}{
+			{model.AuditEntityBook, result.Books},
+			{model.AuditEntityAuthor, result.Authors},
+			{model.AuditEntityMember, result.Members},
+		}
+		for _, p := range purged {
+			for _, id := range p.ids {
+				err = s.auditor.Record(ctx, model.AuditActionPurge, p.entityType, id, nil, nil)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tracing.Logger(ctx, s.logger).Info("purged deleted records",
+		"books", len(result.Books),
+		"authors", len(result.Authors),
+		"members", len(result.Members))
+
+	return result, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"library-api/internal/model"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPurgeStore struct {
+	mock.Mock
+}
+
+func (m *MockPurgeStore) Purge(ctx context.Context, before time.Time) (*model.PurgeResult, error) {
+	args := m.Called(before)
+	result, _ := args.Get(0).(*model.PurgeResult)
+	return result, args.Error(1)
+}
+
+func TestRetentionService_Purge(t *testing.T) {
+	before := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	purged := &model.PurgeResult{
+		Authors: []string{"4dbec5df-c354-4c0a-8f33-7832dfbc12c0"},
+		Books:   []string{"235fcd0e-98af-4af5-b985-68dab66085e1"},
+		Members: []string{},
+	}
+
+	testCases := []struct {
+		description     string
+		storeError      error
+		auditError      error
+		expectedRecords []testRecord
+		expectedError   error
+	}{
+		{
+			description: "purged records are audited",
+			expectedRecords: []testRecord{
+				{model.AuditActionPurge, model.AuditEntityBook, "235fcd0e-98af-4af5-b985-68dab66085e1"},
+				{model.AuditActionPurge, model.AuditEntityAuthor, "4dbec5df-c354-4c0a-8f33-7832dfbc12c0"},
+			},
+		},
+		{
+			description:   "store error",
+			storeError:    errors.New("connection reset"),
+			expectedError: errors.New("connection reset"),
+		},
+		{
+			description:   "audit error fails the purge",
+			auditError:    errors.New("audit log unavailable"),
+			expectedError: errors.New("audit log unavailable"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			store := new(MockPurgeStore)
+			tx := new(testTransactor)
+			auditor := &testAuditor{err: testCase.auditError}
+			s := NewRetentionService(store, tx, auditor, hclog.NewNullLogger())
+
+			var result *model.PurgeResult
+			if testCase.storeError == nil {
+				result = purged
+			}
+			store.On("Purge", before).Return(result, testCase.storeError).Once()
+
+			actual, err := s.Purge(context.Background(), before)
+			if testCase.expectedError != nil {
+				assert.EqualError(t, err, testCase.expectedError.Error())
+				assert.Nil(t, actual)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, purged, actual)
+			}
+
+			assert.Equal(t, testCase.expectedRecords, auditor.records)
+			assert.Equal(t, 1, tx.runs)
+			store.AssertExpectations(t)
+		})
+	}
+}
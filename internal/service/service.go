@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+type transactor interface {
+	Run(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// auditor writes an audit entry for a change. The services call it inside
+// the transaction of the change, so that entry and change commit together.
+type auditor interface {
+	Record(ctx context.Context, action string, entityType string, entityID string, before any, after any) error
+}
+
+type CatalogService struct {
+	authors authorStore
+	books   bookStore
+	tx      transactor
+	auditor auditor
+	logger  hclog.Logger
+}
+
+func NewCatalogService(authors authorStore, books bookStore, tx transactor, auditor auditor, logger hclog.Logger) *CatalogService {
+	return &CatalogService{
+		authors: authors,
+		books:   books,
+		tx:      tx,
+		auditor: auditor,
+		logger:  logger,
+	}
+}
+
+type MembershipService struct {
+	store   memberStore
+	tx      transactor
+	auditor auditor
+	logger  hclog.Logger
+}
+
+func NewMembershipService(store memberStore, tx transactor, auditor auditor, logger hclog.Logger) *MembershipService {
+	return &MembershipService{
+		store:   store,
+		tx:      tx,
+		auditor: auditor,
+		logger:  logger,
+	}
+}
+
+// LoanPolicy holds the lending rules that can change while the service runs.
+type LoanPolicy struct {
+	// MaxLoans is how many books a member may have out at once; 0 means no
+	// limit.
+	MaxLoans int
+}
+
+type CirculationService struct {
+	store   loanStore
+	tx      transactor
+	auditor auditor
+	policy  atomic.Pointer[LoanPolicy]
+	logger  hclog.Logger
+}
+
+func NewCirculationService(store loanStore, tx transactor, auditor auditor, policy LoanPolicy, logger hclog.Logger) *CirculationService {
+	s := &CirculationService{
+		store:   store,
+		tx:      tx,
+		auditor: auditor,
+		logger:  logger,
+	}
+	s.SetPolicy(policy)
+
+	return s
+}
+
+// SetPolicy replaces the lending rules. Loans already being made finish under
+// the rules they started with.
+func (s *CirculationService) SetPolicy(policy LoanPolicy) {
+	s.policy.Store(&policy)
+}
+
+type RetentionService struct {
+	store   purgeStore
+	tx      transactor
+	auditor auditor
+	logger  hclog.Logger
+}
+
+func NewRetentionService(store purgeStore, tx transactor, auditor auditor, logger hclog.Logger) *RetentionService {
+	return &RetentionService{
+		store:   store,
+		tx:      tx,
+		auditor: auditor,
+		logger:  logger,
+	}
+}
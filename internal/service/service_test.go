@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+// testTransactor runs fn directly and counts how often it was asked to.
+type testTransactor struct {
+	runs int
+}
+
+func (t *testTransactor) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.runs++
+	return fn(ctx)
+}
+
+type testRecord struct {
+	action     string
+	entityType string
+	entityID   string
+}
+
+// testAuditor keeps the entries it is asked to write, or fails with err.
+type testAuditor struct {
+	records []testRecord
+	err     error
+}
+
+func (a *testAuditor) Record(ctx context.Context, action string, entityType string, entityID string, before any, after any) error {
+	if a.err != nil {
+		return a.err
+	}
+
+	a.records = append(a.records, testRecord{action: action, entityType: entityType, entityID: entityID})
+	return nil
+}
+
+func TestNewCatalogService(t *testing.T) {
+	authors := new(MockAuthorStore)
+	books := new(MockBookStore)
+	tx := new(testTransactor)
+	auditor := new(testAuditor)
+	actual := NewCatalogService(authors, books, tx, auditor, hclog.NewNullLogger())
+
+	expected := &CatalogService{
+		authors: authors,
+		books:   books,
+		tx:      tx,
+		auditor: auditor,
+		logger:  hclog.NewNullLogger(),
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestNewMembershipService(t *testing.T) {
+	store := new(MockMemberStore)
+	tx := new(testTransactor)
+	auditor := new(testAuditor)
+	actual := NewMembershipService(store, tx, auditor, hclog.NewNullLogger())
+
+	expected := &MembershipService{
+		store:   store,
+		tx:      tx,
+		auditor: auditor,
+		logger:  hclog.NewNullLogger(),
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestNewCirculationService(t *testing.T) {
+	store := new(MockLoanStore)
+	tx := new(testTransactor)
+	auditor := new(testAuditor)
+	actual := NewCirculationService(store, tx, auditor, LoanPolicy{MaxLoans: 3}, hclog.NewNullLogger())
+
+	assert.Equal(t, store, actual.store)
+	assert.Equal(t, tx, actual.tx)
+	assert.Equal(t, auditor, actual.auditor)
+	assert.Equal(t, LoanPolicy{MaxLoans: 3}, *actual.policy.Load())
+	assert.Equal(t, hclog.NewNullLogger(), actual.logger)
+}
+
+func TestNewRetentionService(t *testing.T) {
+	store := new(MockPurgeStore)
+	tx := new(testTransactor)
+	auditor := new(testAuditor)
+	actual := NewRetentionService(store, tx, auditor, hclog.NewNullLogger())
+
+	expected := &RetentionService{
+		store:   store,
+		tx:      tx,
+		auditor: auditor,
+		logger:  hclog.NewNullLogger(),
+	}
+
+	assert.Equal(t, expected, actual)
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+	"time"
+)
+
+// SetCredentials creates the account of a member, or replaces the login,
+// password and role of an existing account in the same statement. An existing
+// account is only changed while its role still is currentRole: otherwise
+// nothing is written and ErrVersionConflict returned.
+func (a *AccountStore) SetCredentials(ctx context.Context, credentials *model.Credentials, currentRole string) error {
+	err := conn(ctx, a.db).QueryRowContext(ctx, `INSERT INTO member_credentials (member_id, login, password_hash, role)
+								VALUES ($1, $2, $3, $4)
+								ON CONFLICT (member_id) DO UPDATE
+								SET login = EXCLUDED.login, password_hash = EXCLUDED.password_hash, role = EXCLUDED.role, updated_at = now()
+								WHERE member_credentials.role = $5
+								RETURNING updated_at`,
+		credentials.MemberID, credentials.Login, credentials.PasswordHash, credentials.Role, currentRole).Scan(&credentials.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			tracing.Logger(ctx, a.logger).Info("account role changed while setting credentials", "member_id", credentials.MemberID)
+			return model.ErrVersionConflict
+		}
+
+		tracing.Logger(ctx, a.logger).Error("failed to set credentials", "member_id", credentials.MemberID, "error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (a *AccountStore) GetCredentialsByMember(ctx context.Context, memberID string) (*model.Credentials, error) {
+	var credentials model.Credentials
+	err := conn(ctx, a.db).QueryRowContext(ctx, `SELECT member_id, login, password_hash, role, updated_at
+								FROM member_credentials WHERE member_id = $1`, memberID).
+		Scan(&credentials.MemberID, &credentials.Login, &credentials.PasswordHash, &credentials.Role, &credentials.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+
+		tracing.Logger(ctx, a.logger).Error("credentials lookup failed", "member_id", memberID, "error", err.Error())
+		return nil, err
+	}
+
+	return &credentials, nil
+}
+
+func (a *AccountStore) GetCredentials(ctx context.Context, login string) (*model.Credentials, error) {
+	var credentials model.Credentials
+	err := conn(ctx, a.db).QueryRowContext(ctx, `SELECT member_id, login, password_hash, role, member_credentials.updated_at
+								FROM member_credentials JOIN members ON members.id = member_credentials.member_id
+								WHERE login = $1 AND members.deleted_at IS NULL`, login).
+		Scan(&credentials.MemberID, &credentials.Login, &credentials.PasswordHash, &credentials.Role, &credentials.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+
+		tracing.Logger(ctx, a.logger).Error("credentials lookup failed", "error", err.Error())
+		return nil, err
+	}
+
+	return &credentials, nil
+}
+
+func (a *AccountStore) CreateRefreshToken(ctx context.Context, id string, memberID string, hash string, expiresAt time.Time) error {
+	_, err := conn(ctx, a.db).ExecContext(ctx, `INSERT INTO refresh_tokens (id, member_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)`,
+		id, memberID, hash, expiresAt)
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("failed to create refresh token", "member_id", memberID, "error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// UseRefreshToken revokes a live refresh token and returns the credentials of
+// its owner, so every token can be exchanged exactly once.
+func (a *AccountStore) UseRefreshToken(ctx context.Context, hash string) (*model.Credentials, error) {
+	var credentials model.Credentials
+	err := conn(ctx, a.db).QueryRowContext(ctx, `WITH used AS (
+									UPDATE refresh_tokens SET revoked_at = now()
+									WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > now()
+									RETURNING member_id)
+								SELECT member_credentials.member_id, login, role, member_credentials.updated_at
+								FROM member_credentials JOIN used ON used.member_id = member_credentials.member_id
+								JOIN members ON members.id = member_credentials.member_id
+								WHERE members.deleted_at IS NULL`, hash).
+		Scan(&credentials.MemberID, &credentials.Login, &credentials.Role, &credentials.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+
+		tracing.Logger(ctx, a.logger).Error("refresh token lookup failed", "error", err.Error())
+		return nil, err
+	}
+
+	return &credentials, nil
+}
+
+func (a *AccountStore) RevokeRefreshToken(ctx context.Context, hash string) error {
+	result, err := conn(ctx, a.db).ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = now() WHERE token_hash = $1 AND revoked_at IS NULL`, hash)
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("revoke failed for refresh token", "error", err.Error())
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("rows affected failed for refresh token revoke", "error", err.Error())
+		return err
+	}
+
+	if affected == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+func (a *AccountStore) CreatePasswordReset(ctx context.Context, memberID string, hash string, expiresAt time.Time) error {
+	_, err := conn(ctx, a.db).ExecContext(ctx, `INSERT INTO password_resets (token_hash, member_id, expires_at) VALUES ($1, $2, $3)`,
+		hash, memberID, expiresAt)
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("failed to create password reset", "member_id", memberID, "error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// ResetPassword consumes a reset token, stores the new password hash and
+// revokes every refresh token of the member in a single statement.
+func (a *AccountStore) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) error {
+	result, err := conn(ctx, a.db).ExecContext(ctx, `WITH reset AS (
+									UPDATE password_resets SET used_at = now()
+									WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+									RETURNING member_id),
+								revoked AS (
+									UPDATE refresh_tokens SET revoked_at = now()
+									WHERE member_id IN (SELECT member_id FROM reset) AND revoked_at IS NULL)
+								UPDATE member_credentials SET password_hash = $2, updated_at = now()
+								WHERE member_id IN (SELECT member_id FROM reset)`, tokenHash, passwordHash)
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("password reset failed", "error", err.Error())
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("rows affected failed for password reset", "error", err.Error())
+		return err
+	}
+
+	if affected == 0 {
+		tracing.Logger(ctx, a.logger).Info("password reset token is unknown, used or expired")
+		return model.ErrNotFound
+	}
+
+	return nil
+}
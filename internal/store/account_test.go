@@ -0,0 +1,428 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"library-api/internal/model"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+const testMemberID = "5d574a92-4b78-46eb-8ab0-02709b710b15"
+
+func TestAccountStore_SetCredentials(t *testing.T) {
+	updatedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			description: "credentials and role stored",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`INSERT INTO member_credentials`).
+					WithArgs(testMemberID, "john", "hash", "librarian", "member").
+					WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(updatedAt))
+			},
+		},
+		{
+			description: "role of the existing account differs",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`INSERT INTO member_credentials (.+) SET (.+) role = EXCLUDED.role(.+) WHERE member_credentials.role = \$5`).
+					WithArgs(testMemberID, "john", "hash", "librarian", "member").
+					WillReturnRows(sqlmock.NewRows([]string{"updated_at"}))
+			},
+			expectedError: model.ErrVersionConflict,
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`INSERT INTO member_credentials`).
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewAccountStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			credentials := model.Credentials{MemberID: testMemberID, Login: "john", PasswordHash: "hash", Role: "librarian"}
+			err = s.SetCredentials(context.Background(), &credentials, "member")
+			assert.Equal(t, testCase.expectedError, err)
+
+			if testCase.expectedError == nil {
+				assert.Equal(t, updatedAt, credentials.UpdatedAt)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAccountStore_GetCredentialsByMember(t *testing.T) {
+	updatedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expected      *model.Credentials
+		expectedError error
+	}{
+		{
+			description: "credentials found",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT member_id, login, password_hash, role, updated_at FROM member_credentials WHERE member_id = \$1`).
+					WithArgs(testMemberID).
+					WillReturnRows(sqlmock.NewRows([]string{"member_id", "login", "password_hash", "role", "updated_at"}).
+						AddRow(testMemberID, "john", "hash", "librarian", updatedAt))
+			},
+			expected: &model.Credentials{MemberID: testMemberID, Login: "john", PasswordHash: "hash", Role: "librarian", UpdatedAt: updatedAt},
+		},
+		{
+			description: "no account",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT member_id, login, password_hash, role, updated_at FROM member_credentials`).
+					WithArgs(testMemberID).
+					WillReturnRows(sqlmock.NewRows([]string{"member_id", "login", "password_hash", "role", "updated_at"}))
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT member_id, login, password_hash, role, updated_at FROM member_credentials`).
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewAccountStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			actual, err := s.GetCredentialsByMember(context.Background(), testMemberID)
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expected, actual)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAccountStore_GetCredentials(t *testing.T) {
+	updatedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expected      *model.Credentials
+		expectedError error
+	}{
+		{
+			description: "credentials found",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT member_id, login, password_hash, role, (.+) FROM member_credentials JOIN members (.+) members.deleted_at IS NULL`).
+					WithArgs("john").
+					WillReturnRows(sqlmock.NewRows([]string{"member_id", "login", "password_hash", "role", "updated_at"}).
+						AddRow(testMemberID, "john", "hash", "member", updatedAt))
+			},
+			expected: &model.Credentials{MemberID: testMemberID, Login: "john", PasswordHash: "hash", Role: "member", UpdatedAt: updatedAt},
+		},
+		{
+			description: "unknown login",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT member_id, login, password_hash, role, (.+) FROM member_credentials JOIN members (.+) members.deleted_at IS NULL`).
+					WithArgs("john").
+					WillReturnRows(sqlmock.NewRows([]string{"member_id", "login", "password_hash", "role", "updated_at"}))
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT member_id, login, password_hash, role, (.+) FROM member_credentials JOIN members (.+) members.deleted_at IS NULL`).
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewAccountStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			actual, err := s.GetCredentials(context.Background(), "john")
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expected, actual)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAccountStore_CreateRefreshToken(t *testing.T) {
+	expiresAt := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			description: "refresh token stored",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO refresh_tokens`).
+					WithArgs("6f1b1c36-46ff-4dc5-8a44-5c0bde0f77a7", testMemberID, "hash", expiresAt).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO refresh_tokens`).
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewAccountStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			err = s.CreateRefreshToken(context.Background(), "6f1b1c36-46ff-4dc5-8a44-5c0bde0f77a7", testMemberID, "hash", expiresAt)
+			assert.Equal(t, testCase.expectedError, err)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAccountStore_UseRefreshToken(t *testing.T) {
+	updatedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expected      *model.Credentials
+		expectedError error
+	}{
+		{
+			description: "refresh token used",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`WITH used AS \( UPDATE refresh_tokens SET revoked_at = now\(\)`).
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows([]string{"member_id", "login", "role", "updated_at"}).
+						AddRow(testMemberID, "john", "member", updatedAt))
+			},
+			expected: &model.Credentials{MemberID: testMemberID, Login: "john", Role: "member", UpdatedAt: updatedAt},
+		},
+		{
+			description: "token revoked or expired",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`WITH used AS`).
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows([]string{"member_id", "login", "role", "updated_at"}))
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`WITH used AS`).
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewAccountStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			actual, err := s.UseRefreshToken(context.Background(), "hash")
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expected, actual)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAccountStore_RevokeRefreshToken(t *testing.T) {
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			description: "refresh token revoked",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`UPDATE refresh_tokens SET revoked_at = now\(\)`).
+					WithArgs("hash").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description: "unknown token",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`UPDATE refresh_tokens SET revoked_at = now\(\)`).
+					WithArgs("hash").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`UPDATE refresh_tokens SET revoked_at = now\(\)`).
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewAccountStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			err = s.RevokeRefreshToken(context.Background(), "hash")
+			assert.Equal(t, testCase.expectedError, err)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAccountStore_CreatePasswordReset(t *testing.T) {
+	expiresAt := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			description: "password reset stored",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO password_resets`).
+					WithArgs("hash", testMemberID, expiresAt).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO password_resets`).
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewAccountStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			err = s.CreatePasswordReset(context.Background(), testMemberID, "hash", expiresAt)
+			assert.Equal(t, testCase.expectedError, err)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAccountStore_ResetPassword(t *testing.T) {
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			description: "password reset",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`WITH reset AS`).
+					WithArgs("token-hash", "password-hash").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description: "token used or expired",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`WITH reset AS`).
+					WithArgs("token-hash", "password-hash").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`WITH reset AS`).
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewAccountStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			err = s.ResetPassword(context.Background(), "token-hash", "password-hash")
+			assert.Equal(t, testCase.expectedError, err)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+
+	"github.com/lib/pq"
+)
+
+func (a *APIKeyStore) Create(ctx context.Context, key *model.APIKey, hash string) error {
+	err := conn(ctx, a.db).QueryRowContext(ctx, `INSERT INTO api_keys (id, name, prefix, key_hash, scopes, expires_at)
+								VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at`,
+		key.ID, key.Name, key.Prefix, hash, pq.Array(key.Scopes), key.ExpiresAt).Scan(&key.CreatedAt)
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("failed to create api key", "name", key.Name, "error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (a *APIKeyStore) Get(ctx context.Context) ([]model.APIKey, error) {
+	rows, err := conn(ctx, a.db).QueryContext(ctx, `SELECT id, name, prefix, scopes, expires_at, last_used_at, created_at, revoked_at
+									FROM api_keys ORDER BY created_at`)
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("failed to execute query for get api keys", "error", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		var key model.APIKey
+		err = rows.Scan(&key.ID, &key.Name, &key.Prefix, pq.Array(&key.Scopes),
+			&key.ExpiresAt, &key.LastUsedAt, &key.CreatedAt, &key.RevokedAt)
+		if err != nil {
+			tracing.Logger(ctx, a.logger).Error("scanning selected failed for api keys", "error", err.Error())
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (a *APIKeyStore) Revoke(ctx context.Context, id string) error {
+	result, err := conn(ctx, a.db).ExecContext(ctx, `UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("revoke failed for api key", "id", id, "error", err.Error())
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("rows affected failed for api key revoke", "id", id, "error", err.Error())
+		return err
+	}
+
+	if affected == 0 {
+		tracing.Logger(ctx, a.logger).Info("api key does not exist or is already revoked", "id", id)
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+func (a *APIKeyStore) Authenticate(ctx context.Context, hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := conn(ctx, a.db).QueryRowContext(ctx, `UPDATE api_keys SET last_used_at = now()
+								WHERE key_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > now())
+								RETURNING id, name, prefix, scopes, expires_at, last_used_at, created_at`, hash).
+		Scan(&key.ID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.ExpiresAt, &key.LastUsedAt, &key.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+
+		tracing.Logger(ctx, a.logger).Error("api key lookup failed", "error", err.Error())
+		return nil, err
+	}
+
+	return &key, nil
+}
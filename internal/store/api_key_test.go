@@ -0,0 +1,250 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"library-api/internal/model"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hashicorp/go-hclog"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyStore_Create(t *testing.T) {
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			description: "create api key successfully",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`INSERT INTO api_keys`).
+					WithArgs("6f1b1c36-46ff-4dc5-8a44-5c0bde0f77a7", "kiosk", "a1b2c3d4", "hash", pq.Array([]string{"circulation:write"}), nil).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+			},
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`INSERT INTO api_keys`).
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewAPIKeyStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			key := model.APIKey{
+				ID:     "6f1b1c36-46ff-4dc5-8a44-5c0bde0f77a7",
+				Name:   "kiosk",
+				Prefix: "a1b2c3d4",
+				Scopes: []string{"circulation:write"},
+			}
+
+			err = s.Create(context.Background(), &key, "hash")
+			assert.Equal(t, testCase.expectedError, err)
+
+			if testCase.expectedError == nil {
+				assert.Equal(t, createdAt, key.CreatedAt)
+			}
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAPIKeyStore_Get(t *testing.T) {
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	columns := []string{"id", "name", "prefix", "scopes", "expires_at", "last_used_at", "created_at", "revoked_at"}
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedBody  []model.APIKey
+		expectedError error
+	}{
+		{
+			description: "get api keys successfully",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow("6f1b1c36-46ff-4dc5-8a44-5c0bde0f77a7", "kiosk", "a1b2c3d4", "{circulation:write,members:read}", nil, nil, createdAt, nil)
+
+				mock.ExpectQuery(`SELECT id, name, prefix, scopes, expires_at, last_used_at, created_at, revoked_at`).
+					WillReturnRows(rows)
+			},
+			expectedBody: []model.APIKey{
+				{
+					ID:        "6f1b1c36-46ff-4dc5-8a44-5c0bde0f77a7",
+					Name:      "kiosk",
+					Prefix:    "a1b2c3d4",
+					Scopes:    []string{"circulation:write", "members:read"},
+					CreatedAt: createdAt,
+				},
+			},
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT id, name, prefix, scopes`).
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewAPIKeyStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			body, err := s.Get(context.Background())
+			assert.Equal(t, testCase.expectedError, err)
+
+			assert.Equal(t, testCase.expectedBody, body)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAPIKeyStore_Revoke(t *testing.T) {
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			description: "revoke api key successfully",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`UPDATE api_keys SET revoked_at = now\(\) WHERE id = \$1 AND revoked_at IS NULL`).
+					WithArgs("6f1b1c36-46ff-4dc5-8a44-5c0bde0f77a7").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description: "already revoked",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`UPDATE api_keys SET revoked_at`).
+					WithArgs("6f1b1c36-46ff-4dc5-8a44-5c0bde0f77a7").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`UPDATE api_keys SET revoked_at`).
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewAPIKeyStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			err = s.Revoke(context.Background(), "6f1b1c36-46ff-4dc5-8a44-5c0bde0f77a7")
+			assert.Equal(t, testCase.expectedError, err)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAPIKeyStore_Authenticate(t *testing.T) {
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	lastUsedAt := time.Date(2026, 2, 2, 3, 4, 5, 0, time.UTC)
+	columns := []string{"id", "name", "prefix", "scopes", "expires_at", "last_used_at", "created_at"}
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedBody  *model.APIKey
+		expectedError error
+	}{
+		{
+			description: "active key",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow("6f1b1c36-46ff-4dc5-8a44-5c0bde0f77a7", "kiosk", "a1b2c3d4", "{circulation:write}", nil, lastUsedAt, createdAt)
+
+				mock.ExpectQuery(`UPDATE api_keys SET last_used_at = now\(\)`).
+					WithArgs("hash").
+					WillReturnRows(rows)
+			},
+			expectedBody: &model.APIKey{
+				ID:         "6f1b1c36-46ff-4dc5-8a44-5c0bde0f77a7",
+				Name:       "kiosk",
+				Prefix:     "a1b2c3d4",
+				Scopes:     []string{"circulation:write"},
+				LastUsedAt: &lastUsedAt,
+				CreatedAt:  createdAt,
+			},
+		},
+		{
+			description: "unknown, expired or revoked key",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`UPDATE api_keys SET last_used_at`).
+					WithArgs("hash").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`UPDATE api_keys SET last_used_at`).
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewAPIKeyStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			body, err := s.Authenticate(context.Background(), "hash")
+			assert.Equal(t, testCase.expectedError, err)
+
+			assert.Equal(t, testCase.expectedBody, body)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
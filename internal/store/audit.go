@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+)
+
+func (a *AuditStore) Create(ctx context.Context, entry *model.AuditEntry) error {
+	err := conn(ctx, a.db).QueryRowContext(ctx, `INSERT INTO audit_log (actor, action, entity_type, entity_id, changes, request_id)
+								VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')) RETURNING id, created_at`,
+		entry.Actor, entry.Action, entry.EntityType, entry.EntityID, []byte(entry.Changes), entry.RequestID).
+		Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("failed to write audit entry",
+			"action", entry.Action,
+			"entity_type", entry.EntityType,
+			"entity_id", entry.EntityID,
+			"error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (a *AuditStore) Get(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	rows, err := conn(ctx, a.db).QueryContext(ctx, `SELECT id, actor, action, entity_type, entity_id, changes, COALESCE(request_id, ''), created_at
+									FROM audit_log
+									WHERE ($1 = '' OR actor = $1) AND ($2 = '' OR action = $2)
+									  AND ($3 = '' OR entity_type = $3) AND ($4 = '' OR entity_id = $4)
+									  AND ($5::timestamptz IS NULL OR created_at >= $5)
+									  AND ($6::timestamptz IS NULL OR created_at < $6)
+									ORDER BY id DESC LIMIT $7 OFFSET $8`,
+		filter.Actor, filter.Action, filter.EntityType, filter.EntityID, filter.From, filter.To, filter.Limit, filter.Offset)
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("failed to execute query for get audit entries", "error", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []model.AuditEntry
+	for rows.Next() {
+		var entry model.AuditEntry
+		var changes []byte
+		err = rows.Scan(&entry.ID, &entry.Actor, &entry.Action, &entry.EntityType, &entry.EntityID,
+			&changes, &entry.RequestID, &entry.CreatedAt)
+		if err != nil {
+			tracing.Logger(ctx, a.logger).Error("scanning selected failed for audit entries", "error", err.Error())
+			return nil, err
+		}
+
+		entry.Changes = changes
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
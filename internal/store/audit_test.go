@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"library-api/internal/model"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+var auditColumns = []string{"id", "actor", "action", "entity_type", "entity_id", "changes", "request_id", "created_at"}
+
+func TestAuditStore_Create(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedEntry model.AuditEntry
+		expectedError error
+	}{
+		{
+			description: "entry written",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO audit_log").
+					WithArgs("admin", "update", "author", "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+						[]byte(`{"nick_name":{"before":"jd","after":"johndoe"}}`), "req-1").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, createdAt))
+			},
+			expectedEntry: model.AuditEntry{
+				ID:         7,
+				Actor:      "admin",
+				Action:     "update",
+				EntityType: "author",
+				EntityID:   "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+				Changes:    json.RawMessage(`{"nick_name":{"before":"jd","after":"johndoe"}}`),
+				RequestID:  "req-1",
+				CreatedAt:  createdAt,
+			},
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO audit_log").
+					WillReturnError(errors.New("insert error"))
+			},
+			expectedEntry: model.AuditEntry{
+				Actor:      "admin",
+				Action:     "update",
+				EntityType: "author",
+				EntityID:   "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+				Changes:    json.RawMessage(`{"nick_name":{"before":"jd","after":"johndoe"}}`),
+				RequestID:  "req-1",
+			},
+			expectedError: errors.New("insert error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewAuditStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			entry := model.AuditEntry{
+				Actor:      "admin",
+				Action:     "update",
+				EntityType: "author",
+				EntityID:   "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+				Changes:    json.RawMessage(`{"nick_name":{"before":"jd","after":"johndoe"}}`),
+				RequestID:  "req-1",
+			}
+
+			err = s.Create(context.Background(), &entry)
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedEntry, entry)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuditStore_Get(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		description     string
+		filter          model.AuditFilter
+		setupMock       func(mock sqlmock.Sqlmock)
+		expectedEntries []model.AuditEntry
+		expectedError   error
+	}{
+		{
+			description: "entries filtered",
+			filter:      model.AuditFilter{EntityType: "author", From: &from, Limit: 100},
+			setupMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(auditColumns).
+					AddRow(7, "admin", "delete", "author", "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+						[]byte(`{"full_name":{"before":"John Doe","after":null}}`), "", createdAt)
+
+				mock.ExpectQuery("SELECT id, actor, (.+) FROM audit_log WHERE (.+) ORDER BY id DESC LIMIT \\$7 OFFSET \\$8").
+					WithArgs("", "", "author", "", &from, nil, 100, 0).
+					WillReturnRows(rows)
+			},
+			expectedEntries: []model.AuditEntry{
+				{
+					ID:         7,
+					Actor:      "admin",
+					Action:     "delete",
+					EntityType: "author",
+					EntityID:   "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+					Changes:    json.RawMessage(`{"full_name":{"before":"John Doe","after":null}}`),
+					CreatedAt:  createdAt,
+				},
+			},
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT id, actor, (.+) FROM audit_log").
+					WillReturnError(errors.New("select error"))
+			},
+			expectedError: errors.New("select error"),
+		},
+		{
+			description: "scan error",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id"}).
+					AddRow(7)
+
+				mock.ExpectQuery("SELECT id, actor, (.+) FROM audit_log").
+					WillReturnRows(rows)
+			},
+			expectedError: errors.New("sql: expected 1 destination arguments in Scan, not 8"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewAuditStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			entries, err := s.Get(context.Background(), testCase.filter)
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedEntries, entries)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -1,14 +1,19 @@
 package store
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"library-api/internal/model"
+	"library-api/pkg/tracing"
 )
 
-func (a *AuthorStore) Get() ([]model.Author, error) {
-	rows, err := a.db.Query(`SELECT * FROM authors`)
+const authorFields = `id, full_name, COALESCE(nick_name, ''), COALESCE(specialization, ''), version`
+
+func (a *AuthorStore) Get(ctx context.Context) ([]model.Author, error) {
+	rows, err := conn(ctx, a.db).QueryContext(ctx, `SELECT id, full_name, nick_name, specialization, version FROM authors WHERE deleted_at IS NULL`)
 	if err != nil {
-		a.logger.Error("failed to execute query for get authors", "error", err.Error())
+		tracing.Logger(ctx, a.logger).Error("failed to execute query for get authors", "error", err.Error())
 		return nil, err
 	}
 	defer rows.Close()
@@ -16,9 +21,9 @@ func (a *AuthorStore) Get() ([]model.Author, error) {
 	var authors []model.Author
 	for rows.Next() {
 		var author model.Author
-		err = rows.Scan(&author.ID, &author.FullName, &author.NickName, &author.Specialization)
+		err = rows.Scan(&author.ID, &author.FullName, &author.NickName, &author.Specialization, &author.Version)
 		if err != nil {
-			a.logger.Error("scanning selected failed for authors", "error", err.Error())
+			tracing.Logger(ctx, a.logger).Error("scanning selected failed for authors", "error", err.Error())
 			return nil, err
 		}
 
@@ -28,66 +33,89 @@ func (a *AuthorStore) Get() ([]model.Author, error) {
 	return authors, nil
 }
 
-func (a *AuthorStore) Create(author *model.Author) error {
-	_, err := a.db.Exec(`INSERT INTO authors (id, full_name, nick_name, specialization) VALUES ($1, $2, $3, $4)`,
-		&author.ID, &author.FullName, &author.NickName, &author.Specialization)
+// Create inserts the author and replaces it with the stored row.
+func (a *AuthorStore) Create(ctx context.Context, author *model.Author) error {
+	err := conn(ctx, a.db).QueryRowContext(ctx, `INSERT INTO authors (id, full_name, nick_name, specialization) VALUES ($1, $2, $3, $4)
+								RETURNING `+authorFields,
+		&author.ID, &author.FullName, &author.NickName, &author.Specialization).
+		Scan(&author.ID, &author.FullName, &author.NickName, &author.Specialization, &author.Version)
 	if err != nil {
-		a.logger.Error("failed to create author", "error", err.Error())
+		tracing.Logger(ctx, a.logger).Error("failed to create author", "error", err.Error())
 		return err
 	}
 
 	return nil
 }
 
-func (a *AuthorStore) Exists(id string) error {
-	rows, err := a.db.Query(`SELECT EXISTS (SELECT 1 FROM authors WHERE id = $1)`, id)
+func (a *AuthorStore) GetByID(ctx context.Context, id string) (*model.Author, error) {
+	var author model.Author
+	err := conn(ctx, a.db).QueryRowContext(ctx, `SELECT `+authorFields+` FROM authors WHERE id = $1 AND deleted_at IS NULL`, id).
+		Scan(&author.ID, &author.FullName, &author.NickName, &author.Specialization, &author.Version)
 	if err != nil {
-		a.logger.Info("select error", "id", id, "error", err.Error())
-		return err
-	}
-
-	for rows.Next() {
-		var exists bool
-		err = rows.Scan(&exists)
-		if err != nil {
-			a.logger.Info("scan error", "id", id, "error", err.Error())
-			return err
+		if errors.Is(err, sql.ErrNoRows) {
+			tracing.Logger(ctx, a.logger).Info("author does not exist", "id", id)
+			return nil, model.ErrNotFound
 		}
 
-		if !exists {
-			a.logger.Info("author does not exist", "id", id)
-			return errors.New("author does not exist")
-		}
+		tracing.Logger(ctx, a.logger).Error("get by id failed for authors", "id", id, "error", err.Error())
+		return nil, err
 	}
 
-	return nil
+	return &author, nil
 }
 
-func (a *AuthorStore) Update(id string, author *model.Author) error {
-	_, err := a.db.Exec(`UPDATE authors SET full_name = $1, nick_name = $2, specialization = $3 WHERE ID = $4`,
-		&author.FullName, &author.NickName, &author.Specialization, id)
+// Update applies the change only if the author is still at author.Version
+// and replaces author with the stored row.
+func (a *AuthorStore) Update(ctx context.Context, id string, author *model.Author) error {
+	err := conn(ctx, a.db).QueryRowContext(ctx, `UPDATE authors SET full_name = $1, nick_name = $2, specialization = $3
+								WHERE ID = $4 AND deleted_at IS NULL AND version = $5
+								RETURNING `+authorFields,
+		&author.FullName, &author.NickName, &author.Specialization, id, author.Version).
+		Scan(&author.ID, &author.FullName, &author.NickName, &author.Specialization, &author.Version)
 	if err != nil {
-		a.logger.Error("update failed for author", "id", id, "error", err.Error())
+		if errors.Is(err, sql.ErrNoRows) {
+			tracing.Logger(ctx, a.logger).Info("author version conflict", "id", id, "version", author.Version)
+			return model.ErrVersionConflict
+		}
+
+		tracing.Logger(ctx, a.logger).Error("update failed for author", "id", id, "error", err.Error())
 		return err
 	}
 
 	return nil
 }
 
-func (a *AuthorStore) Delete(id string) error {
-	_, err := a.db.Exec(`DELETE FROM authors WHERE ID = $1`, id)
+// Delete marks the author as deleted. Authors that still have books in the
+// catalog are left untouched and reported as referenced.
+func (a *AuthorStore) Delete(ctx context.Context, id string) error {
+	err := softDelete(conn(ctx, a.db).QueryRowContext(ctx, `WITH target AS (
+									SELECT id, EXISTS (SELECT 1 FROM books WHERE books.authors_id = authors.id AND books.deleted_at IS NULL) AS referenced
+									FROM authors WHERE id = $1 AND deleted_at IS NULL FOR UPDATE),
+								deleted AS (
+									UPDATE authors SET deleted_at = now() FROM target
+									WHERE authors.id = target.id AND NOT target.referenced)
+								SELECT referenced FROM target`, id))
+	if err != nil && !errors.Is(err, model.ErrNotFound) && !errors.Is(err, model.ErrReferenced) {
+		tracing.Logger(ctx, a.logger).Error("delete failed for authors", "id", id, "error", err.Error())
+	}
+
+	return err
+}
+
+func (a *AuthorStore) Restore(ctx context.Context, id string) error {
+	result, err := conn(ctx, a.db).ExecContext(ctx, `UPDATE authors SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id)
 	if err != nil {
-		a.logger.Error("delete failed for authors", "id", id, "error", err.Error())
+		tracing.Logger(ctx, a.logger).Error("restore failed for authors", "id", id, "error", err.Error())
 		return err
 	}
 
-	return nil
+	return requireAffected(result)
 }
 
-func (a *AuthorStore) GetAuthorsBooks(id string) ([]string, error) {
-	rows, err := a.db.Query(`SELECT title FROM books WHERE authors_id = $1`, id)
+func (a *AuthorStore) GetAuthorsBooks(ctx context.Context, id string) ([]string, error) {
+	rows, err := conn(ctx, a.db).QueryContext(ctx, `SELECT title FROM books WHERE authors_id = $1 AND deleted_at IS NULL`, id)
 	if err != nil {
-		a.logger.Error("select for get for authors books failed", "id", id, "error", err.Error())
+		tracing.Logger(ctx, a.logger).Error("select for get for authors books failed", "id", id, "error", err.Error())
 		return nil, err
 	}
 
@@ -96,7 +124,7 @@ func (a *AuthorStore) GetAuthorsBooks(id string) ([]string, error) {
 		var book model.Book
 		err = rows.Scan(&book.Title)
 		if err != nil {
-			a.logger.Error("scan rows failed for authors books", "id", id, "error", err.Error())
+			tracing.Logger(ctx, a.logger).Error("scan rows failed for authors books", "id", id, "error", err.Error())
 			return nil, err
 		}
 
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"library-api/internal/model"
 	"testing"
@@ -12,7 +14,7 @@ import (
 
 func TestNewAuthorStore_Get(t *testing.T) {
 	fullName := "John Doe"
-	columns := []string{"id", "full_name", "nick_name", "specialization"}
+	columns := []string{"id", "full_name", "nick_name", "specialization", "version"}
 	testCases := []struct {
 		description   string
 		setupMock     func(mock sqlmock.Sqlmock)
@@ -23,10 +25,10 @@ func TestNewAuthorStore_Get(t *testing.T) {
 			description: "author store created successfully",
 			setupMock: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows(columns).
-					AddRow("b7eb3c06-6df8-4353-90f5-7ab897a77158", "John Doe", "johndoe123", "writer").
-					AddRow("b44d8a61-6f6e-490e-88d6-45ff67088d0b", "John Doe", "johndoe123super", "writer")
+					AddRow("b7eb3c06-6df8-4353-90f5-7ab897a77158", "John Doe", "johndoe123", "writer", 1).
+					AddRow("b44d8a61-6f6e-490e-88d6-45ff67088d0b", "John Doe", "johndoe123super", "writer", 3)
 
-				mock.ExpectQuery("SELECT \\* FROM authors").
+				mock.ExpectQuery("SELECT id, full_name, nick_name, specialization, version FROM authors WHERE deleted_at IS NULL").
 					WillReturnRows(rows)
 			},
 			expectedBody: []model.Author{
@@ -35,19 +37,21 @@ func TestNewAuthorStore_Get(t *testing.T) {
 					FullName:       &fullName,
 					NickName:       "johndoe123",
 					Specialization: "writer",
+					Version:        1,
 				},
 				{
 					ID:             "b44d8a61-6f6e-490e-88d6-45ff67088d0b",
 					FullName:       &fullName,
 					NickName:       "johndoe123super",
 					Specialization: "writer",
+					Version:        3,
 				},
 			},
 		},
 		{
 			description: "error db",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT \\* FROM authors").
+				mock.ExpectQuery("SELECT id, full_name, nick_name, specialization, version FROM authors WHERE deleted_at IS NULL").
 					WillReturnError(errors.New("error"))
 			},
 			expectedError: errors.New("error"),
@@ -58,10 +62,10 @@ func TestNewAuthorStore_Get(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"only one row"}).
 					AddRow("hello")
 
-				mock.ExpectQuery("SELECT \\* FROM authors").
+				mock.ExpectQuery("SELECT id, full_name, nick_name, specialization, version FROM authors WHERE deleted_at IS NULL").
 					WillReturnRows(rows)
 			},
-			expectedError: errors.New("sql: expected 1 destination arguments in Scan, not 4"),
+			expectedError: errors.New("sql: expected 1 destination arguments in Scan, not 5"),
 		},
 	}
 
@@ -75,7 +79,7 @@ func TestNewAuthorStore_Get(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			body, err := s.Get()
+			body, err := s.Get(context.Background())
 			assert.Equal(t, testCase.expectedError, err)
 
 			assert.Equal(t, testCase.expectedBody, body)
@@ -89,10 +93,11 @@ func TestNewAuthorStore_Get(t *testing.T) {
 func TestAuthorStore_Create(t *testing.T) {
 	fullName := "John Doe"
 	testCases := []struct {
-		description   string
-		author        model.Author
-		setupMock     func(mock sqlmock.Sqlmock)
-		expectedError error
+		description     string
+		author          model.Author
+		setupMock       func(mock sqlmock.Sqlmock)
+		expectedVersion int
+		expectedError   error
 	}{
 		{
 			description: "create author successfully",
@@ -104,16 +109,18 @@ func TestAuthorStore_Create(t *testing.T) {
 			},
 			expectedError: nil,
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec(`INSERT INTO authors`).
+				mock.ExpectQuery(`INSERT INTO authors (.+) RETURNING id, full_name`).
 					WithArgs("b7eb3c06-6df8-4353-90f5-7ab897a77158", &fullName, "johndoe123", "writer").
-					WillReturnResult(sqlmock.NewResult(1, 1))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "full_name", "nick_name", "specialization", "version"}).
+						AddRow("b7eb3c06-6df8-4353-90f5-7ab897a77158", "John Doe", "johndoe123", "writer", 1))
 			},
+			expectedVersion: 1,
 		},
 		{
 			description:   "error db",
 			expectedError: errors.New("error"),
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec(`INSERT INTO authors`).
+				mock.ExpectQuery(`INSERT INTO authors`).
 					WillReturnError(errors.New("error"))
 			},
 		},
@@ -129,8 +136,9 @@ func TestAuthorStore_Create(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			err = s.Create(&testCase.author)
+			err = s.Create(context.Background(), &testCase.author)
 			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedVersion, testCase.author.Version)
 
 			err = mock.ExpectationsWereMet()
 			assert.NoError(t, err)
@@ -138,57 +146,47 @@ func TestAuthorStore_Create(t *testing.T) {
 	}
 }
 
-func TestAuthorStore_Exists(t *testing.T) {
+func TestAuthorStore_GetByID(t *testing.T) {
+	fullName := "John Doe"
 	testCases := []struct {
-		description   string
-		id            string
-		setupMock     func(mock sqlmock.Sqlmock)
-		expectedError error
+		description    string
+		setupMock      func(mock sqlmock.Sqlmock)
+		expectedAuthor *model.Author
+		expectedError  error
 	}{
 		{
-			description: "author exists successfully",
-			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+			description: "author found",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"exists"}).
-					AddRow(true)
+				rows := sqlmock.NewRows([]string{"id", "full_name", "nick_name", "specialization", "version"}).
+					AddRow("b7eb3c06-6df8-4353-90f5-7ab897a77158", "John Doe", "johndoe123", "writer", 2)
 
-				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM authors WHERE id = \\$1\\)").
+				mock.ExpectQuery("SELECT id, full_name, (.+) FROM authors WHERE id = \\$1 AND deleted_at IS NULL").
 					WithArgs("b7eb3c06-6df8-4353-90f5-7ab897a77158").
 					WillReturnRows(rows)
 			},
-		},
-		{
-			description: "error db",
-			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
-			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM authors WHERE id = \\$1\\)").
-					WillReturnError(errors.New("select error"))
+			expectedAuthor: &model.Author{
+				ID:             "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+				FullName:       &fullName,
+				NickName:       "johndoe123",
+				Specialization: "writer",
+				Version:        2,
 			},
-			expectedError: errors.New("select error"),
 		},
 		{
-			description: "scan error",
-			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+			description: "author doesn't exist",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"1st row", "second row"}).
-					AddRow("hello", "world")
-
-				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM authors WHERE id = \\$1\\)").
-					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT id, full_name, (.+) FROM authors WHERE id = \\$1 AND deleted_at IS NULL").
+					WillReturnError(sql.ErrNoRows)
 			},
-			expectedError: errors.New("sql: expected 2 destination arguments in Scan, not 1"),
+			expectedError: model.ErrNotFound,
 		},
 		{
-			description: "author doesn't exist",
-			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+			description: "error db",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"exists"}).
-					AddRow(false)
-
-				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM authors WHERE id = \\$1\\)").
-					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT id, full_name, (.+) FROM authors WHERE id = \\$1 AND deleted_at IS NULL").
+					WillReturnError(errors.New("select error"))
 			},
-			expectedError: errors.New("author does not exist"),
+			expectedError: errors.New("select error"),
 		},
 	}
 
@@ -202,8 +200,9 @@ func TestAuthorStore_Exists(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			err = s.Exists(testCase.id)
+			author, err := s.GetByID(context.Background(), "b7eb3c06-6df8-4353-90f5-7ab897a77158")
 			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedAuthor, author)
 
 			err = mock.ExpectationsWereMet()
 			assert.NoError(t, err)
@@ -229,23 +228,43 @@ func TestAuthorStore_Update(t *testing.T) {
 				FullName:       &fullName,
 				NickName:       "johndoe123 New",
 				Specialization: "writer New",
+				Version:        1,
 			},
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("UPDATE authors").
-					WithArgs(&fullName, "johndoe123 New", "writer New", "b7eb3c06-6df8-4353-90f5-7ab897a77158").
-					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery("UPDATE authors (.+) WHERE ID = \\$4 AND deleted_at IS NULL AND version = \\$5 RETURNING id, full_name").
+					WithArgs(&fullName, "johndoe123 New", "writer New", "b7eb3c06-6df8-4353-90f5-7ab897a77158", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "full_name", "nick_name", "specialization", "version"}).
+						AddRow("b7eb3c06-6df8-4353-90f5-7ab897a77158", "John Doe", "johndoe123 New", "writer New", 2))
 			},
 			expectedBody: model.Author{
 				ID:             "b7eb3c06-6df8-4353-90f5-7ab897a77158",
 				FullName:       &fullName,
 				NickName:       "johndoe123 New",
 				Specialization: "writer New",
+				Version:        2,
+			},
+		},
+		{
+			description: "version conflict",
+			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+			body: model.Author{
+				ID:      "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+				Version: 1,
+			},
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("UPDATE authors").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedBody: model.Author{
+				ID:      "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+				Version: 1,
 			},
+			expectedError: model.ErrVersionConflict,
 		},
 		{
 			description: "error db",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("UPDATE authors").
+				mock.ExpectQuery("UPDATE authors").
 					WillReturnError(errors.New("update failed"))
 			},
 			expectedError: errors.New("update failed"),
@@ -262,7 +281,7 @@ func TestAuthorStore_Update(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			err = s.Update(testCase.id, &testCase.body)
+			err = s.Update(context.Background(), testCase.id, &testCase.body)
 			assert.Equal(t, testCase.expectedError, err)
 
 			assert.Equal(t, testCase.expectedBody, testCase.body)
@@ -281,18 +300,39 @@ func TestAuthorStore_Delete(t *testing.T) {
 		expectedError error
 	}{
 		{
-			description: "delete author successfully",
+			description: "author marked as deleted",
 			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("DELETE FROM author").
+				mock.ExpectQuery("WITH target AS (.+) FROM authors WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE(.+) UPDATE authors SET deleted_at = now\\(\\)").
 					WithArgs("b7eb3c06-6df8-4353-90f5-7ab897a77158").
-					WillReturnResult(sqlmock.NewResult(1, 1))
+					WillReturnRows(sqlmock.NewRows([]string{"referenced"}).AddRow(false))
 			},
 		},
+		{
+			description: "author still referenced",
+			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("WITH target AS").
+					WithArgs("b7eb3c06-6df8-4353-90f5-7ab897a77158").
+					WillReturnRows(sqlmock.NewRows([]string{"referenced"}).AddRow(true))
+			},
+			expectedError: model.ErrReferenced,
+		},
+		{
+			description: "author missing or already deleted",
+			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("WITH target AS").
+					WithArgs("b7eb3c06-6df8-4353-90f5-7ab897a77158").
+					WillReturnRows(sqlmock.NewRows([]string{"referenced"}))
+			},
+			expectedError: model.ErrNotFound,
+		},
 		{
 			description: "error db",
+			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("DELETE FROM author").
+				mock.ExpectQuery("WITH target AS").
 					WillReturnError(errors.New("delete failed"))
 			},
 			expectedError: errors.New("delete failed"),
@@ -309,7 +349,59 @@ func TestAuthorStore_Delete(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			err = s.Delete(testCase.id)
+			err = s.Delete(context.Background(), testCase.id)
+			assert.Equal(t, testCase.expectedError, err)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthorStore_Restore(t *testing.T) {
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			description: "author restored",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE authors SET deleted_at = NULL WHERE id = \\$1 AND deleted_at IS NOT NULL").
+					WithArgs("b7eb3c06-6df8-4353-90f5-7ab897a77158").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description: "no deleted author",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE authors SET deleted_at = NULL").
+					WithArgs("b7eb3c06-6df8-4353-90f5-7ab897a77158").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE authors SET deleted_at = NULL").
+					WillReturnError(errors.New("restore failed"))
+			},
+			expectedError: errors.New("restore failed"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewAuthorStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			err = s.Restore(context.Background(), "b7eb3c06-6df8-4353-90f5-7ab897a77158")
 			assert.Equal(t, testCase.expectedError, err)
 
 			err = mock.ExpectationsWereMet()
@@ -333,7 +425,7 @@ func TestAuthorStore_GetAuthorBooks(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"title"}).
 					AddRow("title 1").
 					AddRow("title 2")
-				mock.ExpectQuery("SELECT title FROM books WHERE authors_id = \\$1").
+				mock.ExpectQuery("SELECT title FROM books WHERE authors_id = \\$1 AND deleted_at IS NULL").
 					WithArgs("b7eb3c06-6df8-4353-90f5-7ab897a77158").
 					WillReturnRows(rows)
 			},
@@ -343,7 +435,7 @@ func TestAuthorStore_GetAuthorBooks(t *testing.T) {
 		{
 			description: "error db",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT title FROM books WHERE authors_id = \\$1").
+				mock.ExpectQuery("SELECT title FROM books WHERE authors_id = \\$1 AND deleted_at IS NULL").
 					WillReturnError(errors.New("select for get for authors books failed"))
 			},
 			expectedError: errors.New("select for get for authors books failed"),
@@ -353,7 +445,7 @@ func TestAuthorStore_GetAuthorBooks(t *testing.T) {
 			setupMock: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{"1st row", "second row"}).
 					AddRow("hello", "world")
-				mock.ExpectQuery("SELECT title FROM books WHERE authors_id = \\$1").
+				mock.ExpectQuery("SELECT title FROM books WHERE authors_id = \\$1 AND deleted_at IS NULL").
 					WillReturnRows(rows)
 			},
 			expectedError: errors.New("sql: expected 2 destination arguments in Scan, not 1"),
@@ -370,7 +462,7 @@ func TestAuthorStore_GetAuthorBooks(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			res, err := s.GetAuthorsBooks(testCase.authorId)
+			res, err := s.GetAuthorsBooks(context.Background(), testCase.authorId)
 			assert.Equal(t, testCase.expectedError, err)
 			assert.Equal(t, testCase.expectedBody, res)
 
@@ -1,26 +1,41 @@
 package store
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"library-api/internal/model"
+	"library-api/pkg/tracing"
+
+	"github.com/lib/pq"
 )
 
-func (b *BookStore) Create(book *model.Book) error {
-	_, err := b.db.Exec(`INSERT INTO books (id, authors_id, title, genre, isbn) 
-								VALUES ($1, $2, $3, $4, $5)`,
-		&book.ID, &book.AuthorsID, &book.Title, &book.Genre, &book.ISBN)
+// Create inserts the book and replaces it with the stored row. A book of a
+// deleted author is reported as model.ErrNotFound.
+func (b *BookStore) Create(ctx context.Context, book *model.Book) error {
+	err := conn(ctx, b.db).QueryRowContext(ctx, `INSERT INTO books (id, authors_id, title, genre, isbn)
+								SELECT $1, $2, $3, $4, $5
+								WHERE NOT EXISTS (SELECT 1 FROM authors WHERE id = $2 AND deleted_at IS NOT NULL)
+								RETURNING id, authors_id, title, genre, isbn, version`,
+		&book.ID, &book.AuthorsID, &book.Title, &book.Genre, &book.ISBN).
+		Scan(&book.ID, &book.AuthorsID, &book.Title, &book.Genre, &book.ISBN, &book.Version)
 	if err != nil {
-		b.logger.Error("failed to create book", "error", err.Error())
+		if errors.Is(err, sql.ErrNoRows) {
+			tracing.Logger(ctx, b.logger).Info("author of book is deleted", "authors_id", book.AuthorsID)
+			return model.ErrNotFound
+		}
+
+		tracing.Logger(ctx, b.logger).Error("failed to create book", "error", err.Error())
 		return err
 	}
 
 	return nil
 }
 
-func (b *BookStore) Get() ([]model.Book, error) {
-	rows, err := b.db.Query(`SELECT * FROM books`)
+func (b *BookStore) Get(ctx context.Context) ([]model.Book, error) {
+	rows, err := conn(ctx, b.db).QueryContext(ctx, `SELECT id, authors_id, title, genre, isbn, version FROM books WHERE deleted_at IS NULL`)
 	if err != nil {
-		b.logger.Error("failed to execute query for get books", "error", err.Error())
+		tracing.Logger(ctx, b.logger).Error("failed to execute query for get books", "error", err.Error())
 		return nil, err
 	}
 	defer rows.Close()
@@ -28,9 +43,9 @@ func (b *BookStore) Get() ([]model.Book, error) {
 	var books []model.Book
 	for rows.Next() {
 		var book model.Book
-		err = rows.Scan(&book.ID, &book.AuthorsID, &book.Title, &book.Genre, &book.ISBN)
+		err = rows.Scan(&book.ID, &book.AuthorsID, &book.Title, &book.Genre, &book.ISBN, &book.Version)
 		if err != nil {
-			b.logger.Error("scanning selected failed for books", "error", err.Error())
+			tracing.Logger(ctx, b.logger).Error("scanning selected failed for books", "error", err.Error())
 			return nil, err
 		}
 
@@ -40,46 +55,130 @@ func (b *BookStore) Get() ([]model.Book, error) {
 	return books, nil
 }
 
-func (b *BookStore) Exists(id string) error {
-	rows, err := b.db.Query(`SELECT EXISTS (SELECT 1 FROM books WHERE id = $1)`, id)
+// Update applies the change only if the book is still at book.Version and
+// replaces book with the stored row. Moving a book to a deleted author is
+// reported as model.ErrNotFound.
+func (b *BookStore) Update(ctx context.Context, id string, book *model.Book) error {
+	updated := model.Book{ID: id}
+	var authorDeleted bool
+	var version sql.NullInt64
+	err := conn(ctx, b.db).QueryRowContext(ctx, `WITH author AS (
+									SELECT EXISTS (SELECT 1 FROM authors WHERE id = $1 AND deleted_at IS NOT NULL) AS deleted),
+								updated AS (
+									UPDATE books SET authors_id = $1, title = $2, genre = $3, ISBN = $4 FROM author
+									WHERE books.id = $5 AND books.deleted_at IS NULL AND books.version = $6 AND NOT author.deleted
+									RETURNING books.authors_id, books.title, books.genre, books.isbn, books.version)
+								SELECT author.deleted, COALESCE(updated.authors_id::text, ''), COALESCE(updated.title, ''),
+									COALESCE(updated.genre, ''), COALESCE(updated.isbn, ''), updated.version
+								FROM author LEFT JOIN updated ON true`,
+		&book.AuthorsID, &book.Title, &book.Genre, &book.ISBN, id, book.Version).
+		Scan(&authorDeleted, &updated.AuthorsID, &updated.Title, &updated.Genre, &updated.ISBN, &version)
 	if err != nil {
-		b.logger.Info("id doesn't exists in books", "id", id, "info", err.Error())
+		tracing.Logger(ctx, b.logger).Error("update failed for book", "id", id, "error", err.Error())
 		return err
 	}
 
-	for rows.Next() {
-		var exists bool
-		err = rows.Scan(&exists)
-		if err != nil {
-			b.logger.Info("scan error", "id", id, "error", err.Error())
-			return err
-		}
+	if authorDeleted {
+		tracing.Logger(ctx, b.logger).Info("author of book is deleted", "id", id, "authors_id", book.AuthorsID)
+		return model.ErrNotFound
+	}
 
-		if !exists {
-			b.logger.Info("book does not exist", "id", id)
-			return errors.New("book does not exist")
-		}
+	if !version.Valid {
+		tracing.Logger(ctx, b.logger).Info("book version conflict", "id", id, "version", book.Version)
+		return model.ErrVersionConflict
 	}
 
+	updated.Version = int(version.Int64)
+	*book = updated
 	return nil
 }
 
-func (b *BookStore) Update(id string, book *model.Book) error {
-	_, err := b.db.Exec(`UPDATE books SET authors_id = $1, title = $2, genre = $3, ISBN = $4 WHERE id = $5`,
-		&book.AuthorsID, &book.Title, &book.Genre, &book.ISBN, id)
+// Delete marks the book as deleted. Books that are currently borrowed are
+// left untouched and reported as referenced.
+func (b *BookStore) Delete(ctx context.Context, id string) error {
+	err := softDelete(conn(ctx, b.db).QueryRowContext(ctx, `WITH target AS (
+									SELECT id, EXISTS (SELECT 1 FROM borrowed_books WHERE borrowed_books.book_id = books.id) AS referenced
+									FROM books WHERE id = $1 AND deleted_at IS NULL FOR UPDATE),
+								deleted AS (
+									UPDATE books SET deleted_at = now() FROM target
+									WHERE books.id = target.id AND NOT target.referenced)
+								SELECT referenced FROM target`, id))
+	if err != nil && !errors.Is(err, model.ErrNotFound) && !errors.Is(err, model.ErrReferenced) {
+		tracing.Logger(ctx, b.logger).Error("delete failed for books", "id", id, "error", err.Error())
+	}
+
+	return err
+}
+
+// Restore brings back a deleted book. A book whose author is still deleted is
+// left untouched and reported with model.ErrParentDeleted.
+func (b *BookStore) Restore(ctx context.Context, id string) error {
+	var orphaned bool
+	err := conn(ctx, b.db).QueryRowContext(ctx, `WITH target AS (
+									SELECT id, EXISTS (SELECT 1 FROM authors WHERE authors.id = books.authors_id AND authors.deleted_at IS NOT NULL) AS orphaned
+									FROM books WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE),
+								restored AS (
+									UPDATE books SET deleted_at = NULL FROM target
+									WHERE books.id = target.id AND NOT target.orphaned)
+								SELECT orphaned FROM target`, id).Scan(&orphaned)
 	if err != nil {
-		b.logger.Error("update failed for book", "id", id, "error", err.Error())
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrNotFound
+		}
+
+		tracing.Logger(ctx, b.logger).Error("restore failed for books", "id", id, "error", err.Error())
 		return err
 	}
 
+	if orphaned {
+		return model.ErrParentDeleted
+	}
+
 	return nil
 }
 
-func (b *BookStore) Delete(id string) error {
-	_, err := b.db.Exec(`DELETE FROM books WHERE ID = $1`, id)
+const selectBooksWithAuthors = `SELECT books.id, books.authors_id, books.title, books.genre, books.isbn,
+									authors.full_name, COALESCE(authors.nick_name, ''), COALESCE(authors.specialization, ''),
+									EXISTS (SELECT 1 FROM borrowed_books WHERE borrowed_books.book_id = books.id), books.version
+									FROM books
+									LEFT JOIN authors ON authors.id = books.authors_id AND authors.deleted_at IS NULL`
+
+func (b *BookStore) GetByIDs(ctx context.Context, ids []string) ([]model.Book, error) {
+	rows, err := conn(ctx, b.db).QueryContext(ctx, selectBooksWithAuthors+` WHERE books.deleted_at IS NULL AND books.id = ANY($1)`, pq.Array(ids))
 	if err != nil {
-		b.logger.Error("delete failed for books", "id", id, "error", err.Error())
-		return err
+		tracing.Logger(ctx, b.logger).Error("failed to execute query for get books by ids", "error", err.Error())
+		return nil, err
 	}
-	return nil
+	defer rows.Close()
+
+	return b.scanBooksWithAuthors(ctx, rows)
+}
+
+func (b *BookStore) GetCatalog(ctx context.Context) ([]model.Book, error) {
+	rows, err := conn(ctx, b.db).QueryContext(ctx, selectBooksWithAuthors+` WHERE books.deleted_at IS NULL`)
+	if err != nil {
+		tracing.Logger(ctx, b.logger).Error("failed to execute query for get catalog", "error", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	return b.scanBooksWithAuthors(ctx, rows)
+}
+
+func (b *BookStore) scanBooksWithAuthors(ctx context.Context, rows *sql.Rows) ([]model.Book, error) {
+	var books []model.Book
+	for rows.Next() {
+		var book model.Book
+		err := rows.Scan(&book.ID, &book.AuthorsID, &book.Title, &book.Genre, &book.ISBN,
+			&book.Author.FullName, &book.Author.NickName, &book.Author.Specialization, &book.Borrowed, &book.Version)
+		if err != nil {
+			tracing.Logger(ctx, b.logger).Error("scanning selected failed for books with authors", "error", err.Error())
+			return nil, err
+		}
+
+		book.Author.ID = book.AuthorsID
+		books = append(books, book)
+	}
+
+	return books, nil
 }
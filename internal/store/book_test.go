@@ -1,21 +1,25 @@
 package store
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"library-api/internal/model"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/hashicorp/go-hclog"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestBookStore_Create(t *testing.T) {
 	testCases := []struct {
-		description   string
-		book          model.Book
-		setupMock     func(mock sqlmock.Sqlmock)
-		expectedError error
+		description     string
+		book            model.Book
+		setupMock       func(mock sqlmock.Sqlmock)
+		expectedVersion int
+		expectedError   error
 	}{
 		{
 			description: "create book successfully",
@@ -28,16 +32,33 @@ func TestBookStore_Create(t *testing.T) {
 			},
 			expectedError: nil,
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec(`INSERT INTO books`).
+				mock.ExpectQuery(`INSERT INTO books (.+) RETURNING id, authors_id, title, genre, isbn, version`).
 					WithArgs("0eabf8fc-1867-48c4-b835-271db2be1f2e", "ce99cad9-9d1c-4e8c-a306-e51d7022926e", "Desert Stars", "IT", "978-1-00001-000-1").
-					WillReturnResult(sqlmock.NewResult(1, 1))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "authors_id", "title", "genre", "isbn", "version"}).
+						AddRow("0eabf8fc-1867-48c4-b835-271db2be1f2e", "ce99cad9-9d1c-4e8c-a306-e51d7022926e", "Desert Stars", "IT", "978-1-00001-000-1", 1))
+			},
+			expectedVersion: 1,
+		},
+		{
+			description: "author deleted",
+			book: model.Book{
+				ID:        "0eabf8fc-1867-48c4-b835-271db2be1f2e",
+				AuthorsID: "ce99cad9-9d1c-4e8c-a306-e51d7022926e",
+				Title:     "Desert Stars",
+				Genre:     "IT",
+				ISBN:      "978-1-00001-000-1",
+			},
+			expectedError: model.ErrNotFound,
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`INSERT INTO books (.+) WHERE NOT EXISTS \(SELECT 1 FROM authors WHERE id = \$2 AND deleted_at IS NOT NULL\)`).
+					WillReturnError(sql.ErrNoRows)
 			},
 		},
 		{
 			description:   "error db",
 			expectedError: errors.New("error"),
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec(`INSERT INTO books`).
+				mock.ExpectQuery(`INSERT INTO books`).
 					WillReturnError(errors.New("error"))
 			},
 		},
@@ -53,8 +74,9 @@ func TestBookStore_Create(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			err = s.Create(&testCase.book)
+			err = s.Create(context.Background(), &testCase.book)
 			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedVersion, testCase.book.Version)
 
 			err = mock.ExpectationsWereMet()
 			assert.NoError(t, err)
@@ -63,7 +85,7 @@ func TestBookStore_Create(t *testing.T) {
 }
 
 func TestNewBookStore_Get(t *testing.T) {
-	columns := []string{"id", "authors_id", "title", "genre", "isbn"}
+	columns := []string{"id", "authors_id", "title", "genre", "isbn", "version"}
 	testCases := []struct {
 		description   string
 		setupMock     func(mock sqlmock.Sqlmock)
@@ -74,10 +96,10 @@ func TestNewBookStore_Get(t *testing.T) {
 			description: "book store created successfully",
 			setupMock: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows(columns).
-					AddRow("0eabf8fc-1867-48c4-b835-271db2be1f2e", "ce99cad9-9d1c-4e8c-a306-e51d7022926e", "Desert Stars", "IT", "978-1-00001-000-1").
-					AddRow("11f76f2b-9aa1-483c-91e4-3312b931e437", "ed6a7278-97a8-4382-847d-a4a0b02bca86", "Fictional Truths", "Fiction", "978-1-00002-000-1")
+					AddRow("0eabf8fc-1867-48c4-b835-271db2be1f2e", "ce99cad9-9d1c-4e8c-a306-e51d7022926e", "Desert Stars", "IT", "978-1-00001-000-1", 1).
+					AddRow("11f76f2b-9aa1-483c-91e4-3312b931e437", "ed6a7278-97a8-4382-847d-a4a0b02bca86", "Fictional Truths", "Fiction", "978-1-00002-000-1", 4)
 
-				mock.ExpectQuery("SELECT \\* FROM books").
+				mock.ExpectQuery("SELECT id, authors_id, title, genre, isbn, version FROM books WHERE deleted_at IS NULL").
 					WillReturnRows(rows)
 			},
 			expectedBody: []model.Book{
@@ -87,6 +109,7 @@ func TestNewBookStore_Get(t *testing.T) {
 					Title:     "Desert Stars",
 					Genre:     "IT",
 					ISBN:      "978-1-00001-000-1",
+					Version:   1,
 				},
 				{
 					ID:        "11f76f2b-9aa1-483c-91e4-3312b931e437",
@@ -94,13 +117,14 @@ func TestNewBookStore_Get(t *testing.T) {
 					Title:     "Fictional Truths",
 					Genre:     "Fiction",
 					ISBN:      "978-1-00002-000-1",
+					Version:   4,
 				},
 			},
 		},
 		{
 			description: "error db",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT \\* FROM books").
+				mock.ExpectQuery("SELECT id, authors_id, title, genre, isbn, version FROM books WHERE deleted_at IS NULL").
 					WillReturnError(errors.New("error"))
 			},
 			expectedError: errors.New("error"),
@@ -111,10 +135,10 @@ func TestNewBookStore_Get(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"only one row"}).
 					AddRow("hello")
 
-				mock.ExpectQuery("SELECT \\* FROM books").
+				mock.ExpectQuery("SELECT id, authors_id, title, genre, isbn, version FROM books WHERE deleted_at IS NULL").
 					WillReturnRows(rows)
 			},
-			expectedError: errors.New("sql: expected 1 destination arguments in Scan, not 5"),
+			expectedError: errors.New("sql: expected 1 destination arguments in Scan, not 6"),
 		},
 	}
 
@@ -128,7 +152,7 @@ func TestNewBookStore_Get(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			body, err := s.Get()
+			body, err := s.Get(context.Background())
 			assert.Equal(t, testCase.expectedError, err)
 
 			assert.Equal(t, testCase.expectedBody, body)
@@ -139,57 +163,139 @@ func TestNewBookStore_Get(t *testing.T) {
 	}
 }
 
-func TestBookStore_Exists(t *testing.T) {
+func TestBookStore_Update(t *testing.T) {
+	updatedBookColumns := []string{"deleted", "authors_id", "title", "genre", "isbn", "version"}
 	testCases := []struct {
 		description   string
 		id            string
+		body          model.Book
 		setupMock     func(mock sqlmock.Sqlmock)
+		expectedBody  model.Book
 		expectedError error
 	}{
 		{
-			description: "delete book successfully",
+			description: "update book sucsessfully",
+			id:          "0eabf8fc-1867-48c4-b835-271db2be1f2e",
+			body: model.Book{
+				ID:        "0eabf8fc-1867-48c4-b835-271db2be1f2e",
+				AuthorsID: "ce99cad9-9d1c-4e8c-a306-e51d7022926e",
+				Title:     "Desert Stars",
+				Genre:     "IT",
+				ISBN:      "978-1-00001-000-1",
+				Version:   1,
+			},
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("UPDATE books (.+) WHERE books.id = \\$5 AND books.deleted_at IS NULL AND books.version = \\$6").
+					WithArgs("ce99cad9-9d1c-4e8c-a306-e51d7022926e", "Desert Stars", "IT", "978-1-00001-000-1", "0eabf8fc-1867-48c4-b835-271db2be1f2e", 1).
+					WillReturnRows(sqlmock.NewRows(updatedBookColumns).
+						AddRow(false, "ce99cad9-9d1c-4e8c-a306-e51d7022926e", "Desert Stars", "IT", "978-1-00001-000-1", 2))
+			},
+			expectedBody: model.Book{
+				ID:        "0eabf8fc-1867-48c4-b835-271db2be1f2e",
+				AuthorsID: "ce99cad9-9d1c-4e8c-a306-e51d7022926e",
+				Title:     "Desert Stars",
+				Genre:     "IT",
+				ISBN:      "978-1-00001-000-1",
+				Version:   2,
+			},
+		},
+		{
+			description: "version conflict",
+			id:          "0eabf8fc-1867-48c4-b835-271db2be1f2e",
+			body:        model.Book{AuthorsID: "ce99cad9-9d1c-4e8c-a306-e51d7022926e", Version: 1},
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("UPDATE books").
+					WillReturnRows(sqlmock.NewRows(updatedBookColumns).AddRow(false, "", "", "", "", nil))
+			},
+			expectedBody:  model.Book{AuthorsID: "ce99cad9-9d1c-4e8c-a306-e51d7022926e", Version: 1},
+			expectedError: model.ErrVersionConflict,
+		},
+		{
+			description: "author deleted",
 			id:          "0eabf8fc-1867-48c4-b835-271db2be1f2e",
+			body:        model.Book{AuthorsID: "ce99cad9-9d1c-4e8c-a306-e51d7022926e", Version: 1},
 			setupMock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"exists"}).
-					AddRow(true)
+				mock.ExpectQuery("UPDATE books").
+					WillReturnRows(sqlmock.NewRows(updatedBookColumns).AddRow(true, "", "", "", "", nil))
+			},
+			expectedBody:  model.Book{AuthorsID: "ce99cad9-9d1c-4e8c-a306-e51d7022926e", Version: 1},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("UPDATE books").
+					WillReturnError(errors.New("update failed"))
+			},
+			expectedError: errors.New("update failed"),
+		},
+	}
 
-				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM books WHERE id = \\$1\\)").
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewBookStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			err = s.Update(context.Background(), testCase.id, &testCase.body)
+			assert.Equal(t, testCase.expectedError, err)
+
+			assert.Equal(t, testCase.expectedBody, testCase.body)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBookStore_Delete(t *testing.T) {
+	testCases := []struct {
+		description   string
+		id            string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			description: "book marked as deleted",
+			id:          "0eabf8fc-1867-48c4-b835-271db2be1f2e",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("WITH target AS (.+) FROM books WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE(.+) UPDATE books SET deleted_at = now\\(\\)").
 					WithArgs("0eabf8fc-1867-48c4-b835-271db2be1f2e").
-					WillReturnRows(rows)
+					WillReturnRows(sqlmock.NewRows([]string{"referenced"}).AddRow(false))
 			},
 		},
 		{
-			description: "error db",
+			description: "book still referenced",
 			id:          "0eabf8fc-1867-48c4-b835-271db2be1f2e",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM books WHERE id = \\$1\\)").
-					WillReturnError(errors.New("select error"))
+				mock.ExpectQuery("WITH target AS").
+					WithArgs("0eabf8fc-1867-48c4-b835-271db2be1f2e").
+					WillReturnRows(sqlmock.NewRows([]string{"referenced"}).AddRow(true))
 			},
-			expectedError: errors.New("select error"),
+			expectedError: model.ErrReferenced,
 		},
 		{
-			description: "scan error",
+			description: "book missing or already deleted",
 			id:          "0eabf8fc-1867-48c4-b835-271db2be1f2e",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"1st row", "second row"}).
-					AddRow("hello", "world")
-
-				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM books WHERE id = \\$1\\)").
-					WillReturnRows(rows)
+				mock.ExpectQuery("WITH target AS").
+					WithArgs("0eabf8fc-1867-48c4-b835-271db2be1f2e").
+					WillReturnRows(sqlmock.NewRows([]string{"referenced"}))
 			},
-			expectedError: errors.New("sql: expected 2 destination arguments in Scan, not 1"),
+			expectedError: model.ErrNotFound,
 		},
 		{
-			description: "book doesn't exist",
+			description: "error db",
 			id:          "0eabf8fc-1867-48c4-b835-271db2be1f2e",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"exists"}).
-					AddRow(false)
-
-				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM books WHERE id = \\$1\\)").
-					WillReturnRows(rows)
+				mock.ExpectQuery("WITH target AS").
+					WillReturnError(errors.New("delete failed"))
 			},
-			expectedError: errors.New("book does not exist"),
+			expectedError: errors.New("delete failed"),
 		},
 	}
 
@@ -203,7 +309,7 @@ func TestBookStore_Exists(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			err = s.Exists(testCase.id)
+			err = s.Delete(context.Background(), testCase.id)
 			assert.Equal(t, testCase.expectedError, err)
 
 			err = mock.ExpectationsWereMet()
@@ -212,45 +318,45 @@ func TestBookStore_Exists(t *testing.T) {
 	}
 }
 
-func TestBookStore_Update(t *testing.T) {
+func TestBookStore_Restore(t *testing.T) {
 	testCases := []struct {
 		description   string
-		id            string
-		body          model.Book
 		setupMock     func(mock sqlmock.Sqlmock)
-		expectedBody  model.Book
 		expectedError error
 	}{
 		{
-			description: "update book sucsessfully",
-			id:          "0eabf8fc-1867-48c4-b835-271db2be1f2e",
-			body: model.Book{
-				ID:        "0eabf8fc-1867-48c4-b835-271db2be1f2e",
-				AuthorsID: "ce99cad9-9d1c-4e8c-a306-e51d7022926e",
-				Title:     "Desert Stars",
-				Genre:     "IT",
-				ISBN:      "978-1-00001-000-1",
+			description: "book restored",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("WITH target AS (.+) WHERE id = \\$1 AND deleted_at IS NOT NULL (.+) UPDATE books SET deleted_at = NULL").
+					WithArgs("0eabf8fc-1867-48c4-b835-271db2be1f2e").
+					WillReturnRows(sqlmock.NewRows([]string{"orphaned"}).AddRow(false))
 			},
+		},
+		{
+			description: "author of the book is deleted",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("UPDATE books").
-					WithArgs("ce99cad9-9d1c-4e8c-a306-e51d7022926e", "Desert Stars", "IT", "978-1-00001-000-1", "0eabf8fc-1867-48c4-b835-271db2be1f2e").
-					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery("WITH target AS (.+) authors.deleted_at IS NOT NULL").
+					WithArgs("0eabf8fc-1867-48c4-b835-271db2be1f2e").
+					WillReturnRows(sqlmock.NewRows([]string{"orphaned"}).AddRow(true))
 			},
-			expectedBody: model.Book{
-				ID:        "0eabf8fc-1867-48c4-b835-271db2be1f2e",
-				AuthorsID: "ce99cad9-9d1c-4e8c-a306-e51d7022926e",
-				Title:     "Desert Stars",
-				Genre:     "IT",
-				ISBN:      "978-1-00001-000-1",
+			expectedError: model.ErrParentDeleted,
+		},
+		{
+			description: "no deleted book",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("WITH target AS").
+					WithArgs("0eabf8fc-1867-48c4-b835-271db2be1f2e").
+					WillReturnRows(sqlmock.NewRows([]string{"orphaned"}))
 			},
+			expectedError: model.ErrNotFound,
 		},
 		{
 			description: "error db",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("UPDATE books").
-					WillReturnError(errors.New("update failed"))
+				mock.ExpectQuery("WITH target AS").
+					WillReturnError(errors.New("restore failed"))
 			},
-			expectedError: errors.New("update failed"),
+			expectedError: errors.New("restore failed"),
 		},
 	}
 
@@ -264,10 +370,90 @@ func TestBookStore_Update(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			err = s.Update(testCase.id, &testCase.body)
+			err = s.Restore(context.Background(), "0eabf8fc-1867-48c4-b835-271db2be1f2e")
 			assert.Equal(t, testCase.expectedError, err)
 
-			assert.Equal(t, testCase.expectedBody, testCase.body)
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBookStore_GetByIDs(t *testing.T) {
+	columns := []string{"id", "authors_id", "title", "genre", "isbn", "full_name", "nick_name", "specialization", "borrowed", "version"}
+	authorFullName := "Alice Johnson"
+	ids := []string{"0eabf8fc-1867-48c4-b835-271db2be1f2e"}
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedBody  []model.Book
+		expectedError error
+	}{
+		{
+			description: "books with authors selected successfully",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow("0eabf8fc-1867-48c4-b835-271db2be1f2e", "ce99cad9-9d1c-4e8c-a306-e51d7022926e", "Desert Stars", "IT", "978-1-00001-000-1", authorFullName, "Ali", "IT", true, 2)
+
+				mock.ExpectQuery("SELECT books.id, books.authors_id, books.title(.+)LEFT JOIN authors ON authors.id = books.authors_id AND authors.deleted_at IS NULL").
+					WithArgs(pq.Array(ids)).
+					WillReturnRows(rows)
+			},
+			expectedBody: []model.Book{
+				{
+					ID:        "0eabf8fc-1867-48c4-b835-271db2be1f2e",
+					AuthorsID: "ce99cad9-9d1c-4e8c-a306-e51d7022926e",
+					Title:     "Desert Stars",
+					Genre:     "IT",
+					ISBN:      "978-1-00001-000-1",
+					Version:   2,
+					Author: model.Author{
+						ID:             "ce99cad9-9d1c-4e8c-a306-e51d7022926e",
+						FullName:       &authorFullName,
+						NickName:       "Ali",
+						Specialization: "IT",
+					},
+					Borrowed: true,
+				},
+			},
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT books.id, books.authors_id, books.title").
+					WithArgs(pq.Array(ids)).
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+		{
+			description: "scan error",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"only one row"}).
+					AddRow("hello")
+
+				mock.ExpectQuery("SELECT books.id, books.authors_id, books.title").
+					WithArgs(pq.Array(ids)).
+					WillReturnRows(rows)
+			},
+			expectedError: errors.New("sql: expected 1 destination arguments in Scan, not 10"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewBookStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			body, err := s.GetByIDs(context.Background(), ids)
+			assert.Equal(t, testCase.expectedError, err)
+
+			assert.Equal(t, testCase.expectedBody, body)
 
 			err = mock.ExpectationsWereMet()
 			assert.NoError(t, err)
@@ -275,29 +461,48 @@ func TestBookStore_Update(t *testing.T) {
 	}
 }
 
-func TestBookStore_Delete(t *testing.T) {
+func TestBookStore_GetCatalog(t *testing.T) {
+	columns := []string{"id", "authors_id", "title", "genre", "isbn", "full_name", "nick_name", "specialization", "borrowed", "version"}
+	authorFullName := "Alice Johnson"
 	testCases := []struct {
 		description   string
-		id            string
 		setupMock     func(mock sqlmock.Sqlmock)
+		expectedBody  []model.Book
 		expectedError error
 	}{
 		{
-			description: "delete book successfully",
-			id:          "0eabf8fc-1867-48c4-b835-271db2be1f2e",
+			description: "catalog selected successfully",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("DELETE FROM books WHERE ID = \\$1").
-					WithArgs("0eabf8fc-1867-48c4-b835-271db2be1f2e").
-					WillReturnResult(sqlmock.NewResult(1, 1))
+				rows := sqlmock.NewRows(columns).
+					AddRow("0eabf8fc-1867-48c4-b835-271db2be1f2e", "ce99cad9-9d1c-4e8c-a306-e51d7022926e", "Desert Stars", "IT", "978-1-00001-000-1", authorFullName, "Ali", "IT", false, 2)
+
+				mock.ExpectQuery("SELECT books.id, books.authors_id, books.title").
+					WillReturnRows(rows)
+			},
+			expectedBody: []model.Book{
+				{
+					ID:        "0eabf8fc-1867-48c4-b835-271db2be1f2e",
+					AuthorsID: "ce99cad9-9d1c-4e8c-a306-e51d7022926e",
+					Title:     "Desert Stars",
+					Genre:     "IT",
+					ISBN:      "978-1-00001-000-1",
+					Version:   2,
+					Author: model.Author{
+						ID:             "ce99cad9-9d1c-4e8c-a306-e51d7022926e",
+						FullName:       &authorFullName,
+						NickName:       "Ali",
+						Specialization: "IT",
+					},
+				},
 			},
 		},
 		{
 			description: "error db",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("DELETE FROM books").
-					WillReturnError(errors.New("delete failed"))
+				mock.ExpectQuery("SELECT books.id, books.authors_id, books.title").
+					WillReturnError(errors.New("error"))
 			},
-			expectedError: errors.New("delete failed"),
+			expectedError: errors.New("error"),
 		},
 	}
 
@@ -311,9 +516,11 @@ func TestBookStore_Delete(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			err = s.Delete(testCase.id)
+			body, err := s.GetCatalog(context.Background())
 			assert.Equal(t, testCase.expectedError, err)
 
+			assert.Equal(t, testCase.expectedBody, body)
+
 			err = mock.ExpectationsWereMet()
 			assert.NoError(t, err)
 		})
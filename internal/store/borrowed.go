@@ -1,16 +1,27 @@
 package store
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"library-api/internal/model"
+	"library-api/pkg/tracing"
 
 	"github.com/lib/pq"
 )
 
-func (b *BorrowedStore) Create(book *model.Borrowed) error {
-	_, err := b.db.Exec(`INSERT INTO borrowed_books (member_id, book_id) VALUES ($1, $2)`,
-		&book.MemberID, &book.BookID)
+func (b *BorrowedStore) Create(ctx context.Context, book *model.Borrowed) error {
+	err := conn(ctx, b.db).QueryRowContext(ctx, `INSERT INTO borrowed_books (member_id, book_id)
+								SELECT $1, $2 WHERE EXISTS (SELECT 1 FROM books WHERE id = $2 AND deleted_at IS NULL)
+								RETURNING member_id, book_id`,
+		&book.MemberID, &book.BookID).Scan(&book.MemberID, &book.BookID)
 	if err != nil {
-		b.logger.Error("failed to create book",
+		if errors.Is(err, sql.ErrNoRows) {
+			tracing.Logger(ctx, b.logger).Info("book to borrow does not exist", "book_id", book.BookID)
+			return model.ErrNotFound
+		}
+
+		tracing.Logger(ctx, b.logger).Error("failed to create book",
 			"member_id", book.MemberID,
 			"book_id", book.BookID,
 			"error", err.Error())
@@ -20,14 +31,14 @@ func (b *BorrowedStore) Create(book *model.Borrowed) error {
 	return nil
 }
 
-func (b *BorrowedStore) Get(id string) ([]model.Book, error) {
-	rows, err := b.db.Query(`SELECT books.title, authors.full_name, books.genre, books.isbn
+func (b *BorrowedStore) Get(ctx context.Context, id string) ([]model.Book, error) {
+	rows, err := conn(ctx, b.db).QueryContext(ctx, `SELECT books.title, authors.full_name, books.genre, books.isbn
 									FROM books, authors, borrowed_books
 									WHERE (authors.id = books.authors_id 
 									           AND books.id = borrowed_books.book_id 
 									           AND borrowed_books.member_id = $1)`, id)
 	if err != nil {
-		b.logger.Error("get books failed for member",
+		tracing.Logger(ctx, b.logger).Error("get books failed for member",
 			"id", id,
 			"error", err.Error())
 		return nil, err
@@ -41,7 +52,7 @@ func (b *BorrowedStore) Get(id string) ([]model.Book, error) {
 
 		err = rows.Scan(&book.Title, &authorFullName, &book.Genre, &book.ISBN)
 		if err != nil {
-			b.logger.Error("scanning selected failed for books of member",
+			tracing.Logger(ctx, b.logger).Error("scanning selected failed for books of member",
 				"id", id,
 				"error", err.Error())
 			return nil, err
@@ -57,11 +68,18 @@ func (b *BorrowedStore) Get(id string) ([]model.Book, error) {
 	return books, nil
 }
 
-func (b *BorrowedStore) Delete(memberId string, bookId string) error {
-	_, err := b.db.Exec(`DELETE FROM borrowed_books WHERE member_id = $1 AND book_id = $2`,
-		memberId, bookId)
+// Delete ends a loan. It returns model.ErrNotFound when the member does not
+// have the book on loan.
+func (b *BorrowedStore) Delete(ctx context.Context, memberId string, bookId string) error {
+	err := conn(ctx, b.db).QueryRowContext(ctx, `DELETE FROM borrowed_books WHERE member_id = $1 AND book_id = $2
+								RETURNING book_id`,
+		memberId, bookId).Scan(&bookId)
 	if err != nil {
-		b.logger.Error("delete book failed for member",
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrNotFound
+		}
+
+		tracing.Logger(ctx, b.logger).Error("delete book failed for member",
 			"member_id", memberId,
 			"book_id", bookId,
 			"error", err.Error())
@@ -71,14 +89,67 @@ func (b *BorrowedStore) Delete(memberId string, bookId string) error {
 	return nil
 }
 
-func (b *BorrowedStore) DeleteList(id string, books []string) error {
-	_, err := b.db.Exec(`DELETE FROM borrowed_books WHERE (member_id = $1 AND book_id = ANY($2))`,
+// DeleteList ends the loans of the given books and returns the ids of the
+// books that were actually on loan to the member.
+func (b *BorrowedStore) DeleteList(ctx context.Context, id string, books []string) ([]string, error) {
+	rows, err := conn(ctx, b.db).QueryContext(ctx, `DELETE FROM borrowed_books WHERE (member_id = $1 AND book_id = ANY($2))
+								RETURNING book_id`,
 		id, pq.Array(books))
 	if err != nil {
-		b.logger.Error("delete list of books failed for member",
+		tracing.Logger(ctx, b.logger).Error("delete list of books failed for member",
 			"member_id", id,
 			"error", err.Error())
-		return err
+		return nil, err
 	}
-	return nil
+	defer rows.Close()
+
+	deleted := []string{}
+	for rows.Next() {
+		var bookId string
+		err = rows.Scan(&bookId)
+		if err != nil {
+			tracing.Logger(ctx, b.logger).Error("scanning deleted books failed for member",
+				"member_id", id,
+				"error", err.Error())
+			return nil, err
+		}
+
+		deleted = append(deleted, bookId)
+	}
+
+	return deleted, rows.Err()
+}
+
+// MemberStatus reports the status that applies today, so loans are refused
+// as soon as a membership lapses even before the scheduled sweep runs.
+func (b *BorrowedStore) MemberStatus(ctx context.Context, memberId string) (string, error) {
+	var status string
+	err := conn(ctx, b.db).QueryRowContext(ctx, `SELECT CASE
+									WHEN status = 'suspended' AND (suspended_until IS NULL OR suspended_until >= CURRENT_DATE) THEN 'suspended'
+									WHEN expires_at < CURRENT_DATE THEN 'expired'
+									ELSE 'active' END
+								FROM members WHERE id = $1 AND deleted_at IS NULL`, memberId).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", model.ErrNotFound
+		}
+
+		tracing.Logger(ctx, b.logger).Error("member status lookup failed", "member_id", memberId, "error", err.Error())
+		return "", err
+	}
+
+	return status, nil
+}
+
+// Count returns how many books a member has out.
+func (b *BorrowedStore) Count(ctx context.Context, memberId string) (int, error) {
+	var count int
+	err := conn(ctx, b.db).QueryRowContext(ctx, `SELECT count(*) FROM borrowed_books WHERE member_id = $1`,
+		memberId).Scan(&count)
+	if err != nil {
+		tracing.Logger(ctx, b.logger).Error("loan count failed for member", "member_id", memberId, "error", err.Error())
+		return 0, err
+	}
+
+	return count, nil
 }
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"library-api/internal/model"
 	"testing"
@@ -25,9 +27,10 @@ func TestBorrowedStore_Create(t *testing.T) {
 				BookID:   "0eabf8fc-1867-48c4-b835-271db2be1f2e",
 			},
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("INSERT INTO borrowed_books \\(member_id, book_id\\) VALUES \\(\\$1, \\$2\\)").
+				mock.ExpectQuery("INSERT INTO borrowed_books \\(member_id, book_id\\) SELECT \\$1, \\$2 WHERE EXISTS (.+) RETURNING member_id, book_id").
 					WithArgs("dd2346fc-51c3-420f-a37e-8273d65120ad", "0eabf8fc-1867-48c4-b835-271db2be1f2e").
-					WillReturnResult(sqlmock.NewResult(1, 1))
+					WillReturnRows(sqlmock.NewRows([]string{"member_id", "book_id"}).
+						AddRow("dd2346fc-51c3-420f-a37e-8273d65120ad", "0eabf8fc-1867-48c4-b835-271db2be1f2e"))
 			},
 		},
 		{
@@ -37,12 +40,25 @@ func TestBorrowedStore_Create(t *testing.T) {
 				BookID:   "0eabf8fc-1867-48c4-b835-271db2be1f2e",
 			},
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("INSERT INTO borrowed_books \\(member_id, book_id\\) VALUES \\(\\$1, \\$2\\)").
+				mock.ExpectQuery("INSERT INTO borrowed_books \\(member_id, book_id\\) SELECT \\$1, \\$2 WHERE EXISTS").
 					WithArgs("dd2346fc-51c3-420f-a37e-8273d65120ad", "0eabf8fc-1867-48c4-b835-271db2be1f2e").
 					WillReturnError(errors.New("insert request failed"))
 			},
 			expectedError: errors.New("insert request failed"),
 		},
+		{
+			description: "book deleted",
+			body: model.Borrowed{
+				MemberID: "dd2346fc-51c3-420f-a37e-8273d65120ad",
+				BookID:   "0eabf8fc-1867-48c4-b835-271db2be1f2e",
+			},
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO borrowed_books").
+					WithArgs("dd2346fc-51c3-420f-a37e-8273d65120ad", "0eabf8fc-1867-48c4-b835-271db2be1f2e").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedError: model.ErrNotFound,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -55,7 +71,7 @@ func TestBorrowedStore_Create(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			err = s.Create(&testCase.body)
+			err = s.Create(context.Background(), &testCase.body)
 			assert.Equal(t, testCase.expectedError, err)
 
 			err = mock.ExpectationsWereMet()
@@ -152,7 +168,7 @@ func TestNewBorrowedStore_Get(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			body, err := s.Get(testCase.memberId)
+			body, err := s.Get(context.Background(), testCase.memberId)
 			assert.Equal(t, testCase.expectedError, err)
 
 			assert.Equal(t, testCase.expectedBody, body)
@@ -176,17 +192,28 @@ func TestBorrowedStore_Delete(t *testing.T) {
 			memberId:    "dd2346fc-51c3-420f-a37e-8273d65120ad",
 			bookId:      "0eabf8fc-1867-48c4-b835-271db2be1f2e",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("DELETE FROM borrowed_books").
+				mock.ExpectQuery("DELETE FROM borrowed_books (.+) RETURNING book_id").
 					WithArgs("dd2346fc-51c3-420f-a37e-8273d65120ad", "0eabf8fc-1867-48c4-b835-271db2be1f2e").
-					WillReturnResult(sqlmock.NewResult(1, 1))
+					WillReturnRows(sqlmock.NewRows([]string{"book_id"}).AddRow("0eabf8fc-1867-48c4-b835-271db2be1f2e"))
 			},
 		},
+		{
+			description: "book not on loan",
+			memberId:    "dd2346fc-51c3-420f-a37e-8273d65120ad",
+			bookId:      "0eabf8fc-1867-48c4-b835-271db2be1f2e",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("DELETE FROM borrowed_books (.+) RETURNING book_id").
+					WithArgs("dd2346fc-51c3-420f-a37e-8273d65120ad", "0eabf8fc-1867-48c4-b835-271db2be1f2e").
+					WillReturnRows(sqlmock.NewRows([]string{"book_id"}))
+			},
+			expectedError: model.ErrNotFound,
+		},
 		{
 			description: "error db",
 			memberId:    "dd2346fc-51c3-420f-a37e-8273d65120ad",
 			bookId:      "0eabf8fc-1867-48c4-b835-271db2be1f2e",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("DELETE FROM borrowed_books").
+				mock.ExpectQuery("DELETE FROM borrowed_books (.+) RETURNING book_id").
 					WithArgs("dd2346fc-51c3-420f-a37e-8273d65120ad", "0eabf8fc-1867-48c4-b835-271db2be1f2e").
 					WillReturnError(errors.New("delete failed"))
 			},
@@ -204,7 +231,7 @@ func TestBorrowedStore_Delete(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			err = s.Delete(testCase.memberId, testCase.bookId)
+			err = s.Delete(context.Background(), testCase.memberId, testCase.bookId)
 			assert.Equal(t, testCase.expectedError, err)
 
 			err = mock.ExpectationsWereMet()
@@ -217,25 +244,27 @@ func TestBorrowedStore_DeleteList(t *testing.T) {
 	books := []string{"0eabf8fc-1867-48c4-b835-271db2be1f2e", "81790db6-a440-48e2-9951-d5fcf359fd7c"}
 
 	testCases := []struct {
-		description   string
-		memberId      string
-		setupMock     func(mock sqlmock.Sqlmock)
-		expectedError error
+		description     string
+		memberId        string
+		setupMock       func(mock sqlmock.Sqlmock)
+		expectedDeleted []string
+		expectedError   error
 	}{
 		{
-			description: "borrowed book deleted successfully",
+			description: "only books on loan are deleted",
 			memberId:    "dd2346fc-51c3-420f-a37e-8273d65120ad",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("DELETE FROM borrowed_books WHERE \\(member_id = \\$1 AND book_id = ANY\\(\\$2\\)\\)").
+				mock.ExpectQuery("DELETE FROM borrowed_books WHERE \\(member_id = \\$1 AND book_id = ANY\\(\\$2\\)\\) RETURNING book_id").
 					WithArgs("dd2346fc-51c3-420f-a37e-8273d65120ad", pq.Array(books)).
-					WillReturnResult(sqlmock.NewResult(1, 1))
+					WillReturnRows(sqlmock.NewRows([]string{"book_id"}).AddRow("81790db6-a440-48e2-9951-d5fcf359fd7c"))
 			},
+			expectedDeleted: []string{"81790db6-a440-48e2-9951-d5fcf359fd7c"},
 		},
 		{
 			description: "error db",
 			memberId:    "dd2346fc-51c3-420f-a37e-8273d65120ad",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("DELETE FROM borrowed_books WHERE \\(member_id = \\$1 AND book_id = ANY\\(\\$2\\)\\)").
+				mock.ExpectQuery("DELETE FROM borrowed_books WHERE \\(member_id = \\$1 AND book_id = ANY\\(\\$2\\)\\) RETURNING book_id").
 					WithArgs("dd2346fc-51c3-420f-a37e-8273d65120ad", pq.Array(books)).
 					WillReturnError(errors.New("delete failed"))
 			},
@@ -253,10 +282,113 @@ func TestBorrowedStore_DeleteList(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			err = s.DeleteList(testCase.memberId, books)
+			deleted, err := s.DeleteList(context.Background(), testCase.memberId, books)
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedDeleted, deleted)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBorrowedStore_MemberStatus(t *testing.T) {
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expected      string
+		expectedError error
+	}{
+		{
+			description: "member is active",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT CASE (.+) FROM members WHERE id = \\$1 AND deleted_at IS NULL").
+					WithArgs("dd2346fc-51c3-420f-a37e-8273d65120ad").
+					WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+			},
+			expected: "active",
+		},
+		{
+			description: "member does not exist",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT CASE (.+) FROM members WHERE id = \\$1 AND deleted_at IS NULL").
+					WithArgs("dd2346fc-51c3-420f-a37e-8273d65120ad").
+					WillReturnRows(sqlmock.NewRows([]string{"status"}))
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "db error",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT CASE (.+) FROM members WHERE id = \\$1 AND deleted_at IS NULL").
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewBorrowedStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			actual, err := s.MemberStatus(context.Background(), "dd2346fc-51c3-420f-a37e-8273d65120ad")
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expected, actual)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBorrowedStore_Count(t *testing.T) {
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expected      int
+		expectedError error
+	}{
+		{
+			description: "books out",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM borrowed_books WHERE member_id = \\$1").
+					WithArgs("dd2346fc-51c3-420f-a37e-8273d65120ad").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+			},
+			expected: 2,
+		},
+		{
+			description: "db error",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM borrowed_books").
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewBorrowedStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			actual, err := s.Count(context.Background(), "dd2346fc-51c3-420f-a37e-8273d65120ad")
 			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expected, actual)
 
 			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
 		})
 	}
 }
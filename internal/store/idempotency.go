@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+)
+
+// Reserve claims record.Key for record.Owner. It returns nil when the key was
+// free or had expired, and the stored record when the key is already taken.
+func (i *IdempotencyStore) Reserve(ctx context.Context, record *model.IdempotencyRecord) (*model.IdempotencyRecord, error) {
+	var owner string
+	err := conn(ctx, i.db).QueryRowContext(ctx, `INSERT INTO idempotency_keys (owner, key, request_hash, expires_at) VALUES ($1, $2, $3, $4)
+								ON CONFLICT (owner, key) DO UPDATE SET request_hash = EXCLUDED.request_hash,
+									status_code = NULL, content_type = NULL, location = NULL, etag = NULL, body = NULL,
+									created_at = now(), expires_at = EXCLUDED.expires_at
+								WHERE idempotency_keys.expires_at <= now()
+								RETURNING owner`,
+		record.Owner, record.Key, record.RequestHash, record.ExpiresAt).Scan(&owner)
+	if err == nil {
+		return nil, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		tracing.Logger(ctx, i.logger).Error("reserving idempotency key failed", "owner", record.Owner, "error", err.Error())
+		return nil, err
+	}
+
+	existing := model.IdempotencyRecord{Owner: record.Owner, Key: record.Key}
+	var statusCode sql.NullInt64
+	var contentType, location, etag sql.NullString
+	err = conn(ctx, i.db).QueryRowContext(ctx, `SELECT request_hash, status_code, content_type, location, etag, body, expires_at
+								FROM idempotency_keys WHERE owner = $1 AND key = $2`, record.Owner, record.Key).
+		Scan(&existing.RequestHash, &statusCode, &contentType, &location, &etag, &existing.Body, &existing.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+
+		tracing.Logger(ctx, i.logger).Error("reading idempotency key failed", "owner", record.Owner, "error", err.Error())
+		return nil, err
+	}
+
+	existing.StatusCode = int(statusCode.Int64)
+	existing.ContentType = contentType.String
+	existing.Location = location.String
+	existing.ETag = etag.String
+
+	return &existing, nil
+}
+
+// Complete stores the response of the request that reserved the key so
+// later retries can replay it.
+func (i *IdempotencyStore) Complete(ctx context.Context, record *model.IdempotencyRecord) error {
+	result, err := conn(ctx, i.db).ExecContext(ctx, `UPDATE idempotency_keys SET status_code = $1, content_type = $2, location = $3, etag = $4, body = $5
+								WHERE owner = $6 AND key = $7`,
+		record.StatusCode, record.ContentType, record.Location, record.ETag, record.Body, record.Owner, record.Key)
+	if err != nil {
+		tracing.Logger(ctx, i.logger).Error("storing idempotent response failed", "owner", record.Owner, "error", err.Error())
+		return err
+	}
+
+	return requireAffected(result)
+}
+
+// Release frees a key whose request did not finish so that it can be
+// retried.
+func (i *IdempotencyStore) Release(ctx context.Context, owner string, key string) error {
+	_, err := conn(ctx, i.db).ExecContext(ctx, `DELETE FROM idempotency_keys WHERE owner = $1 AND key = $2 AND status_code IS NULL`, owner, key)
+	if err != nil {
+		tracing.Logger(ctx, i.logger).Error("releasing idempotency key failed", "owner", owner, "error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (i *IdempotencyStore) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := conn(ctx, i.db).ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= now()`)
+	if err != nil {
+		tracing.Logger(ctx, i.logger).Error("deleting expired idempotency keys failed", "error", err.Error())
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
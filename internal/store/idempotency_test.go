@@ -0,0 +1,213 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"library-api/internal/model"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyStore_Reserve(t *testing.T) {
+	expiresAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	record := &model.IdempotencyRecord{
+		Owner:       "5d574a92-4b78-46eb-8ab0-02709b710b15",
+		Key:         "retry-1",
+		RequestHash: "3a1f",
+		ExpiresAt:   expiresAt,
+	}
+	testCases := []struct {
+		description      string
+		setupMock        func(mock sqlmock.Sqlmock)
+		expectedExisting *model.IdempotencyRecord
+		expectedError    error
+	}{
+		{
+			description: "key reserved",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO idempotency_keys (.+) ON CONFLICT (.+) WHERE idempotency_keys.expires_at <= now\\(\\) RETURNING owner").
+					WithArgs(record.Owner, record.Key, record.RequestHash, expiresAt).
+					WillReturnRows(sqlmock.NewRows([]string{"owner"}).AddRow(record.Owner))
+			},
+		},
+		{
+			description: "key already used",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT request_hash, status_code, content_type, location, etag, body, expires_at FROM idempotency_keys WHERE owner = \\$1 AND key = \\$2").
+					WithArgs(record.Owner, record.Key).
+					WillReturnRows(sqlmock.NewRows([]string{"request_hash", "status_code", "content_type", "location", "etag", "body", "expires_at"}).
+						AddRow("3a1f", 201, "application/json", "/book/1", `"1"`, []byte(`{"id":"1"}`), expiresAt))
+			},
+			expectedExisting: &model.IdempotencyRecord{
+				Owner:       record.Owner,
+				Key:         record.Key,
+				RequestHash: "3a1f",
+				StatusCode:  201,
+				ContentType: "application/json",
+				Location:    "/book/1",
+				ETag:        `"1"`,
+				Body:        []byte(`{"id":"1"}`),
+				ExpiresAt:   expiresAt,
+			},
+		},
+		{
+			description: "original request still running",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT request_hash").
+					WillReturnRows(sqlmock.NewRows([]string{"request_hash", "status_code", "content_type", "location", "etag", "body", "expires_at"}).
+						AddRow("3a1f", nil, nil, nil, nil, nil, expiresAt))
+			},
+			expectedExisting: &model.IdempotencyRecord{
+				Owner:       record.Owner,
+				Key:         record.Key,
+				RequestHash: "3a1f",
+				ExpiresAt:   expiresAt,
+			},
+		},
+		{
+			description: "key released in between",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT request_hash").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WillReturnError(errors.New("insert failed"))
+			},
+			expectedError: errors.New("insert failed"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewIdempotencyStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			existing, err := s.Reserve(context.Background(), record)
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedExisting, existing)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIdempotencyStore_Complete(t *testing.T) {
+	record := &model.IdempotencyRecord{
+		Owner:       "5d574a92-4b78-46eb-8ab0-02709b710b15",
+		Key:         "retry-1",
+		StatusCode:  201,
+		ContentType: "application/json",
+		Location:    "/book/1",
+		ETag:        `"1"`,
+		Body:        []byte(`{"id":"1"}`),
+	}
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			description: "response stored",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE idempotency_keys SET status_code = \\$1, content_type = \\$2, location = \\$3, etag = \\$4, body = \\$5 WHERE owner = \\$6 AND key = \\$7").
+					WithArgs(201, "application/json", "/book/1", `"1"`, []byte(`{"id":"1"}`), record.Owner, record.Key).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description: "key no longer reserved",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE idempotency_keys").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE idempotency_keys").
+					WillReturnError(errors.New("update failed"))
+			},
+			expectedError: errors.New("update failed"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewIdempotencyStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			err = s.Complete(context.Background(), record)
+			assert.Equal(t, testCase.expectedError, err)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIdempotencyStore_Release(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := NewIdempotencyStore(db, hclog.NewNullLogger())
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE owner = \\$1 AND key = \\$2 AND status_code IS NULL").
+		WithArgs("5d574a92-4b78-46eb-8ab0-02709b710b15", "retry-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM idempotency_keys").
+		WillReturnError(errors.New("error"))
+
+	assert.NoError(t, s.Release(context.Background(), "5d574a92-4b78-46eb-8ab0-02709b710b15", "retry-1"))
+	assert.Equal(t, errors.New("error"), s.Release(context.Background(), "5d574a92-4b78-46eb-8ab0-02709b710b15", "retry-1"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyStore_DeleteExpired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := NewIdempotencyStore(db, hclog.NewNullLogger())
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE expires_at <= now\\(\\)").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec("DELETE FROM idempotency_keys").
+		WillReturnError(errors.New("error"))
+
+	deleted, err := s.DeleteExpired(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), deleted)
+
+	_, err = s.DeleteExpired(context.Background())
+	assert.Equal(t, errors.New("error"), err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,14 +1,31 @@
 package store
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"library-api/internal/model"
+	"library-api/pkg/tracing"
 )
 
-func (m *MemberStore) Get() ([]model.Member, error) {
-	rows, err := m.db.Query(`SELECT * FROM members`)
+const memberFields = `id, full_name, COALESCE(email, ''), COALESCE(phone, ''), COALESCE(address, ''),
+								date_of_birth, card_number, registered_at, expires_at, status,
+								COALESCE(suspension_reason, ''), suspended_until, version`
+
+const selectMembers = `SELECT ` + memberFields + ` FROM members WHERE deleted_at IS NULL`
+
+// memberDest returns the scan destinations matching memberFields.
+func memberDest(member *model.Member) []any {
+	return []any{&member.ID, &member.FullName, &member.Email, &member.Phone, &member.Address,
+		&member.DateOfBirth, &member.CardNumber, &member.RegisteredAt, &member.ExpiresAt, &member.Status,
+		&member.SuspensionReason, &member.SuspendedUntil, &member.Version}
+}
+
+func (m *MemberStore) Get(ctx context.Context, filter model.MemberFilter) ([]model.Member, error) {
+	rows, err := conn(ctx, m.db).QueryContext(ctx, selectMembers+` AND ($1 = '' OR card_number = $1) AND ($2 = '' OR lower(email) = lower($2))
+								ORDER BY full_name`, filter.CardNumber, filter.Email)
 	if err != nil {
-		m.logger.Error("failed to execute query for get members", "error", err.Error())
+		tracing.Logger(ctx, m.logger).Error("failed to execute query for get members", "error", err.Error())
 		return nil, err
 	}
 	defer rows.Close()
@@ -16,9 +33,9 @@ func (m *MemberStore) Get() ([]model.Member, error) {
 	var members []model.Member
 	for rows.Next() {
 		var member model.Member
-		err = rows.Scan(&member.ID, &member.FullName)
+		err = rows.Scan(memberDest(&member)...)
 		if err != nil {
-			m.logger.Error("scanning selected failed for members", "error", err.Error())
+			tracing.Logger(ctx, m.logger).Error("scanning selected failed for members", "error", err.Error())
 			return nil, err
 		}
 
@@ -28,57 +45,173 @@ func (m *MemberStore) Get() ([]model.Member, error) {
 	return members, nil
 }
 
-func (m *MemberStore) Create(member *model.Member) error {
-	_, err := m.db.Exec(`INSERT INTO members(id, full_name) VALUES ($1, $2)`, &member.ID, &member.FullName)
+func (m *MemberStore) GetByID(ctx context.Context, id string) (*model.Member, error) {
+	var member model.Member
+	err := conn(ctx, m.db).QueryRowContext(ctx, selectMembers+` AND id = $1`, id).Scan(memberDest(&member)...)
 	if err != nil {
-		m.logger.Error("create failed for members", "error", err.Error())
-		return err
+		if errors.Is(err, sql.ErrNoRows) {
+			tracing.Logger(ctx, m.logger).Info("member does not exist", "id", id)
+			return nil, model.ErrNotFound
+		}
+
+		tracing.Logger(ctx, m.logger).Error("get by id failed for members", "id", id, "error", err.Error())
+		return nil, err
 	}
 
-	return nil
+	return &member, nil
 }
 
-func (m *MemberStore) Exists(id string) error {
-	rows, err := m.db.Query(`SELECT EXISTS (SELECT 1 FROM members WHERE id = $1)`, id)
+// Create inserts the member and replaces it with the stored row. A card
+// number that is already issued gives ErrCardNumberTaken without failing a
+// surrounding transaction, so the caller can try another one.
+func (m *MemberStore) Create(ctx context.Context, member *model.Member) error {
+	err := conn(ctx, m.db).QueryRowContext(ctx, `INSERT INTO members(id, full_name, email, phone, address, date_of_birth, card_number, registered_at, expires_at, status)
+								VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9, $10)
+								ON CONFLICT (card_number) DO NOTHING
+								RETURNING `+memberFields,
+		member.ID, member.FullName, member.Email, member.Phone, member.Address,
+		member.DateOfBirth, member.CardNumber, member.RegisteredAt, member.ExpiresAt, member.Status).
+		Scan(memberDest(member)...)
 	if err != nil {
-		m.logger.Info("id doesn't exist in members", "info", err.Error())
+		if errors.Is(err, sql.ErrNoRows) {
+			tracing.Logger(ctx, m.logger).Info("card number already issued", "card_number", member.CardNumber)
+			return model.ErrCardNumberTaken
+		}
+
+		tracing.Logger(ctx, m.logger).Error("create failed for members", "error", err.Error())
 		return err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var exists bool
-		err = rows.Scan(&exists)
-		if err != nil {
-			m.logger.Info("scan error", "id", id, "error", err.Error())
-			return err
-		}
+	return nil
+}
 
-		if !exists {
-			m.logger.Info("member does not exist", "id", id)
-			return errors.New("member does not exist")
+// Update applies the change only if the member is still at member.Version
+// and replaces member with the stored row.
+func (m *MemberStore) Update(ctx context.Context, id string, member *model.Member) error {
+	err := conn(ctx, m.db).QueryRowContext(ctx, `UPDATE members SET full_name = COALESCE(NULLIF($1, ''), full_name),
+								email = COALESCE(NULLIF($2, ''), email), phone = COALESCE(NULLIF($3, ''), phone),
+								address = COALESCE(NULLIF($4, ''), address), date_of_birth = COALESCE($5, date_of_birth)
+								WHERE id = $6 AND deleted_at IS NULL AND version = $7
+								RETURNING `+memberFields,
+		member.FullName, member.Email, member.Phone, member.Address, member.DateOfBirth, id, member.Version).
+		Scan(memberDest(member)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			tracing.Logger(ctx, m.logger).Info("member version conflict", "id", id, "version", member.Version)
+			return model.ErrVersionConflict
 		}
+
+		tracing.Logger(ctx, m.logger).Error("update failed for members", "id", id, "error", err.Error())
+		return err
 	}
 
 	return nil
 }
 
-func (m *MemberStore) Update(id string, member *model.Member) error {
-	_, err := m.db.Exec(`UPDATE members SET full_name = $1 WHERE id = $2`, &member.FullName, id)
+// Delete marks the member as deleted. Members who still have borrowed books
+// are left untouched and reported as referenced.
+func (m *MemberStore) Delete(ctx context.Context, id string) error {
+	err := softDelete(conn(ctx, m.db).QueryRowContext(ctx, `WITH target AS (
+									SELECT id, EXISTS (SELECT 1 FROM borrowed_books WHERE borrowed_books.member_id = members.id) AS referenced
+									FROM members WHERE id = $1 AND deleted_at IS NULL FOR UPDATE),
+								deleted AS (
+									UPDATE members SET deleted_at = now() FROM target
+									WHERE members.id = target.id AND NOT target.referenced)
+								SELECT referenced FROM target`, id))
+	if err != nil && !errors.Is(err, model.ErrNotFound) && !errors.Is(err, model.ErrReferenced) {
+		tracing.Logger(ctx, m.logger).Error("delete failed for members", "id", id, "error", err.Error())
+	}
+
+	return err
+}
+
+func (m *MemberStore) Restore(ctx context.Context, id string) error {
+	result, err := conn(ctx, m.db).ExecContext(ctx, `UPDATE members SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id)
 	if err != nil {
-		m.logger.Error("update failed for members", "id", id, "error", err.Error())
+		tracing.Logger(ctx, m.logger).Error("restore failed for members", "id", id, "error", err.Error())
 		return err
 	}
 
-	return nil
+	return requireAffected(result)
 }
 
-func (m *MemberStore) Delete(id string) error {
-	_, err := m.db.Exec(`DELETE FROM members WHERE id = $1`, id)
+func (m *MemberStore) Suspend(ctx context.Context, id string, suspension *model.Suspension) error {
+	result, err := conn(ctx, m.db).ExecContext(ctx, `UPDATE members SET status = 'suspended', suspension_reason = $1, suspended_until = $2
+								WHERE id = $3 AND deleted_at IS NULL`, suspension.Reason, suspension.Until, id)
 	if err != nil {
-		m.logger.Error("delete failed for members", "id", id, "error", err.Error())
+		tracing.Logger(ctx, m.logger).Error("suspend failed for members", "id", id, "error", err.Error())
 		return err
 	}
 
-	return nil
+	return requireAffected(result)
+}
+
+// Reinstate lifts a suspension and optionally renews the membership. A
+// member whose membership has already lapsed goes back to expired.
+func (m *MemberStore) Reinstate(ctx context.Context, id string, expiresAt model.Date) error {
+	result, err := conn(ctx, m.db).ExecContext(ctx, `UPDATE members SET expires_at = COALESCE($1, expires_at),
+								status = CASE WHEN COALESCE($1, expires_at) < CURRENT_DATE THEN 'expired' ELSE 'active' END,
+								suspension_reason = NULL, suspended_until = NULL
+								WHERE id = $2 AND deleted_at IS NULL`, expiresAt, id)
+	if err != nil {
+		tracing.Logger(ctx, m.logger).Error("reinstate failed for members", "id", id, "error", err.Error())
+		return err
+	}
+
+	return requireAffected(result)
+}
+
+// ExpireMemberships marks active members whose membership has run out as
+// expired and returns them as they were before and after.
+func (m *MemberStore) ExpireMemberships(ctx context.Context) ([]model.MemberChange, error) {
+	return m.sweep(ctx, "expiring memberships failed", `WITH lapsed AS (
+									SELECT id AS lapsed_id, status AS old_status, suspension_reason AS old_reason, suspended_until AS old_until
+									FROM members WHERE status = 'active' AND expires_at < CURRENT_DATE FOR UPDATE)
+								UPDATE members SET status = 'expired'
+								FROM lapsed WHERE members.id = lapsed.lapsed_id
+								RETURNING `+memberFields+`, old_status, COALESCE(old_reason, ''), old_until`)
+}
+
+// LiftSuspensions ends the suspensions that have run out and returns the
+// members as they were before and after.
+func (m *MemberStore) LiftSuspensions(ctx context.Context) ([]model.MemberChange, error) {
+	return m.sweep(ctx, "lifting suspensions failed", `WITH lapsed AS (
+									SELECT id AS lapsed_id, status AS old_status, suspension_reason AS old_reason, suspended_until AS old_until
+									FROM members WHERE status = 'suspended' AND suspended_until < CURRENT_DATE FOR UPDATE)
+								UPDATE members
+								SET status = CASE WHEN expires_at < CURRENT_DATE THEN 'expired' ELSE 'active' END,
+								    suspension_reason = NULL, suspended_until = NULL
+								FROM lapsed WHERE members.id = lapsed.lapsed_id
+								RETURNING `+memberFields+`, old_status, COALESCE(old_reason, ''), old_until`)
+}
+
+// sweep runs a bulk status update that returns the new state of every member
+// it changed followed by their old status and suspension.
+func (m *MemberStore) sweep(ctx context.Context, failure string, query string) ([]model.MemberChange, error) {
+	rows, err := conn(ctx, m.db).QueryContext(ctx, query)
+	if err != nil {
+		tracing.Logger(ctx, m.logger).Error(failure, "error", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []model.MemberChange{}
+	for rows.Next() {
+		var change model.MemberChange
+		var status, reason string
+		var until model.Date
+		err = rows.Scan(append(memberDest(&change.After), &status, &reason, &until)...)
+		if err != nil {
+			tracing.Logger(ctx, m.logger).Error(failure, "error", err.Error())
+			return nil, err
+		}
+
+		change.Before = change.After
+		change.Before.Status = status
+		change.Before.SuspensionReason = reason
+		change.Before.SuspendedUntil = until
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
 }
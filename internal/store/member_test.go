@@ -1,16 +1,24 @@
 package store
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"library-api/internal/model"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/assert"
 )
 
+var memberColumns = []string{"id", "full_name", "email", "phone", "address", "date_of_birth",
+	"card_number", "registered_at", "expires_at", "status", "suspension_reason", "suspended_until", "version"}
+
 func TestBorrowedStore_Get(t *testing.T) {
+	registeredAt := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	expiresAt := time.Date(2027, 1, 10, 0, 0, 0, 0, time.UTC)
 	testCases := []struct {
 		description   string
 		setupMock     func(mock sqlmock.Sqlmock)
@@ -20,28 +28,48 @@ func TestBorrowedStore_Get(t *testing.T) {
 		{
 			description: "get member successfully",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "full_name"}).
-					AddRow("3f45f596-ae05-4a60-802c-e2d45e7c26a2", "Samir Kenzhe").
-					AddRow("8ed3d7fd-88e6-44d9-b34b-9257a9a2d5b4", "Amina Tulegen")
-
-				mock.ExpectQuery("SELECT \\* FROM members").
+				rows := sqlmock.NewRows(memberColumns).
+					AddRow("3f45f596-ae05-4a60-802c-e2d45e7c26a2", "Samir Kenzhe", "samir@example.com", "+77010000000", "Almaty",
+						time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC), "29000000000015", registeredAt, expiresAt, "active", "", nil, 1).
+					AddRow("8ed3d7fd-88e6-44d9-b34b-9257a9a2d5b4", "Amina Tulegen", "", "", "",
+						nil, "29000000000023", registeredAt, expiresAt, "suspended", "lost books", expiresAt, 3)
+
+				mock.ExpectQuery("SELECT id, full_name, (.+) FROM members WHERE").
+					WithArgs("", "").
 					WillReturnRows(rows)
 			},
 			expectedBody: []model.Member{
 				{
-					ID:       "3f45f596-ae05-4a60-802c-e2d45e7c26a2",
-					FullName: "Samir Kenzhe",
+					ID:           "3f45f596-ae05-4a60-802c-e2d45e7c26a2",
+					FullName:     "Samir Kenzhe",
+					Email:        "samir@example.com",
+					Phone:        "+77010000000",
+					Address:      "Almaty",
+					DateOfBirth:  model.NewDate(time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC)),
+					CardNumber:   "29000000000015",
+					RegisteredAt: model.NewDate(registeredAt),
+					ExpiresAt:    model.NewDate(expiresAt),
+					Status:       "active",
+					Version:      1,
 				},
 				{
-					ID:       "8ed3d7fd-88e6-44d9-b34b-9257a9a2d5b4",
-					FullName: "Amina Tulegen",
+					ID:           "8ed3d7fd-88e6-44d9-b34b-9257a9a2d5b4",
+					FullName:     "Amina Tulegen",
+					CardNumber:   "29000000000023",
+					RegisteredAt: model.NewDate(registeredAt),
+					ExpiresAt:    model.NewDate(expiresAt),
+					Status:       "suspended",
+
+					SuspensionReason: "lost books",
+					SuspendedUntil:   model.NewDate(expiresAt),
+					Version:          3,
 				},
 			},
 		},
 		{
 			description: "db error",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT \\* FROM members").
+				mock.ExpectQuery("SELECT id, full_name, (.+) FROM members").
 					WillReturnError(errors.New("select all failed for members"))
 			},
 			expectedError: errors.New("select all failed for members"),
@@ -52,10 +80,10 @@ func TestBorrowedStore_Get(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"1st row"}).
 					AddRow("hello")
 
-				mock.ExpectQuery("SELECT \\* FROM members").
+				mock.ExpectQuery("SELECT id, full_name, (.+) FROM members").
 					WillReturnRows(rows)
 			},
-			expectedError: errors.New("sql: expected 1 destination arguments in Scan, not 2"),
+			expectedError: errors.New("sql: expected 1 destination arguments in Scan, not 13"),
 		},
 	}
 
@@ -69,7 +97,7 @@ func TestBorrowedStore_Get(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			body, err := s.Get()
+			body, err := s.Get(context.Background(), model.MemberFilter{})
 			assert.Equal(t, testCase.expectedBody, body)
 			assert.Equal(t, testCase.expectedError, err)
 
@@ -80,33 +108,70 @@ func TestBorrowedStore_Get(t *testing.T) {
 }
 
 func TestNewMemberStore_Create(t *testing.T) {
+	registeredAt := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	expiresAt := time.Date(2027, 1, 10, 0, 0, 0, 0, time.UTC)
 	testCases := []struct {
-		description   string
-		body          model.Member
-		setupMock     func(mock sqlmock.Sqlmock)
-		expectedError error
+		description     string
+		body            model.Member
+		setupMock       func(mock sqlmock.Sqlmock)
+		expectedVersion int
+		expectedError   error
 	}{
 		{
 			description: "create member successfully",
 			body: model.Member{
-				ID:       "3f45f596-ae05-4a60-802c-e2d45e7c26a2",
-				FullName: "Samir Kenzhe",
+				ID:           "3f45f596-ae05-4a60-802c-e2d45e7c26a2",
+				FullName:     "Samir Kenzhe",
+				Email:        "samir@example.com",
+				CardNumber:   "29000000000015",
+				RegisteredAt: model.NewDate(registeredAt),
+				ExpiresAt:    model.NewDate(expiresAt),
+				Status:       "active",
+			},
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO members\\(id, full_name, (.+)\\) (.+) RETURNING id, full_name").
+					WithArgs("3f45f596-ae05-4a60-802c-e2d45e7c26a2", "Samir Kenzhe", "samir@example.com", "", "",
+						nil, "29000000000015", "2026-01-10", "2027-01-10", "active").
+					WillReturnRows(sqlmock.NewRows(memberColumns).
+						AddRow("3f45f596-ae05-4a60-802c-e2d45e7c26a2", "Samir Kenzhe", "samir@example.com", "", "",
+							nil, "29000000000015", registeredAt, expiresAt, "active", "", nil, 1))
+			},
+			expectedVersion: 1,
+		},
+		{
+			description: "card number taken",
+			body: model.Member{
+				ID:           "3f45f596-ae05-4a60-802c-e2d45e7c26a2",
+				FullName:     "Samir Kenzhe",
+				Email:        "samir@example.com",
+				CardNumber:   "29000000000015",
+				RegisteredAt: model.NewDate(registeredAt),
+				ExpiresAt:    model.NewDate(expiresAt),
+				Status:       "active",
 			},
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("INSERT INTO members\\(id, full_name\\) VALUES \\(\\$1, \\$2\\)").
-					WithArgs("3f45f596-ae05-4a60-802c-e2d45e7c26a2", "Samir Kenzhe").
-					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery("INSERT INTO members\\(id, full_name, (.+)\\) (.+) ON CONFLICT \\(card_number\\) DO NOTHING").
+					WithArgs("3f45f596-ae05-4a60-802c-e2d45e7c26a2", "Samir Kenzhe", "samir@example.com", "", "",
+						nil, "29000000000015", "2026-01-10", "2027-01-10", "active").
+					WillReturnRows(sqlmock.NewRows(memberColumns))
 			},
+			expectedError: model.ErrCardNumberTaken,
 		},
 		{
 			description: "error db",
 			body: model.Member{
-				ID:       "3f45f596-ae05-4a60-802c-e2d45e7c26a2",
-				FullName: "Samir Kenzhe",
+				ID:           "3f45f596-ae05-4a60-802c-e2d45e7c26a2",
+				FullName:     "Samir Kenzhe",
+				Email:        "samir@example.com",
+				CardNumber:   "29000000000015",
+				RegisteredAt: model.NewDate(registeredAt),
+				ExpiresAt:    model.NewDate(expiresAt),
+				Status:       "active",
 			},
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("INSERT INTO members\\(id, full_name\\) VALUES \\(\\$1, \\$2\\)").
-					WithArgs("3f45f596-ae05-4a60-802c-e2d45e7c26a2", "Samir Kenzhe").
+				mock.ExpectQuery("INSERT INTO members\\(id, full_name, (.+)\\)").
+					WithArgs("3f45f596-ae05-4a60-802c-e2d45e7c26a2", "Samir Kenzhe", "samir@example.com", "", "",
+						nil, "29000000000015", "2026-01-10", "2027-01-10", "active").
 					WillReturnError(errors.New("insert request failed"))
 			},
 			expectedError: errors.New("insert request failed"),
@@ -123,8 +188,9 @@ func TestNewMemberStore_Create(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			err = s.Create(&testCase.body)
+			err = s.Create(context.Background(), &testCase.body)
 			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedVersion, testCase.body.Version)
 
 			err = mock.ExpectationsWereMet()
 			assert.NoError(t, err)
@@ -132,57 +198,51 @@ func TestNewMemberStore_Create(t *testing.T) {
 	}
 }
 
-func TestMemberStore_Exists(t *testing.T) {
+func TestMemberStore_GetByID(t *testing.T) {
+	registeredAt := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	expiresAt := time.Date(2027, 1, 10, 0, 0, 0, 0, time.UTC)
 	testCases := []struct {
-		description   string
-		id            string
-		setupMock     func(mock sqlmock.Sqlmock)
-		expectedError error
+		description    string
+		setupMock      func(mock sqlmock.Sqlmock)
+		expectedMember *model.Member
+		expectedError  error
 	}{
 		{
-			description: "member exists successfully",
-			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+			description: "member found",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"exists"}).
-					AddRow(true)
+				rows := sqlmock.NewRows(memberColumns).
+					AddRow("3f45f596-ae05-4a60-802c-e2d45e7c26a2", "Samir Kenzhe", "", "", "",
+						nil, "29000000000015", registeredAt, expiresAt, "active", "", nil, 2)
 
-				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM members WHERE id = \\$1\\)").
-					WithArgs("b7eb3c06-6df8-4353-90f5-7ab897a77158").
+				mock.ExpectQuery("SELECT id, full_name, (.+) FROM members WHERE deleted_at IS NULL AND id = \\$1").
+					WithArgs("3f45f596-ae05-4a60-802c-e2d45e7c26a2").
 					WillReturnRows(rows)
 			},
-		},
-		{
-			description: "error db",
-			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
-			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM members WHERE id = \\$1\\)").
-					WillReturnError(errors.New("select error"))
+			expectedMember: &model.Member{
+				ID:           "3f45f596-ae05-4a60-802c-e2d45e7c26a2",
+				FullName:     "Samir Kenzhe",
+				CardNumber:   "29000000000015",
+				RegisteredAt: model.NewDate(registeredAt),
+				ExpiresAt:    model.NewDate(expiresAt),
+				Status:       "active",
+				Version:      2,
 			},
-			expectedError: errors.New("select error"),
 		},
 		{
-			description: "scan error",
-			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+			description: "member doesn't exist",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"1st row", "second row"}).
-					AddRow("hello", "world")
-
-				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM members WHERE id = \\$1\\)").
-					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT id, full_name, (.+) FROM members WHERE deleted_at IS NULL AND id = \\$1").
+					WillReturnError(sql.ErrNoRows)
 			},
-			expectedError: errors.New("sql: expected 2 destination arguments in Scan, not 1"),
+			expectedError: model.ErrNotFound,
 		},
 		{
-			description: "member doesn't exist",
-			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+			description: "db error",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"exists"}).
-					AddRow(false)
-
-				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM members WHERE id = \\$1\\)").
-					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT id, full_name, (.+) FROM members WHERE deleted_at IS NULL AND id = \\$1").
+					WillReturnError(errors.New("select error"))
 			},
-			expectedError: errors.New("member does not exist"),
+			expectedError: errors.New("select error"),
 		},
 	}
 
@@ -196,8 +256,9 @@ func TestMemberStore_Exists(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			err = s.Exists(testCase.id)
+			member, err := s.GetByID(context.Background(), "3f45f596-ae05-4a60-802c-e2d45e7c26a2")
 			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedMember, member)
 
 			err = mock.ExpectationsWereMet()
 			assert.NoError(t, err)
@@ -206,12 +267,15 @@ func TestMemberStore_Exists(t *testing.T) {
 }
 
 func TestNewMemberStore_Update(t *testing.T) {
+	registeredAt := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	expiresAt := time.Date(2027, 1, 10, 0, 0, 0, 0, time.UTC)
 	testCases := []struct {
-		description   string
-		id            string
-		body          model.Member
-		setupMock     func(mock sqlmock.Sqlmock)
-		expectedError error
+		description     string
+		id              string
+		body            model.Member
+		setupMock       func(mock sqlmock.Sqlmock)
+		expectedVersion int
+		expectedError   error
 	}{
 		{
 			description: "member updated successfully",
@@ -219,12 +283,32 @@ func TestNewMemberStore_Update(t *testing.T) {
 			body: model.Member{
 				ID:       "b7eb3c06-6df8-4353-90f5-7ab897a77158",
 				FullName: "John Doe",
+				Version:  1,
+			},
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("UPDATE members SET full_name = COALESCE\\(NULLIF\\(\\$1, ''\\), full_name\\)(.+) AND version = \\$7 RETURNING id, full_name").
+					WithArgs("John Doe", "", "", "", nil, "b7eb3c06-6df8-4353-90f5-7ab897a77158", 1).
+					WillReturnRows(sqlmock.NewRows(memberColumns).
+						AddRow("b7eb3c06-6df8-4353-90f5-7ab897a77158", "John Doe", "", "", "",
+							nil, "29000000000015", registeredAt, expiresAt, "active", "", nil, 2))
+			},
+			expectedVersion: 2,
+		},
+		{
+			description: "version conflict",
+			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+			body: model.Member{
+				ID:       "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+				FullName: "John Doe",
+				Version:  1,
 			},
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("UPDATE members SET full_name = \\$1 WHERE id = \\$2").
-					WithArgs("John Doe", "b7eb3c06-6df8-4353-90f5-7ab897a77158").
-					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery("UPDATE members").
+					WithArgs("John Doe", "", "", "", nil, "b7eb3c06-6df8-4353-90f5-7ab897a77158", 1).
+					WillReturnError(sql.ErrNoRows)
 			},
+			expectedVersion: 1,
+			expectedError:   model.ErrVersionConflict,
 		},
 		{
 			description: "update request failed",
@@ -234,8 +318,8 @@ func TestNewMemberStore_Update(t *testing.T) {
 				FullName: "John Doe",
 			},
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("UPDATE members SET full_name = \\$1 WHERE id = \\$2").
-					WithArgs("John Doe", "b7eb3c06-6df8-4353-90f5-7ab897a77158").
+				mock.ExpectQuery("UPDATE members").
+					WithArgs("John Doe", "", "", "", nil, "b7eb3c06-6df8-4353-90f5-7ab897a77158", 0).
 					WillReturnError(errors.New("update request failed"))
 			},
 			expectedError: errors.New("update request failed"),
@@ -252,8 +336,9 @@ func TestNewMemberStore_Update(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			err = s.Update(testCase.id, &testCase.body)
+			err = s.Update(context.Background(), testCase.id, &testCase.body)
 			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedVersion, testCase.body.Version)
 
 			err = mock.ExpectationsWereMet()
 			assert.NoError(t, err)
@@ -269,23 +354,42 @@ func TestMemberStore_Delete(t *testing.T) {
 		expectedError error
 	}{
 		{
-			description: "member deleted successfully",
+			description: "member marked as deleted",
+			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("WITH target AS (.+) FROM members WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE(.+) UPDATE members SET deleted_at = now\\(\\)").
+					WithArgs("b7eb3c06-6df8-4353-90f5-7ab897a77158").
+					WillReturnRows(sqlmock.NewRows([]string{"referenced"}).AddRow(false))
+			},
+		},
+		{
+			description: "member still referenced",
 			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("DELETE FROM members WHERE id = \\$1").
+				mock.ExpectQuery("WITH target AS").
 					WithArgs("b7eb3c06-6df8-4353-90f5-7ab897a77158").
-					WillReturnResult(sqlmock.NewResult(1, 1))
+					WillReturnRows(sqlmock.NewRows([]string{"referenced"}).AddRow(true))
 			},
+			expectedError: model.ErrReferenced,
 		},
 		{
-			description: "delete request failed",
+			description: "member missing or already deleted",
 			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("DELETE FROM members WHERE id = \\$1").
+				mock.ExpectQuery("WITH target AS").
 					WithArgs("b7eb3c06-6df8-4353-90f5-7ab897a77158").
-					WillReturnError(errors.New("delete request failed"))
+					WillReturnRows(sqlmock.NewRows([]string{"referenced"}))
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "error db",
+			id:          "b7eb3c06-6df8-4353-90f5-7ab897a77158",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("WITH target AS").
+					WillReturnError(errors.New("delete failed"))
 			},
-			expectedError: errors.New("delete request failed"),
+			expectedError: errors.New("delete failed"),
 		},
 	}
 
@@ -299,7 +403,7 @@ func TestMemberStore_Delete(t *testing.T) {
 
 			testCase.setupMock(mock)
 
-			err = s.Delete(testCase.id)
+			err = s.Delete(context.Background(), testCase.id)
 			assert.Equal(t, testCase.expectedError, err)
 
 			err = mock.ExpectationsWereMet()
@@ -307,3 +411,221 @@ func TestMemberStore_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestMemberStore_Restore(t *testing.T) {
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			description: "member restored",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE members SET deleted_at = NULL WHERE id = \\$1 AND deleted_at IS NOT NULL").
+					WithArgs("b7eb3c06-6df8-4353-90f5-7ab897a77158").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description: "no deleted member",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE members SET deleted_at = NULL").
+					WithArgs("b7eb3c06-6df8-4353-90f5-7ab897a77158").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "error db",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE members SET deleted_at = NULL").
+					WillReturnError(errors.New("restore failed"))
+			},
+			expectedError: errors.New("restore failed"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewMemberStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			err = s.Restore(context.Background(), "b7eb3c06-6df8-4353-90f5-7ab897a77158")
+			assert.Equal(t, testCase.expectedError, err)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMemberStore_Suspend(t *testing.T) {
+	until := model.NewDate(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			description: "member suspended",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE members SET status = 'suspended'").
+					WithArgs("lost books", "2026-03-01", "b7eb3c06-6df8-4353-90f5-7ab897a77158").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description: "member not found",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE members SET status = 'suspended'").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: model.ErrNotFound,
+		},
+		{
+			description: "update request failed",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE members SET status = 'suspended'").
+					WillReturnError(errors.New("update request failed"))
+			},
+			expectedError: errors.New("update request failed"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewMemberStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			err = s.Suspend(context.Background(), "b7eb3c06-6df8-4353-90f5-7ab897a77158", &model.Suspension{Reason: "lost books", Until: until})
+			assert.Equal(t, testCase.expectedError, err)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMemberStore_Reinstate(t *testing.T) {
+	testCases := []struct {
+		description   string
+		expiresAt     model.Date
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			description: "member reinstated",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE members SET expires_at = COALESCE\\(\\$1, expires_at\\)").
+					WithArgs(nil, "b7eb3c06-6df8-4353-90f5-7ab897a77158").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description: "member reinstated and renewed",
+			expiresAt:   model.NewDate(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)),
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE members SET expires_at = COALESCE\\(\\$1, expires_at\\)").
+					WithArgs("2027-01-01", "b7eb3c06-6df8-4353-90f5-7ab897a77158").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description: "member not found",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE members SET expires_at").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: model.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewMemberStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			err = s.Reinstate(context.Background(), "b7eb3c06-6df8-4353-90f5-7ab897a77158", testCase.expiresAt)
+			assert.Equal(t, testCase.expectedError, err)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMemberStore_ExpireMemberships(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := NewMemberStore(db, hclog.NewNullLogger())
+
+	registered := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("WITH lapsed AS (.+) WHERE status = 'active' AND expires_at < CURRENT_DATE FOR UPDATE\\) UPDATE members SET status = 'expired' (.+) RETURNING").
+		WillReturnRows(sqlmock.NewRows(append(memberColumns, "old_status", "old_reason", "old_until")).
+			AddRow("1de94d3e-09b2-4f62-bfff-964012c649d3", "Ada Lovelace", "", "", "", nil, "29000000000015",
+				registered, registered.AddDate(1, 0, 0), "expired", "", nil, 2, "active", "", nil))
+	mock.ExpectQuery("WITH lapsed AS").
+		WillReturnError(errors.New("error"))
+
+	expired, err := s.ExpireMemberships(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, expired, 1)
+	assert.Equal(t, "1de94d3e-09b2-4f62-bfff-964012c649d3", expired[0].After.ID)
+	assert.Equal(t, model.MemberStatusActive, expired[0].Before.Status)
+	assert.Equal(t, model.MemberStatusExpired, expired[0].After.Status)
+
+	_, err = s.ExpireMemberships(context.Background())
+	assert.Equal(t, errors.New("error"), err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMemberStore_LiftSuspensions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	s := NewMemberStore(db, hclog.NewNullLogger())
+
+	registered := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("WITH lapsed AS (.+) WHERE status = 'suspended' AND suspended_until < CURRENT_DATE FOR UPDATE\\) UPDATE members SET status = CASE").
+		WillReturnRows(sqlmock.NewRows(append(memberColumns, "old_status", "old_reason", "old_until")).
+			AddRow("1de94d3e-09b2-4f62-bfff-964012c649d3", "Ada Lovelace", "", "", "", nil, "29000000000015",
+				registered, registered.AddDate(2, 0, 0), "active", "", nil, 3, "suspended", "overdue books", until))
+	mock.ExpectQuery("WITH lapsed AS").
+		WillReturnError(errors.New("error"))
+
+	lifted, err := s.LiftSuspensions(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, lifted, 1)
+	assert.Equal(t, model.Member{
+		ID: "1de94d3e-09b2-4f62-bfff-964012c649d3", FullName: "Ada Lovelace", CardNumber: "29000000000015",
+		RegisteredAt: model.NewDate(registered), ExpiresAt: model.NewDate(registered.AddDate(2, 0, 0)),
+		Status: model.MemberStatusSuspended, SuspensionReason: "overdue books", SuspendedUntil: model.NewDate(until), Version: 3,
+	}, lifted[0].Before)
+	assert.Equal(t, model.MemberStatusActive, lifted[0].After.Status)
+	assert.Empty(t, lifted[0].After.SuspensionReason)
+
+	_, err = s.LiftSuspensions(context.Background())
+	assert.Equal(t, errors.New("error"), err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+	"time"
+)
+
+// Purge permanently removes records that were soft deleted before the given
+// time. Books go first so that their authors can follow in the same run;
+// records that are still referenced by live rows are kept.
+func (p *PurgeStore) Purge(ctx context.Context, before time.Time) (*model.PurgeResult, error) {
+	var result model.PurgeResult
+	err := runTx(ctx, p.db, p.logger, func(ctx context.Context) error {
+		var err error
+		result.Books, err = p.purge(ctx, "books", `DELETE FROM books
+								WHERE deleted_at < $1
+								  AND NOT EXISTS (SELECT 1 FROM borrowed_books WHERE borrowed_books.book_id = books.id)
+								RETURNING id`, before)
+		if err != nil {
+			return err
+		}
+
+		result.Authors, err = p.purge(ctx, "authors", `DELETE FROM authors
+								WHERE deleted_at < $1
+								  AND NOT EXISTS (SELECT 1 FROM books WHERE books.authors_id = authors.id)
+								RETURNING id`, before)
+		if err != nil {
+			return err
+		}
+
+		result.Members, err = p.purge(ctx, "members", `DELETE FROM members
+								WHERE deleted_at < $1
+								  AND NOT EXISTS (SELECT 1 FROM borrowed_books WHERE borrowed_books.member_id = members.id)
+								RETURNING id`, before)
+		return err
+	})
+	if err != nil {
+		tracing.Logger(ctx, p.logger).Error("purge failed", "error", err.Error())
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (p *PurgeStore) purge(ctx context.Context, table string, query string, before time.Time) ([]string, error) {
+	rows, err := conn(ctx, p.db).QueryContext(ctx, query, before)
+	if err != nil {
+		tracing.Logger(ctx, p.logger).Error("purging table failed", "table", table, "error", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		err = rows.Scan(&id)
+		if err != nil {
+			tracing.Logger(ctx, p.logger).Error("scanning purged ids failed", "table", table, "error", err.Error())
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
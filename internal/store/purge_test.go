@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"library-api/internal/model"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeStore_Purge(t *testing.T) {
+	before := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		description    string
+		setupMock      func(mock sqlmock.Sqlmock)
+		expectedResult *model.PurgeResult
+		expectedError  error
+	}{
+		{
+			description: "books purged before their authors",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("DELETE FROM books WHERE deleted_at < \\$1 (.+) RETURNING id").
+					WithArgs(before).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("0eabf8fc-1867-48c4-b835-271db2be1f2e"))
+				mock.ExpectQuery("DELETE FROM authors WHERE deleted_at < \\$1 (.+) RETURNING id").
+					WithArgs(before).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("ce99cad9-9d1c-4e8c-a306-e51d7022926e"))
+				mock.ExpectQuery("DELETE FROM members WHERE deleted_at < \\$1 (.+) RETURNING id").
+					WithArgs(before).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectCommit()
+			},
+			expectedResult: &model.PurgeResult{
+				Authors: []string{"ce99cad9-9d1c-4e8c-a306-e51d7022926e"},
+				Books:   []string{"0eabf8fc-1867-48c4-b835-271db2be1f2e"},
+				Members: []string{},
+			},
+		},
+		{
+			description: "rolled back on error",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("DELETE FROM books").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery("DELETE FROM authors").
+					WillReturnError(errors.New("delete failed"))
+				mock.ExpectRollback()
+			},
+			expectedError: errors.New("delete failed"),
+		},
+		{
+			description: "begin failed",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin().WillReturnError(errors.New("begin failed"))
+			},
+			expectedError: errors.New("begin failed"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewPurgeStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			result, err := s.Purge(context.Background(), before)
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedResult, result)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
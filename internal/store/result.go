@@ -0,0 +1,42 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"library-api/internal/model"
+)
+
+// requireAffected reports model.ErrNotFound when a statement addressed to a
+// single record did not change anything.
+func requireAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// softDelete reads the result of a soft delete query that selects whether the
+// live record is still referenced. No row means there was nothing to delete.
+func softDelete(row *sql.Row) error {
+	var referenced bool
+	err := row.Scan(&referenced)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrNotFound
+		}
+
+		return err
+	}
+
+	if referenced {
+		return model.ErrReferenced
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"library-api/internal/cql"
+	"library-api/internal/model"
+	"library-api/pkg/tracing"
+	"strings"
+)
+
+const booksWithAuthors = `FROM books LEFT JOIN authors ON authors.id = books.authors_id AND authors.deleted_at IS NULL`
+
+var searchIndexes = map[string]cql.Index{
+	"dc.title":            {Columns: []string{"books.title"}},
+	"dc.creator":          {Columns: []string{"authors.full_name", "authors.nick_name"}},
+	"dc.subject":          {Columns: []string{"books.genre"}},
+	"bath.isbn":           {Columns: []string{"books.isbn"}, IgnoreHyphens: true},
+	cql.ServerChoiceIndex: {Columns: []string{"books.title", "authors.full_name", "books.genre"}},
+}
+
+var scanColumns = map[string]string{
+	"dc.title":   "books.title",
+	"dc.creator": "authors.full_name",
+	"dc.subject": "books.genre",
+	"bath.isbn":  "books.isbn",
+}
+
+func (b *BookStore) Search(ctx context.Context, query cql.Node, offset int, limit int) ([]model.Book, int, error) {
+	where, args, err := cql.ToSQL(query, searchIndexes, 0)
+	if err != nil {
+		tracing.Logger(ctx, b.logger).Info("cql translation failed", "error", err.Error())
+		return nil, 0, err
+	}
+
+	var total int
+	err = conn(ctx, b.db).QueryRowContext(ctx, `SELECT COUNT(*) `+booksWithAuthors+` WHERE books.deleted_at IS NULL AND `+where, args...).Scan(&total)
+	if err != nil {
+		tracing.Logger(ctx, b.logger).Error("failed to count search results", "error", err.Error())
+		return nil, 0, err
+	}
+
+	if total == 0 || limit == 0 {
+		return nil, total, nil
+	}
+
+	rows, err := conn(ctx, b.db).QueryContext(ctx, fmt.Sprintf(`%s WHERE books.deleted_at IS NULL AND %s ORDER BY books.title, books.id LIMIT $%d OFFSET $%d`,
+		selectBooksWithAuthors, where, len(args)+1, len(args)+2), append(args, limit, offset)...)
+	if err != nil {
+		tracing.Logger(ctx, b.logger).Error("failed to execute search query", "error", err.Error())
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	books, err := b.scanBooksWithAuthors(ctx, rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return books, total, nil
+}
+
+func (b *BookStore) ScanIndex(ctx context.Context, index string, from string, limit int) ([]model.Term, error) {
+	column, ok := scanColumns[strings.ToLower(index)]
+	if !ok {
+		return nil, &cql.Error{Code: cql.CodeUnsupportedIndex, Message: "unsupported index", Details: index}
+	}
+
+	rows, err := conn(ctx, b.db).QueryContext(ctx, fmt.Sprintf(`SELECT %[1]s, COUNT(*) %[2]s
+									WHERE books.deleted_at IS NULL AND %[1]s IS NOT NULL AND LOWER(%[1]s) >= LOWER($1)
+									GROUP BY %[1]s ORDER BY LOWER(%[1]s), %[1]s LIMIT $2`, column, booksWithAuthors), from, limit)
+	if err != nil {
+		tracing.Logger(ctx, b.logger).Error("failed to execute scan query", "index", index, "error", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var terms []model.Term
+	for rows.Next() {
+		var term model.Term
+		err = rows.Scan(&term.Value, &term.NumberOfRecords)
+		if err != nil {
+			tracing.Logger(ctx, b.logger).Error("scanning terms failed", "index", index, "error", err.Error())
+			return nil, err
+		}
+
+		terms = append(terms, term)
+	}
+
+	return terms, nil
+}
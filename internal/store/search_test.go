@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"library-api/internal/cql"
+	"library-api/internal/model"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookStore_Search(t *testing.T) {
+	columns := []string{"id", "authors_id", "title", "genre", "isbn", "full_name", "nick_name", "specialization", "borrowed", "version"}
+	authorFullName := "Alice Johnson"
+	testCases := []struct {
+		description   string
+		query         cql.Node
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedBody  []model.Book
+		expectedTotal int
+		expectedError error
+	}{
+		{
+			description: "search successfully",
+			query:       &cql.Clause{Index: "dc.title", Relation: "=", Term: "desert"},
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM books LEFT JOIN authors ON authors.id = books.authors_id AND authors.deleted_at IS NULL WHERE books.deleted_at IS NULL AND books.title ILIKE \$1`).
+					WithArgs("%desert%").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+				rows := sqlmock.NewRows(columns).
+					AddRow("0eabf8fc-1867-48c4-b835-271db2be1f2e", "ce99cad9-9d1c-4e8c-a306-e51d7022926e", "Desert Stars", "IT", "978-1-00001-000-1", authorFullName, "Ali", "IT", false, 2)
+
+				mock.ExpectQuery(`WHERE books.deleted_at IS NULL AND books.title ILIKE \$1 ORDER BY books.title, books.id LIMIT \$2 OFFSET \$3`).
+					WithArgs("%desert%", 1, 2).
+					WillReturnRows(rows)
+			},
+			expectedBody: []model.Book{
+				{
+					ID:        "0eabf8fc-1867-48c4-b835-271db2be1f2e",
+					AuthorsID: "ce99cad9-9d1c-4e8c-a306-e51d7022926e",
+					Title:     "Desert Stars",
+					Genre:     "IT",
+					ISBN:      "978-1-00001-000-1",
+					Version:   2,
+					Author: model.Author{
+						ID:             "ce99cad9-9d1c-4e8c-a306-e51d7022926e",
+						FullName:       &authorFullName,
+						NickName:       "Ali",
+						Specialization: "IT",
+					},
+				},
+			},
+			expectedTotal: 3,
+		},
+		{
+			description: "nothing found",
+			query:       &cql.Clause{Index: "bath.isbn", Relation: "==", Term: "978-1"},
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT COUNT\(\*\)`).
+					WithArgs("9781").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			},
+		},
+		{
+			description:   "unsupported index",
+			query:         &cql.Clause{Index: "dc.publisher", Relation: "=", Term: "x"},
+			setupMock:     func(mock sqlmock.Sqlmock) {},
+			expectedError: &cql.Error{Code: cql.CodeUnsupportedIndex, Message: "unsupported index", Details: "dc.publisher"},
+		},
+		{
+			description: "error db",
+			query:       &cql.Clause{Index: "dc.subject", Relation: "=", Term: "it"},
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT COUNT\(\*\)`).
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewBookStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			body, total, err := s.Search(context.Background(), testCase.query, 2, 1)
+			assert.Equal(t, testCase.expectedError, err)
+
+			assert.Equal(t, testCase.expectedBody, body)
+			assert.Equal(t, testCase.expectedTotal, total)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBookStore_ScanIndex(t *testing.T) {
+	testCases := []struct {
+		description   string
+		index         string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedBody  []model.Term
+		expectedError error
+	}{
+		{
+			description: "scan successfully",
+			index:       "dc.creator",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"full_name", "count"}).
+					AddRow("Agatha Christie", 6).
+					AddRow("Alice Johnson", 1)
+
+				mock.ExpectQuery(`SELECT authors.full_name, COUNT\(\*\)`).
+					WithArgs("a", 2).
+					WillReturnRows(rows)
+			},
+			expectedBody: []model.Term{
+				{Value: "Agatha Christie", NumberOfRecords: 6},
+				{Value: "Alice Johnson", NumberOfRecords: 1},
+			},
+		},
+		{
+			description:   "unsupported index",
+			index:         "cql.serverchoice",
+			setupMock:     func(mock sqlmock.Sqlmock) {},
+			expectedError: &cql.Error{Code: cql.CodeUnsupportedIndex, Message: "unsupported index", Details: "cql.serverchoice"},
+		},
+		{
+			description: "error db",
+			index:       "dc.title",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT books.title, COUNT\(\*\)`).
+					WillReturnError(errors.New("error"))
+			},
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			s := NewBookStore(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			body, err := s.ScanIndex(context.Background(), testCase.index, "a", 2)
+			assert.Equal(t, testCase.expectedError, err)
+
+			assert.Equal(t, testCase.expectedBody, body)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
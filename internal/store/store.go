@@ -53,3 +53,75 @@ func NewMemberStore(db *sql.DB, logger hclog.Logger) *MemberStore {
 		logger: logger,
 	}
 }
+
+type APIKeyStore struct {
+	db     *sql.DB
+	logger hclog.Logger
+}
+
+func NewAPIKeyStore(db *sql.DB, logger hclog.Logger) *APIKeyStore {
+	return &APIKeyStore{
+		db:     db,
+		logger: logger,
+	}
+}
+
+type AccountStore struct {
+	db     *sql.DB
+	logger hclog.Logger
+}
+
+func NewAccountStore(db *sql.DB, logger hclog.Logger) *AccountStore {
+	return &AccountStore{
+		db:     db,
+		logger: logger,
+	}
+}
+
+type AuditStore struct {
+	db     *sql.DB
+	logger hclog.Logger
+}
+
+func NewAuditStore(db *sql.DB, logger hclog.Logger) *AuditStore {
+	return &AuditStore{
+		db:     db,
+		logger: logger,
+	}
+}
+
+type PurgeStore struct {
+	db     *sql.DB
+	logger hclog.Logger
+}
+
+func NewPurgeStore(db *sql.DB, logger hclog.Logger) *PurgeStore {
+	return &PurgeStore{
+		db:     db,
+		logger: logger,
+	}
+}
+
+type IdempotencyStore struct {
+	db     *sql.DB
+	logger hclog.Logger
+}
+
+func NewIdempotencyStore(db *sql.DB, logger hclog.Logger) *IdempotencyStore {
+	return &IdempotencyStore{
+		db:     db,
+		logger: logger,
+	}
+}
+
+type Transactor struct {
+	db     *sql.DB
+	logger hclog.Logger
+}
+
+func NewTransactor(db *sql.DB, logger hclog.Logger) *Transactor {
+	return &Transactor{
+		db:     db,
+		logger: logger,
+	}
+}
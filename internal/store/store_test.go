@@ -67,3 +67,93 @@ func TestMemberStore(t *testing.T) {
 
 	assert.Equal(t, expected, actual)
 }
+
+func TestAPIKeyStore(t *testing.T) {
+	mockDb, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDb.Close()
+
+	actual := NewAPIKeyStore(mockDb, hclog.NewNullLogger())
+
+	expected := &APIKeyStore{
+		db:     mockDb,
+		logger: hclog.NewNullLogger(),
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestAccountStore(t *testing.T) {
+	mockDb, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDb.Close()
+
+	actual := NewAccountStore(mockDb, hclog.NewNullLogger())
+
+	expected := &AccountStore{
+		db:     mockDb,
+		logger: hclog.NewNullLogger(),
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestAuditStore(t *testing.T) {
+	mockDb, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDb.Close()
+
+	actual := NewAuditStore(mockDb, hclog.NewNullLogger())
+
+	expected := &AuditStore{
+		db:     mockDb,
+		logger: hclog.NewNullLogger(),
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestPurgeStore(t *testing.T) {
+	mockDb, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDb.Close()
+
+	actual := NewPurgeStore(mockDb, hclog.NewNullLogger())
+
+	expected := &PurgeStore{
+		db:     mockDb,
+		logger: hclog.NewNullLogger(),
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestIdempotencyStore(t *testing.T) {
+	mockDb, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDb.Close()
+
+	actual := NewIdempotencyStore(mockDb, hclog.NewNullLogger())
+
+	expected := &IdempotencyStore{
+		db:     mockDb,
+		logger: hclog.NewNullLogger(),
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestTransactor(t *testing.T) {
+	mockDb, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDb.Close()
+
+	actual := NewTransactor(mockDb, hclog.NewNullLogger())
+
+	expected := &Transactor{
+		db:     mockDb,
+		logger: hclog.NewNullLogger(),
+	}
+
+	assert.Equal(t, expected, actual)
+}
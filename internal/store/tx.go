@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"library-api/pkg/tracing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/lib/pq"
+)
+
+const maxTxAttempts = 3
+
+const (
+	codeSerializationFailure pq.ErrorCode = "40001"
+	codeDeadlockDetected     pq.ErrorCode = "40P01"
+)
+
+type txKey struct{}
+
+// querier is what the stores need from a connection, so the same query code
+// runs against the pool or inside a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// conn returns the transaction carried by ctx, or db when there is none.
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return db
+}
+
+// Run executes fn in a serializable transaction. Store methods called with
+// the context handed to fn take part in it. The transaction is committed when
+// fn returns nil and rolled back otherwise; serialization failures and
+// deadlocks run fn again from the start, so fn must not have side effects
+// outside the database. Calling Run inside fn joins the outer transaction.
+func (t *Transactor) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	return runTx(ctx, t.db, t.logger, fn)
+}
+
+func runTx(ctx context.Context, db *sql.DB, logger hclog.Logger, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = runTxOnce(ctx, db, fn)
+		if !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		tracing.Logger(ctx, logger).Info("transaction conflict, retrying", "attempt", attempt, "error", err.Error())
+	}
+
+	tracing.Logger(ctx, logger).Error("transaction failed after retries", "attempts", maxTxAttempts, "error", err.Error())
+	return err
+}
+
+func runTxOnce(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func retryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code == codeSerializationFailure || pqErr.Code == codeDeadlockDetected
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hashicorp/go-hclog"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactor_Run(t *testing.T) {
+	conflict := &pq.Error{Code: codeSerializationFailure}
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		nested        bool
+		fnError       error
+		expectedCalls int
+		expectedError error
+	}{
+		{
+			description: "committed",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE books SET title = \\$1").WithArgs("It").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedCalls: 1,
+		},
+		{
+			description: "rolled back when fn fails",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE books").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectRollback()
+			},
+			fnError:       errors.New("book not found"),
+			expectedCalls: 1,
+			expectedError: errors.New("book not found"),
+		},
+		{
+			description: "retried after a serialization failure",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE books").WillReturnError(conflict)
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE books").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedCalls: 2,
+		},
+		{
+			description: "deadlock on commit is retried",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE books").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit().WillReturnError(&pq.Error{Code: codeDeadlockDetected})
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE books").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedCalls: 2,
+		},
+		{
+			description: "gives up after the last attempt",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				for range maxTxAttempts {
+					mock.ExpectBegin()
+					mock.ExpectExec("UPDATE books").WillReturnError(conflict)
+					mock.ExpectRollback()
+				}
+			},
+			expectedCalls: maxTxAttempts,
+			expectedError: conflict,
+		},
+		{
+			description: "other errors are not retried",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE books").WillReturnError(errors.New("update failed"))
+				mock.ExpectRollback()
+			},
+			expectedCalls: 1,
+			expectedError: errors.New("update failed"),
+		},
+		{
+			description: "nested run joins the outer transaction",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE books").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			nested:        true,
+			expectedCalls: 1,
+		},
+		{
+			description: "begin failed",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin().WillReturnError(errors.New("begin failed"))
+			},
+			expectedError: errors.New("begin failed"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			transactor := NewTransactor(db, hclog.NewNullLogger())
+
+			testCase.setupMock(mock)
+
+			calls := 0
+			update := func(ctx context.Context) error {
+				calls++
+				_, ok := conn(ctx, db).(*sql.Tx)
+				assert.True(t, ok)
+
+				_, err := conn(ctx, db).ExecContext(ctx, "UPDATE books SET title = $1", "It")
+				if err != nil {
+					return err
+				}
+
+				return testCase.fnError
+			}
+
+			fn := update
+			if testCase.nested {
+				fn = func(ctx context.Context) error {
+					return transactor.Run(ctx, update)
+				}
+			}
+
+			err = transactor.Run(context.Background(), fn)
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedCalls, calls)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
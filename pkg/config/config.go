@@ -1,25 +1,73 @@
 package config
 
 import (
-	"github.com/caarlos0/env/v11"
+	"library-api/pkg/db"
+	"library-api/pkg/tracing"
+	"time"
 )
 
+// Config is the service configuration. Every field is named by its
+// environment variable; the same name in lower case is its key in a config
+// file, and in lower case with dashes its command line flag. Fields tagged
+// secret are hidden when the configuration is printed redacted, and fields
+// tagged reload take effect without a restart when the server gets SIGHUP.
 type Config struct {
-	Port   string `env:"PORT,required"`
-	DbConn string `env:"DB_CONN,required"`
-}
+	Port             string        `env:"PORT,required"`
+	DbConn           string        `env:"DB_CONN,required" secret:"true"`
+	JWTSecret        string        `env:"JWT_SECRET" secret:"true"`
+	JWTPublicKey     string        `env:"JWT_PUBLIC_KEY"`
+	JWTPrivateKey    string        `env:"JWT_PRIVATE_KEY" secret:"true"`
+	JWTIssuer        string        `env:"JWT_ISSUER"`
+	JWTAudience      string        `env:"JWT_AUDIENCE"`
+	AccessTokenTTL   time.Duration `env:"ACCESS_TOKEN_TTL" envDefault:"15m"`
+	RefreshTokenTTL  time.Duration `env:"REFRESH_TOKEN_TTL" envDefault:"720h"`
+	PasswordResetTTL time.Duration `env:"PASSWORD_RESET_TTL" envDefault:"1h"`
+	MembershipSweep  time.Duration `env:"MEMBERSHIP_SWEEP_INTERVAL" envDefault:"1h"`
+	PurgeRetention   time.Duration `env:"PURGE_RETENTION" envDefault:"720h"`
+	RequireIfMatch   bool          `env:"REQUIRE_IF_MATCH" envDefault:"false"`
+	IdempotencyTTL   time.Duration `env:"IDEMPOTENCY_KEY_TTL" envDefault:"24h"`
+	IdempotencySweep time.Duration `env:"IDEMPOTENCY_SWEEP_INTERVAL" envDefault:"1h"`
+	RequestTimeout   time.Duration `env:"REQUEST_TIMEOUT" envDefault:"30s" reload:"true"`
 
-var C Config
+	ShutdownDrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY" envDefault:"5s"`
+	ShutdownTimeout    time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"20s"`
+	HealthCheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" envDefault:"1s"`
 
-func Load() error {
-	err := env.Parse(&C)
-	if err != nil {
-		return err
-	}
+	LogLevel         string        `env:"LOG_LEVEL" envDefault:"debug" reload:"true"`
+	CORSAllowOrigins string        `env:"CORS_ALLOW_ORIGINS" envDefault:"*" reload:"true"`
+	RateLimit        int           `env:"RATE_LIMIT" envDefault:"0" reload:"true"`
+	RateLimitWindow  time.Duration `env:"RATE_LIMIT_WINDOW" envDefault:"1m" reload:"true"`
+	MaxLoans         int           `env:"MAX_LOANS_PER_MEMBER" envDefault:"0" reload:"true"`
+
+	DBMaxOpenConns     int           `env:"DB_MAX_OPEN_CONNS" envDefault:"20"`
+	DBMaxIdleConns     int           `env:"DB_MAX_IDLE_CONNS" envDefault:"10"`
+	DBConnMaxLifetime  time.Duration `env:"DB_CONN_MAX_LIFETIME" envDefault:"30m"`
+	DBConnMaxIdleTime  time.Duration `env:"DB_CONN_MAX_IDLE_TIME" envDefault:"5m"`
+	DBStatementTimeout time.Duration `env:"DB_STATEMENT_TIMEOUT" envDefault:"0s"`
+	DBConnectAttempts  int           `env:"DB_CONNECT_ATTEMPTS" envDefault:"5"`
+	DBConnectBackoff   time.Duration `env:"DB_CONNECT_BACKOFF" envDefault:"1s"`
+
+	TracingEndpoint    string  `env:"TRACING_ENDPOINT"`
+	TracingSampleRatio float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
 
-	return nil
+	NotifyWebhookURL string `env:"NOTIFY_WEBHOOK_URL" secret:"true"`
 }
 
-func Get() *Config {
-	return &C
+func (c *Config) Database() db.Options {
+	return db.Options{
+		MaxOpenConns:     c.DBMaxOpenConns,
+		MaxIdleConns:     c.DBMaxIdleConns,
+		ConnMaxLifetime:  c.DBConnMaxLifetime,
+		ConnMaxIdleTime:  c.DBConnMaxIdleTime,
+		StatementTimeout: c.DBStatementTimeout,
+		ConnectAttempts:  c.DBConnectAttempts,
+		ConnectBackoff:   c.DBConnectBackoff,
+	}
+}
+
+func (c *Config) Tracing() tracing.Options {
+	return tracing.Options{
+		Endpoint:    c.TracingEndpoint,
+		SampleRatio: c.TracingSampleRatio,
+	}
 }
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	secret := write("jwt_secret", "from-file\n")
+	file := write("config.yaml", `
+port: 8080
+db:
+  conn: host=file
+  max_open_conns: 40
+request_timeout: 10s
+`)
+	unknown := write("unknown.yaml", `
+port: 8080
+db_conection: host=file
+`)
+
+	required := []string{"DB_CONN=host=env", "JWT_SECRET=secret"}
+	testCases := []struct {
+		description      string
+		sources          Sources
+		check            func(t *testing.T, c *Config)
+		expectedProblems Problems
+	}{
+		{
+			description: "defaults",
+			sources:     Sources{Environ: append([]string{"PORT=8080"}, required...)},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, "8080", c.Port)
+				assert.Equal(t, 30*time.Second, c.RequestTimeout)
+				assert.Equal(t, 20, c.DBMaxOpenConns)
+			},
+		},
+		{
+			description: "file under environment under flags",
+			sources: Sources{
+				File:    file,
+				Environ: []string{"DB_CONN=host=env", "JWT_SECRET=secret", "REQUEST_TIMEOUT=20s"},
+				Flags:   map[string]string{"REQUEST_TIMEOUT": "40s"},
+			},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, "8080", c.Port)
+				assert.Equal(t, "host=env", c.DbConn)
+				assert.Equal(t, 40, c.DBMaxOpenConns)
+				assert.Equal(t, 40*time.Second, c.RequestTimeout)
+			},
+		},
+		{
+			description: "file named by the environment",
+			sources:     Sources{Environ: []string{"CONFIG_FILE=" + file, "JWT_SECRET=secret"}},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, "host=file", c.DbConn)
+			},
+		},
+		{
+			description: "secret read from a file",
+			sources:     Sources{Environ: []string{"PORT=8080", "DB_CONN=host=env", "JWT_SECRET_FILE=" + secret}},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, "from-file", c.JWTSecret)
+			},
+		},
+		{
+			description: "flag replaces a secret file from the environment",
+			sources: Sources{
+				Environ: []string{"PORT=8080", "DB_CONN=host=env", "JWT_SECRET_FILE=" + secret},
+				Flags:   map[string]string{"JWT_SECRET": "from-flag"},
+			},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, "from-flag", c.JWTSecret)
+			},
+		},
+		{
+			description: "every problem is reported",
+			sources: Sources{
+				File: unknown,
+				Environ: []string{"PORT=80800", "JWT_SECRET=secret", "JWT_SECRET_FILE=" + secret, "DB_CONNECT_ATTEMPTS=0", "DB_MAX_IDLE_CONNS=30",
+					"DB_CONNECT_BACKOFF=soon"},
+			},
+			expectedProblems: Problems{
+				`unknown setting "db_conection" in ` + unknown,
+				"JWT_SECRET and JWT_SECRET_FILE are both set in the environment",
+				"DB_CONN is required",
+				"DB_CONNECT_BACKOFF must be a valid time.Duration",
+				`PORT must be a number between 1 and 65535, got "80800"`,
+				"DB_MAX_IDLE_CONNS (30) must not exceed DB_MAX_OPEN_CONNS (20)",
+				"DB_CONNECT_ATTEMPTS must be at least 1, got 0",
+			},
+		},
+		{
+			description: "invalid runtime settings",
+			sources: Sources{Environ: append([]string{"PORT=8080", "LOG_LEVEL=loud", "CORS_ALLOW_ORIGINS=https://*.example.org, example.org",
+				"RATE_LIMIT=-1", "RATE_LIMIT_WINDOW=500ms", "MAX_LOANS_PER_MEMBER=-2", "TRACING_ENDPOINT=collector:4318",
+				"TRACING_SAMPLE_RATIO=1.5", "NOTIFY_WEBHOOK_URL=hooks.example.org/notify"}, required...)},
+			expectedProblems: Problems{
+				`LOG_LEVEL must be one of trace, debug, info, warn, error or off, got "loud"`,
+				`CORS_ALLOW_ORIGINS must be * or a list of origins like https://example.org, got " example.org"`,
+				"RATE_LIMIT must not be negative, got -1",
+				"RATE_LIMIT_WINDOW must be at least 1s, got 500ms",
+				"MAX_LOANS_PER_MEMBER must not be negative, got -2",
+				`TRACING_ENDPOINT must be an http or https URL, got "collector:4318"`,
+				"TRACING_SAMPLE_RATIO must be between 0 and 1, got 1.5",
+				"NOTIFY_WEBHOOK_URL must be an http or https URL",
+			},
+		},
+		{
+			description:      "request timeout must be positive",
+			sources:          Sources{Environ: append([]string{"PORT=8080", "REQUEST_TIMEOUT=0s"}, required...)},
+			expectedProblems: Problems{"REQUEST_TIMEOUT must be positive, got 0s"},
+		},
+		{
+			description:      "health check timeout must be positive",
+			sources:          Sources{Environ: append([]string{"PORT=8080", "HEALTH_CHECK_TIMEOUT=0s"}, required...)},
+			expectedProblems: Problems{"HEALTH_CHECK_TIMEOUT must be positive, got 0s"},
+		},
+		{
+			description: "missing secret file and keys",
+			sources:     Sources{Environ: []string{"PORT=8080", "DB_CONN=host=env", "JWT_SECRET_FILE=" + filepath.Join(dir, "missing")}},
+			expectedProblems: Problems{
+				"JWT_SECRET_FILE: open " + filepath.Join(dir, "missing") + ": no such file or directory",
+				"JWT_SECRET or JWT_PUBLIC_KEY is required to verify tokens",
+				"JWT_SECRET or JWT_PRIVATE_KEY is required to sign tokens",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			c, err := Load(testCase.sources)
+			if testCase.expectedProblems != nil {
+				assert.Equal(t, testCase.expectedProblems, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			testCase.check(t, c)
+		})
+	}
+}
+
+func TestConfig_Settings(t *testing.T) {
+	c := Config{Port: "8080", DbConn: "host=db password=qwerty123", RequestTimeout: time.Minute}
+
+	testCases := []struct {
+		description string
+		redact      bool
+		expected    map[string]string
+	}{
+		{
+			description: "plain",
+			expected: map[string]string{
+				"PORT":            "8080",
+				"DB_CONN":         "host=db password=qwerty123",
+				"JWT_SECRET":      "",
+				"REQUEST_TIMEOUT": "1m0s",
+			},
+		},
+		{
+			description: "redacted",
+			redact:      true,
+			expected: map[string]string{
+				"PORT":            "8080",
+				"DB_CONN":         "[redacted]",
+				"JWT_SECRET":      "",
+				"REQUEST_TIMEOUT": "1m0s",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			settings := c.Settings(testCase.redact)
+			assert.Len(t, settings, len(Keys()))
+
+			actual := make(map[string]string)
+			for _, setting := range settings {
+				if _, ok := testCase.expected[setting.Key]; ok {
+					actual[setting.Key] = setting.Value
+				}
+			}
+			assert.Equal(t, testCase.expected, actual)
+		})
+	}
+}
+
+func TestConfig_Diff(t *testing.T) {
+	current := Config{Port: "8080", DbConn: "host=db", LogLevel: "info", RateLimit: 100}
+
+	testCases := []struct {
+		description string
+		next        Config
+		expected    []Change
+	}{
+		{
+			description: "nothing changed",
+			next:        current,
+		},
+		{
+			description: "reloadable and fixed settings",
+			next:        Config{Port: "9090", DbConn: "host=replica", LogLevel: "warn", RateLimit: 100},
+			expected: []Change{
+				{Key: "PORT", From: "8080", To: "9090"},
+				{Key: "DB_CONN", From: "[redacted]", To: "[redacted]"},
+				{Key: "LOG_LEVEL", From: "info", To: "warn", Reloadable: true},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, current.Diff(&testCase.next))
+		})
+	}
+}
+
+func TestConfig_Reload(t *testing.T) {
+	current := &Config{Port: "8080", LogLevel: "info", RequestTimeout: time.Second, MaxLoans: 3}
+	next := &Config{Port: "9090", LogLevel: "error", RequestTimeout: time.Minute, MaxLoans: 5}
+
+	actual := current.Reload(next)
+
+	assert.Equal(t, &Config{Port: "8080", LogLevel: "error", RequestTimeout: time.Minute, MaxLoans: 5}, actual)
+	assert.Equal(t, "info", current.LogLevel)
+}
@@ -0,0 +1,312 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the environment variable that points at a config file when
+// no file is given explicitly.
+const FileEnv = "CONFIG_FILE"
+
+// fileSuffix marks a setting whose value is read from the named file, the
+// way container secrets are mounted.
+const fileSuffix = "_FILE"
+
+// Sources are the layers a configuration is built from. Later layers win:
+// defaults, then the YAML file, then the environment, then flags.
+type Sources struct {
+	File    string
+	Environ []string
+	Flags   map[string]string
+}
+
+// Problems lists everything that is wrong with a configuration, so that it
+// can be fixed in one go.
+type Problems []string
+
+func (p Problems) Error() string {
+	return "invalid configuration:\n  " + strings.Join(p, "\n  ")
+}
+
+// Load builds the configuration from its sources and validates it. Any
+// problem makes it fail with Problems.
+func Load(sources Sources) (*Config, error) {
+	var problems Problems
+	known := Keys()
+	values := make(map[string]string)
+
+	environ := make(map[string]string)
+	for _, variable := range sources.Environ {
+		key, value, _ := strings.Cut(variable, "=")
+		environ[key] = value
+	}
+
+	file := sources.File
+	if file == "" {
+		file = environ[FileEnv]
+	}
+
+	// apply lays one source over the values collected so far. Unknown keys
+	// are only an error where the source is ours alone.
+	apply := func(source string, settings map[string]string, strict bool) {
+		for _, key := range sortedKeys(settings) {
+			if !isKey(known, key) {
+				if strict {
+					problems = append(problems, fmt.Sprintf("unknown setting %q in %s", strings.ToLower(key), source))
+				}
+
+				continue
+			}
+
+			if _, ok := settings[key+fileSuffix]; ok {
+				problems = append(problems, fmt.Sprintf("%s and %s%s are both set in %s", key, key, fileSuffix, source))
+			}
+
+			set(values, key, settings[key])
+		}
+	}
+
+	if file != "" {
+		settings, err := readFile(file)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+
+		apply(file, settings, true)
+	}
+
+	apply("the environment", environ, false)
+	apply("flags", sources.Flags, true)
+
+	for _, key := range known {
+		path, ok := values[key+fileSuffix]
+		if !ok {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s%s: %v", key, fileSuffix, err))
+			continue
+		}
+
+		values[key] = strings.TrimRight(string(content), "\r\n")
+	}
+
+	var c Config
+	err := env.ParseWithOptions(&c, env.Options{Environment: values})
+	if err != nil {
+		var aggregate env.AggregateError
+		if errors.As(err, &aggregate) {
+			for _, err := range aggregate.Errors {
+				problems = append(problems, describe(err))
+			}
+		} else {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	problems = append(problems, c.validate()...)
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	return &c, nil
+}
+
+// describe words a parsing error in terms of settings rather than the
+// environment variables and struct fields the env package knows about.
+func describe(err error) string {
+	var notSet env.VarIsNotSetError
+	if errors.As(err, &notSet) {
+		return notSet.Key + " is required"
+	}
+
+	var parseError env.ParseError
+	if errors.As(err, &parseError) {
+		field, ok := reflect.TypeOf(Config{}).FieldByName(parseError.Name)
+		if ok {
+			name, _, _ := strings.Cut(field.Tag.Get("env"), ",")
+			return fmt.Sprintf("%s must be a valid %s", name, parseError.Type)
+		}
+	}
+
+	return err.Error()
+}
+
+// Keys returns the names of all settings in declaration order.
+func Keys() []string {
+	var keys []string
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("env"), ",")
+		keys = append(keys, name)
+	}
+
+	return keys
+}
+
+// Setting is one configuration value in printable form.
+type Setting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Settings lists every value of c. With redact set, secrets that are set are
+// replaced by a placeholder.
+func (c *Config) Settings(redact bool) []Setting {
+	var settings []Setting
+	v := reflect.ValueOf(*c)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("env"), ",")
+		settings = append(settings, Setting{Key: name, Value: format(t.Field(i), v.Field(i), redact)})
+	}
+
+	return settings
+}
+
+// Change is a setting that differs between two configurations. Reloadable
+// changes can be applied to a running server, the others need a restart.
+type Change struct {
+	Key        string
+	From       string
+	To         string
+	Reloadable bool
+}
+
+// Diff lists the settings that differ between c and next, with secrets
+// redacted.
+func (c *Config) Diff(next *Config) []Change {
+	var changes []Change
+	before, after := reflect.ValueOf(*c), reflect.ValueOf(*next)
+	t := before.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if reflect.DeepEqual(before.Field(i).Interface(), after.Field(i).Interface()) {
+			continue
+		}
+
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("env"), ",")
+		changes = append(changes, Change{
+			Key:        name,
+			From:       format(t.Field(i), before.Field(i), true),
+			To:         format(t.Field(i), after.Field(i), true),
+			Reloadable: t.Field(i).Tag.Get("reload") == "true",
+		})
+	}
+
+	return changes
+}
+
+// Reload returns a copy of c with the reloadable settings of next. The other
+// settings keep the values the server was started with.
+func (c *Config) Reload(next *Config) *Config {
+	reloaded := *c
+	target, source := reflect.ValueOf(&reloaded).Elem(), reflect.ValueOf(*next)
+	t := target.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("reload") == "true" {
+			target.Field(i).Set(source.Field(i))
+		}
+	}
+
+	return &reloaded
+}
+
+func format(field reflect.StructField, v reflect.Value, redact bool) string {
+	value := fmt.Sprint(v.Interface())
+	if d, ok := v.Interface().(time.Duration); ok {
+		value = d.String()
+	}
+
+	if redact && field.Tag.Get("secret") == "true" && value != "" {
+		value = "[redacted]"
+	}
+
+	return value
+}
+
+// set stores a value and drops the other spelling of the same setting, so
+// that a later layer's JWT_SECRET replaces an earlier JWT_SECRET_FILE and the
+// other way round.
+func set(values map[string]string, key string, value string) {
+	if base, ok := strings.CutSuffix(key, fileSuffix); ok {
+		delete(values, base)
+	} else {
+		delete(values, key+fileSuffix)
+	}
+
+	values[key] = value
+}
+
+func isKey(known []string, key string) bool {
+	key = strings.TrimSuffix(key, fileSuffix)
+	for _, k := range known {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// readFile reads a YAML config file into settings keyed like environment
+// variables. Nested maps are joined with underscores, so db: {conn: ...}
+// sets DB_CONN just like db_conn: ... does.
+func readFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var document map[string]any
+	err = yaml.Unmarshal(content, &document)
+	if err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	settings := make(map[string]string)
+	var problems Problems
+	flatten(document, "", settings, &problems)
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return settings, fmt.Errorf("config file %s: %s", path, strings.Join(problems, ", "))
+	}
+
+	return settings, nil
+}
+
+func flatten(document map[string]any, prefix string, settings map[string]string, problems *Problems) {
+	for key, value := range document {
+		name := prefix + strings.ToUpper(key)
+		switch v := value.(type) {
+		case map[string]any:
+			flatten(v, name+"_", settings, problems)
+		case []any:
+			*problems = append(*problems, fmt.Sprintf("%s must be a single value", strings.ToLower(name)))
+		case nil:
+			settings[name] = ""
+		default:
+			settings[name] = fmt.Sprint(v)
+		}
+	}
+}
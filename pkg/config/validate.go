@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// validate checks the rules the types alone do not express.
+func (c *Config) validate() Problems {
+	var problems Problems
+
+	if c.Port != "" {
+		port, err := strconv.Atoi(c.Port)
+		if err != nil || port < 1 || port > 65535 {
+			problems = append(problems, fmt.Sprintf("PORT must be a number between 1 and 65535, got %q", c.Port))
+		}
+	}
+
+	if c.JWTSecret == "" && c.JWTPublicKey == "" {
+		problems = append(problems, "JWT_SECRET or JWT_PUBLIC_KEY is required to verify tokens")
+	}
+
+	if c.JWTSecret == "" && c.JWTPrivateKey == "" {
+		problems = append(problems, "JWT_SECRET or JWT_PRIVATE_KEY is required to sign tokens")
+	}
+
+	positive := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"ACCESS_TOKEN_TTL", c.AccessTokenTTL},
+		{"REFRESH_TOKEN_TTL", c.RefreshTokenTTL},
+		{"PASSWORD_RESET_TTL", c.PasswordResetTTL},
+		{"MEMBERSHIP_SWEEP_INTERVAL", c.MembershipSweep},
+		{"PURGE_RETENTION", c.PurgeRetention},
+		{"IDEMPOTENCY_KEY_TTL", c.IdempotencyTTL},
+		{"IDEMPOTENCY_SWEEP_INTERVAL", c.IdempotencySweep},
+		{"REQUEST_TIMEOUT", c.RequestTimeout},
+		{"SHUTDOWN_TIMEOUT", c.ShutdownTimeout},
+		{"HEALTH_CHECK_TIMEOUT", c.HealthCheckTimeout},
+	}
+	for _, setting := range positive {
+		if setting.value <= 0 {
+			problems = append(problems, fmt.Sprintf("%s must be positive, got %s", setting.key, setting.value))
+		}
+	}
+
+	notNegative := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"DB_CONN_MAX_LIFETIME", c.DBConnMaxLifetime},
+		{"DB_CONN_MAX_IDLE_TIME", c.DBConnMaxIdleTime},
+		{"DB_STATEMENT_TIMEOUT", c.DBStatementTimeout},
+		{"DB_CONNECT_BACKOFF", c.DBConnectBackoff},
+		{"SHUTDOWN_DRAIN_DELAY", c.ShutdownDrainDelay},
+	}
+	for _, setting := range notNegative {
+		if setting.value < 0 {
+			problems = append(problems, fmt.Sprintf("%s must not be negative, got %s", setting.key, setting.value))
+		}
+	}
+
+	if c.DBMaxOpenConns < 0 {
+		problems = append(problems, fmt.Sprintf("DB_MAX_OPEN_CONNS must not be negative, got %d", c.DBMaxOpenConns))
+	}
+
+	if c.DBMaxIdleConns < 0 {
+		problems = append(problems, fmt.Sprintf("DB_MAX_IDLE_CONNS must not be negative, got %d", c.DBMaxIdleConns))
+	}
+
+	if c.DBMaxOpenConns > 0 && c.DBMaxIdleConns > c.DBMaxOpenConns {
+		problems = append(problems, fmt.Sprintf("DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)",
+			c.DBMaxIdleConns, c.DBMaxOpenConns))
+	}
+
+	if c.DBConnectAttempts < 1 {
+		problems = append(problems, fmt.Sprintf("DB_CONNECT_ATTEMPTS must be at least 1, got %d", c.DBConnectAttempts))
+	}
+
+	if hclog.LevelFromString(c.LogLevel) == hclog.NoLevel {
+		problems = append(problems, fmt.Sprintf("LOG_LEVEL must be one of trace, debug, info, warn, error or off, got %q", c.LogLevel))
+	}
+
+	if c.CORSAllowOrigins != "*" {
+		for _, origin := range strings.Split(c.CORSAllowOrigins, ",") {
+			if !validOrigin(strings.TrimSpace(origin)) {
+				problems = append(problems, fmt.Sprintf("CORS_ALLOW_ORIGINS must be * or a list of origins like https://example.org, got %q", origin))
+			}
+		}
+	}
+
+	if c.RateLimit < 0 {
+		problems = append(problems, fmt.Sprintf("RATE_LIMIT must not be negative, got %d", c.RateLimit))
+	}
+
+	if c.RateLimitWindow < time.Second {
+		problems = append(problems, fmt.Sprintf("RATE_LIMIT_WINDOW must be at least 1s, got %s", c.RateLimitWindow))
+	}
+
+	if c.MaxLoans < 0 {
+		problems = append(problems, fmt.Sprintf("MAX_LOANS_PER_MEMBER must not be negative, got %d", c.MaxLoans))
+	}
+
+	if c.TracingEndpoint != "" {
+		u, err := url.Parse(c.TracingEndpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("TRACING_ENDPOINT must be an http or https URL, got %q", c.TracingEndpoint))
+		}
+	}
+
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		problems = append(problems, fmt.Sprintf("TRACING_SAMPLE_RATIO must be between 0 and 1, got %g", c.TracingSampleRatio))
+	}
+
+	if c.NotifyWebhookURL != "" {
+		u, err := url.Parse(c.NotifyWebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, "NOTIFY_WEBHOOK_URL must be an http or https URL")
+		}
+	}
+
+	return problems
+}
+
+// validOrigin accepts what the CORS middleware accepts: a scheme and a host,
+// optionally with a *. wildcard for subdomains, and nothing else.
+func validOrigin(origin string) bool {
+	u, err := url.Parse(strings.Replace(origin, "://*.", "://", 1))
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.Path == "" &&
+		u.RawQuery == "" && u.Fragment == "" && u.User == nil
+}
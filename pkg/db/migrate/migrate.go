@@ -0,0 +1,334 @@
+// Package migrate applies the numbered SQL migrations to a database and
+// records the schema version. The version table has the layout golang-migrate
+// uses, so databases migrated by it are picked up where they left off.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// lockID names the advisory lock held while migrating, so that instances
+// started together do not apply the same migration twice.
+const lockID int64 = 4_723_119_058
+
+const createVersionTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL PRIMARY KEY,
+    dirty   BOOLEAN NOT NULL)`
+
+var (
+	ErrDirty          = errors.New("database is dirty")
+	ErrUnknownVersion = errors.New("unknown migration version")
+	ErrPending        = errors.New("migrations are pending")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version uint   `json:"version"`
+	Name    string `json:"name"`
+	up      string
+	down    string
+}
+
+type Status struct {
+	Version uint        `json:"version"`
+	Dirty   bool        `json:"dirty"`
+	Applied []Migration `json:"applied"`
+	Pending []Migration `json:"pending"`
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	logger     hclog.Logger
+}
+
+// New reads the migrations from source. Every version needs both an up and
+// a down file.
+func New(db *sql.DB, source fs.FS, logger hclog.Logger) (*Migrator, error) {
+	files, err := fs.Glob(source, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, file := range files {
+		match := fileName.FindStringSubmatch(file)
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: file name must look like 001_name.up.sql", file)
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("migration %s: invalid version", file)
+		}
+
+		content, err := fs.ReadFile(source, file)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d: conflicting names %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.up = string(content)
+		} else {
+			migration.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.up == "" || migration.down == "" {
+			return nil, fmt.Errorf("migration %d_%s: needs both an up and a down file", migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		logger:     logger,
+	}, nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	if len(m.migrations) == 0 {
+		return nil
+	}
+
+	return m.Goto(ctx, m.migrations[len(m.migrations)-1].Version)
+}
+
+// Down rolls back the given number of applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1")
+	}
+
+	return m.run(ctx, func(applied int) uint {
+		if applied <= steps {
+			return 0
+		}
+
+		return m.migrations[applied-steps-1].Version
+	})
+}
+
+// Goto migrates up or down until the database is at version. Version 0
+// rolls back everything.
+func (m *Migrator) Goto(ctx context.Context, version uint) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return m.run(ctx, func(int) uint {
+		return version
+	})
+}
+
+// Status reports the version the database is at and which migrations are
+// still pending.
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	_, err := m.db.ExecContext(ctx, createVersionTable)
+	if err != nil {
+		return nil, err
+	}
+
+	version, dirty, err := currentVersion(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{Version: version, Dirty: dirty}
+	for _, migration := range m.migrations {
+		if migration.Version <= version {
+			status.Applied = append(status.Applied, migration)
+		} else {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+
+	return status, nil
+}
+
+// Check reports whether the database has every known migration applied. It
+// only reads, so it can back a readiness probe. A database ahead of the known
+// migrations passes, as it does while a newer release rolls out.
+func (m *Migrator) Check(ctx context.Context) error {
+	version, dirty, err := currentVersion(ctx, m.db)
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("%w at version %d", ErrDirty, version)
+	}
+
+	if len(m.migrations) > 0 && version < m.migrations[len(m.migrations)-1].Version {
+		return fmt.Errorf("%w: database is at %d, expected %d", ErrPending, version, m.migrations[len(m.migrations)-1].Version)
+	}
+
+	return nil
+}
+
+// run holds the advisory lock while it moves the database to the version
+// chosen by target. target gets the number of applied migrations.
+func (m *Migrator) run(ctx context.Context, target func(applied int) uint) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockID)
+		if err != nil {
+			m.logger.Error("releasing migration lock failed", "error", err.Error())
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, createVersionTable)
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := currentVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("%w at version %d: repair the schema and fix schema_migrations by hand", ErrDirty, version)
+	}
+
+	current := m.index(version)
+	if version != 0 && current < 0 {
+		return fmt.Errorf("%w: database is at %d", ErrUnknownVersion, version)
+	}
+
+	to := target(current + 1)
+	if to == version {
+		m.logger.Info("schema is up to date", "version", version)
+		return nil
+	}
+
+	for i := current + 1; i < len(m.migrations) && m.migrations[i].Version <= to; i++ {
+		err = m.apply(ctx, conn, m.migrations[i].up, m.migrations[i], m.migrations[i].Version)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := current; i >= 0 && m.migrations[i].Version > to; i-- {
+		var previous uint
+		if i > 0 {
+			previous = m.migrations[i-1].Version
+		}
+
+		err = m.apply(ctx, conn, m.migrations[i].down, m.migrations[i], previous)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply runs one migration file and records the new version in the same
+// transaction, so a failed migration leaves both untouched.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, script string, migration Migration, version uint) error {
+	direction := "up"
+	if version < migration.Version {
+		direction = "down"
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, script)
+	if err != nil {
+		return fmt.Errorf("migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations")
+	if err != nil {
+		return err
+	}
+
+	if version != 0 {
+		_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", version)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("migration applied", "version", migration.Version, "name", migration.Name, "direction", direction)
+	return nil
+}
+
+// index returns the position of version in the migrations, or -1.
+func (m *Migrator) index(version uint) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+
+	return -1
+}
+
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func currentVersion(ctx context.Context, q querier) (uint, bool, error) {
+	var version uint
+	var dirty bool
+
+	err := q.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	return version, dirty, nil
+}
@@ -0,0 +1,330 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"library-api/pkg/db/migrations"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+var source = fstest.MapFS{
+	"001_create_authors.up.sql":   {Data: []byte("CREATE TABLE authors()")},
+	"001_create_authors.down.sql": {Data: []byte("DROP TABLE authors")},
+	"002_create_books.up.sql":     {Data: []byte("CREATE TABLE books()")},
+	"002_create_books.down.sql":   {Data: []byte("DROP TABLE books")},
+	"003_add_isbn.up.sql":         {Data: []byte("ALTER TABLE books ADD COLUMN isbn TEXT")},
+	"003_add_isbn.down.sql":       {Data: []byte("ALTER TABLE books DROP COLUMN isbn")},
+}
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		description      string
+		source           fs.FS
+		expectedVersions []uint
+		expectedError    string
+	}{
+		{
+			description:      "sorted by version",
+			source:           source,
+			expectedVersions: []uint{1, 2, 3},
+		},
+		{
+			description:      "embedded schema",
+			source:           migrations.FS,
+			expectedVersions: []uint{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14},
+		},
+		{
+			description: "down file missing",
+			source: fstest.MapFS{
+				"001_create_authors.up.sql": {Data: []byte("CREATE TABLE authors()")},
+			},
+			expectedError: "migration 1_create_authors: needs both an up and a down file",
+		},
+		{
+			description: "badly named file",
+			source: fstest.MapFS{
+				"create_authors.sql": {Data: []byte("CREATE TABLE authors()")},
+			},
+			expectedError: "migration create_authors.sql: file name must look like 001_name.up.sql",
+		},
+		{
+			description: "two names for one version",
+			source: fstest.MapFS{
+				"001_create_authors.up.sql": {Data: []byte("CREATE TABLE authors()")},
+				"001_create_books.down.sql": {Data: []byte("DROP TABLE books")},
+			},
+			expectedError: "migration 1: conflicting names create_authors and create_books",
+		},
+		{
+			description:      "no migrations",
+			source:           fstest.MapFS{},
+			expectedVersions: []uint{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			m, err := New(nil, testCase.source, hclog.NewNullLogger())
+			if testCase.expectedError != "" {
+				assert.EqualError(t, err, testCase.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			versions := []uint{}
+			for _, migration := range m.migrations {
+				versions = append(versions, migration.Version)
+			}
+			assert.Equal(t, testCase.expectedVersions, versions)
+		})
+	}
+}
+
+func TestMigrator_Run(t *testing.T) {
+	testCases := []struct {
+		description   string
+		current       *uint
+		dirty         bool
+		run           func(m *Migrator) error
+		steps         []string
+		versions      []uint
+		stepError     error
+		expectedError error
+	}{
+		{
+			description: "up from an empty database",
+			run:         func(m *Migrator) error { return m.Up(context.Background()) },
+			steps:       []string{"CREATE TABLE authors", "CREATE TABLE books", "ALTER TABLE books ADD COLUMN isbn"},
+			versions:    []uint{1, 2, 3},
+		},
+		{
+			description: "up applies only pending migrations",
+			current:     version(2),
+			run:         func(m *Migrator) error { return m.Up(context.Background()) },
+			steps:       []string{"ALTER TABLE books ADD COLUMN isbn"},
+			versions:    []uint{3},
+		},
+		{
+			description: "up to date",
+			current:     version(3),
+			run:         func(m *Migrator) error { return m.Up(context.Background()) },
+		},
+		{
+			description: "down one step",
+			current:     version(3),
+			run:         func(m *Migrator) error { return m.Down(context.Background(), 1) },
+			steps:       []string{"ALTER TABLE books DROP COLUMN isbn"},
+			versions:    []uint{2},
+		},
+		{
+			description: "down past the first migration",
+			current:     version(2),
+			run:         func(m *Migrator) error { return m.Down(context.Background(), 5) },
+			steps:       []string{"DROP TABLE books", "DROP TABLE authors"},
+			versions:    []uint{1, 0},
+		},
+		{
+			description: "goto an earlier version",
+			current:     version(3),
+			run:         func(m *Migrator) error { return m.Goto(context.Background(), 1) },
+			steps:       []string{"ALTER TABLE books DROP COLUMN isbn", "DROP TABLE books"},
+			versions:    []uint{2, 1},
+		},
+		{
+			description: "goto a later version",
+			current:     version(1),
+			run:         func(m *Migrator) error { return m.Goto(context.Background(), 2) },
+			steps:       []string{"CREATE TABLE books"},
+			versions:    []uint{2},
+		},
+		{
+			description:   "dirty database",
+			current:       version(2),
+			dirty:         true,
+			run:           func(m *Migrator) error { return m.Up(context.Background()) },
+			expectedError: ErrDirty,
+		},
+		{
+			description:   "database ahead of the binary",
+			current:       version(7),
+			run:           func(m *Migrator) error { return m.Up(context.Background()) },
+			expectedError: ErrUnknownVersion,
+		},
+		{
+			description:   "failed migration is rolled back",
+			current:       version(1),
+			run:           func(m *Migrator) error { return m.Up(context.Background()) },
+			steps:         []string{"CREATE TABLE books"},
+			stepError:     errors.New("syntax error"),
+			expectedError: errors.New("syntax error"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectExec("SELECT pg_advisory_lock\\(\\$1\\)").WithArgs(lockID).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			rows := sqlmock.NewRows([]string{"version", "dirty"})
+			if testCase.current != nil {
+				rows.AddRow(*testCase.current, testCase.dirty)
+			}
+			mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").WillReturnRows(rows)
+
+			for i, step := range testCase.steps {
+				mock.ExpectBegin()
+				if testCase.stepError != nil {
+					mock.ExpectExec(step).WillReturnError(testCase.stepError)
+					mock.ExpectRollback()
+					break
+				}
+
+				mock.ExpectExec(step).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DELETE FROM schema_migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+				if testCase.versions[i] != 0 {
+					mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(testCase.versions[i]).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+				mock.ExpectCommit()
+			}
+
+			mock.ExpectExec("SELECT pg_advisory_unlock\\(\\$1\\)").WithArgs(lockID).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			m, err := New(db, source, hclog.NewNullLogger())
+			assert.NoError(t, err)
+
+			err = testCase.run(m)
+			if testCase.expectedError != nil {
+				assert.ErrorContains(t, err, testCase.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMigrator_Goto(t *testing.T) {
+	m, err := New(nil, source, hclog.NewNullLogger())
+	assert.NoError(t, err)
+
+	err = m.Goto(context.Background(), 9)
+	assert.ErrorIs(t, err, ErrUnknownVersion)
+}
+
+func TestMigrator_Status(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(2, false))
+
+	m, err := New(db, source, hclog.NewNullLogger())
+	assert.NoError(t, err)
+
+	status, err := m.Status(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), status.Version)
+	assert.False(t, status.Dirty)
+	assert.Len(t, status.Applied, 2)
+	assert.Len(t, status.Pending, 1)
+	assert.Equal(t, "add_isbn", status.Pending[0].Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Check(t *testing.T) {
+	testCases := []struct {
+		description   string
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedError string
+	}{
+		{
+			description: "up to date",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+					WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(3, false))
+			},
+		},
+		{
+			description: "ahead of the known migrations",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+					WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(4, false))
+			},
+		},
+		{
+			description: "behind",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+					WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(2, false))
+			},
+			expectedError: "migrations are pending: database is at 2, expected 3",
+		},
+		{
+			description: "never migrated",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+					WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}))
+			},
+			expectedError: "migrations are pending: database is at 0, expected 3",
+		},
+		{
+			description: "dirty",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+					WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(3, true))
+			},
+			expectedError: "database is dirty at version 3",
+		},
+		{
+			description: "version table missing",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+					WillReturnError(errors.New(`relation "schema_migrations" does not exist`))
+			},
+			expectedError: `relation "schema_migrations" does not exist`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			testCase.setupMock(mock)
+
+			m, err := New(db, source, hclog.NewNullLogger())
+			assert.NoError(t, err)
+
+			err = m.Check(context.Background())
+			if testCase.expectedError != "" {
+				assert.EqualError(t, err, testCase.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func version(v uint) *uint {
+	return &v
+}
@@ -0,0 +1,10 @@
+CREATE TABLE api_keys(
+                         ID             UUID PRIMARY KEY,
+                         name           TEXT NOT NULL CHECK ( name <> '' ),
+                         prefix         TEXT NOT NULL,
+                         key_hash       TEXT NOT NULL UNIQUE,
+                         scopes         TEXT[] NOT NULL DEFAULT '{}',
+                         expires_at     TIMESTAMPTZ,
+                         last_used_at   TIMESTAMPTZ,
+                         created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+                         revoked_at     TIMESTAMPTZ);
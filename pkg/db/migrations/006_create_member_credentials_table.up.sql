@@ -0,0 +1,20 @@
+CREATE TABLE member_credentials(
+                                   member_id      UUID PRIMARY KEY REFERENCES members(ID) ON DELETE CASCADE,
+                                   login          TEXT NOT NULL UNIQUE CHECK ( login <> '' ),
+                                   password_hash  TEXT NOT NULL,
+                                   role           TEXT NOT NULL DEFAULT 'member' CHECK ( role IN ('admin', 'librarian', 'member') ),
+                                   updated_at     TIMESTAMPTZ NOT NULL DEFAULT now());
+
+CREATE TABLE refresh_tokens(
+                               ID             UUID PRIMARY KEY,
+                               member_id      UUID NOT NULL REFERENCES members(ID) ON DELETE CASCADE,
+                               token_hash     TEXT NOT NULL UNIQUE,
+                               expires_at     TIMESTAMPTZ NOT NULL,
+                               created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+                               revoked_at     TIMESTAMPTZ);
+
+CREATE TABLE password_resets(
+                                token_hash     TEXT PRIMARY KEY,
+                                member_id      UUID NOT NULL REFERENCES members(ID) ON DELETE CASCADE,
+                                expires_at     TIMESTAMPTZ NOT NULL,
+                                used_at        TIMESTAMPTZ);
@@ -0,0 +1,11 @@
+DROP INDEX members_email_key;
+
+ALTER TABLE members
+    DROP COLUMN email,
+    DROP COLUMN phone,
+    DROP COLUMN address,
+    DROP COLUMN date_of_birth,
+    DROP COLUMN card_number,
+    DROP COLUMN registered_at,
+    DROP COLUMN expires_at,
+    DROP COLUMN status;
@@ -0,0 +1,26 @@
+ALTER TABLE members
+    ADD COLUMN email          TEXT CHECK ( email <> '' ),
+    ADD COLUMN phone          TEXT,
+    ADD COLUMN address        TEXT,
+    ADD COLUMN date_of_birth  DATE,
+    ADD COLUMN card_number    TEXT UNIQUE CHECK ( card_number ~ '^[0-9]{14}$' ),
+    ADD COLUMN registered_at  DATE NOT NULL DEFAULT CURRENT_DATE,
+    ADD COLUMN expires_at     DATE NOT NULL DEFAULT CURRENT_DATE + INTERVAL '1 year',
+    ADD COLUMN status         TEXT NOT NULL DEFAULT 'active' CHECK ( status IN ('active', 'suspended', 'expired') );
+
+CREATE UNIQUE INDEX members_email_key ON members (lower(email));
+
+-- Existing members get sequential card numbers with a Luhn check digit,
+-- matching the 29 prefix the API uses when it issues new cards.
+CREATE FUNCTION luhn_check_digit(payload TEXT) RETURNS TEXT AS $$
+SELECT ((10 - SUM(CASE WHEN i % 2 = 1 THEN (d * 2) / 10 + (d * 2) % 10 ELSE d END) % 10) % 10)::TEXT
+FROM (SELECT i, substr(reverse(payload), i, 1)::INT AS d FROM generate_series(1, length(payload)) AS i) AS digits
+$$ LANGUAGE SQL IMMUTABLE;
+
+UPDATE members SET card_number = numbered.payload || luhn_check_digit(numbered.payload)
+FROM (SELECT ID, '29' || lpad((row_number() OVER (ORDER BY ID))::TEXT, 11, '0') AS payload FROM members) AS numbered
+WHERE members.ID = numbered.ID;
+
+DROP FUNCTION luhn_check_digit(TEXT);
+
+ALTER TABLE members ALTER COLUMN card_number SET NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE members
+    DROP COLUMN suspension_reason,
+    DROP COLUMN suspended_until;
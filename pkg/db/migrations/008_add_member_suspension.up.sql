@@ -0,0 +1,3 @@
+ALTER TABLE members
+    ADD COLUMN suspension_reason  TEXT,
+    ADD COLUMN suspended_until    DATE;
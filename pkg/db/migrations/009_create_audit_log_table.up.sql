@@ -0,0 +1,22 @@
+CREATE TABLE audit_log(
+                          ID           BIGSERIAL PRIMARY KEY,
+                          actor        TEXT NOT NULL,
+                          action       TEXT NOT NULL CHECK ( action IN ('create', 'update', 'delete') ),
+                          entity_type  TEXT NOT NULL,
+                          entity_id    TEXT NOT NULL,
+                          changes      JSONB NOT NULL DEFAULT '{}',
+                          request_id   TEXT,
+                          created_at   TIMESTAMPTZ NOT NULL DEFAULT now());
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity_type, entity_id);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+CREATE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
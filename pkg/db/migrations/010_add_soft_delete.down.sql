@@ -0,0 +1,14 @@
+ALTER TABLE audit_log DROP CONSTRAINT audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check
+    CHECK ( action IN ('create', 'update', 'delete') ) NOT VALID;
+
+DROP INDEX members_email_key;
+CREATE UNIQUE INDEX members_email_key ON members (lower(email));
+
+DROP INDEX members_deleted_at_idx;
+DROP INDEX books_deleted_at_idx;
+DROP INDEX authors_deleted_at_idx;
+
+ALTER TABLE members DROP COLUMN deleted_at;
+ALTER TABLE books DROP COLUMN deleted_at;
+ALTER TABLE authors DROP COLUMN deleted_at;
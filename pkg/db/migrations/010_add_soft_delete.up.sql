@@ -0,0 +1,16 @@
+ALTER TABLE authors ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE books ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE members ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX authors_deleted_at_idx ON authors (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX books_deleted_at_idx ON books (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX members_deleted_at_idx ON members (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- A deleted member keeps its row until it is purged, so its email must not
+-- block a new registration.
+DROP INDEX members_email_key;
+CREATE UNIQUE INDEX members_email_key ON members (lower(email)) WHERE deleted_at IS NULL;
+
+ALTER TABLE audit_log DROP CONSTRAINT audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check
+    CHECK ( action IN ('create', 'update', 'delete', 'restore', 'purge') );
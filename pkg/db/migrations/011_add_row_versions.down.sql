@@ -0,0 +1,8 @@
+DROP TRIGGER members_bump_version ON members;
+DROP TRIGGER books_bump_version ON books;
+DROP TRIGGER authors_bump_version ON authors;
+DROP FUNCTION bump_version();
+
+ALTER TABLE members DROP COLUMN version;
+ALTER TABLE books DROP COLUMN version;
+ALTER TABLE authors DROP COLUMN version;
@@ -0,0 +1,19 @@
+ALTER TABLE authors ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE books ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE members ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- Every change to a row moves it to a new version, including the ones made
+-- by sweeps and soft deletes, so an ETag never outlives the state it names.
+CREATE FUNCTION bump_version() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER authors_bump_version BEFORE UPDATE ON authors
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
+CREATE TRIGGER books_bump_version BEFORE UPDATE ON books
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
+CREATE TRIGGER members_bump_version BEFORE UPDATE ON members
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
@@ -0,0 +1,12 @@
+CREATE TABLE idempotency_keys(
+                                 owner          TEXT NOT NULL,
+                                 key            TEXT NOT NULL CHECK ( key <> '' ),
+                                 request_hash   TEXT NOT NULL,
+                                 status_code    INTEGER,
+                                 content_type   TEXT,
+                                 body           BYTEA,
+                                 created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+                                 expires_at     TIMESTAMPTZ NOT NULL,
+                                 PRIMARY KEY (owner, key));
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,8 @@
+// Package migrations holds the database schema as numbered up and down SQL
+// files, embedded into the binary.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -1,21 +1,136 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/XSAM/otelsql"
+	"github.com/hashicorp/go-hclog"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
 )
 
-func Connect(dataSourceName string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", dataSourceName)
+const maxConnectBackoff = 30 * time.Second
+
+type Options struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// StatementTimeout makes the server cancel statements that run longer.
+	// Zero leaves the server default in place.
+	StatementTimeout time.Duration
+	// ConnectAttempts is how often the first ping is tried before giving up.
+	// The wait between attempts starts at ConnectBackoff and doubles.
+	ConnectAttempts int
+	ConnectBackoff  time.Duration
+}
+
+// Connect opens a connection pool and waits until the database answers, so
+// the service can start while the database is still coming up. Every query
+// becomes a span under the one in its context, with literals stripped from
+// the recorded SQL.
+func Connect(ctx context.Context, dataSourceName string, options Options, logger hclog.Logger) (*sql.DB, error) {
+	dsn, err := dataSource(dataSourceName, options.StatementTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := otelsql.Open("postgres", dsn,
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableQuery:         true,
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+		}),
+		otelsql.WithAttributesGetter(func(ctx context.Context, method otelsql.Method, query string, args []driver.NamedValue) []attribute.KeyValue {
+			if query == "" {
+				return nil
+			}
+
+			return []attribute.KeyValue{semconv.DBQueryText(sanitize(query))}
+		}))
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.Ping()
+	db.SetMaxOpenConns(options.MaxOpenConns)
+	db.SetMaxIdleConns(options.MaxIdleConns)
+	db.SetConnMaxLifetime(options.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(options.ConnMaxIdleTime)
+
+	err = ping(ctx, db, options, logger)
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
 
 	return db, nil
 }
+
+func ping(ctx context.Context, db *sql.DB, options Options, logger hclog.Logger) error {
+	backoff := options.ConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= options.ConnectAttempts {
+			return fmt.Errorf("database unreachable after %d attempts: %w", attempt, err)
+		}
+
+		logger.Warn("database not reachable, retrying", "attempt", attempt, "backoff", backoff, "error", err.Error())
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+// dataSource adds the statement timeout to the connection string. lib/pq
+// sends settings it does not know itself to the server as run-time
+// parameters. URLs are turned into the key=value form first so that the
+// setting can be appended.
+func dataSource(dataSourceName string, statementTimeout time.Duration) (string, error) {
+	if statementTimeout <= 0 {
+		return dataSourceName, nil
+	}
+
+	if strings.HasPrefix(dataSourceName, "postgres://") || strings.HasPrefix(dataSourceName, "postgresql://") {
+		converted, err := pq.ParseURL(dataSourceName)
+		if err != nil {
+			return "", err
+		}
+
+		dataSourceName = converted
+	}
+
+	return fmt.Sprintf("%s statement_timeout=%d", dataSourceName, statementTimeout.Milliseconds()), nil
+}
+
+var (
+	stringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numericLiteral = regexp.MustCompile(`([^$\w.])\d+(?:\.\d+)?\b`)
+	whitespace     = regexp.MustCompile(`\s+`)
+)
+
+// sanitize replaces string and number literals with ? so that the SQL in
+// spans carries no data. Placeholders like $1 are kept.
+func sanitize(query string) string {
+	query = stringLiteral.ReplaceAllString(query, "?")
+	query = numericLiteral.ReplaceAllString(query, "$1?")
+
+	return strings.TrimSpace(whitespace.ReplaceAllString(query, " "))
+}
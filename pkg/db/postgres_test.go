@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataSource(t *testing.T) {
+	testCases := []struct {
+		description      string
+		dataSourceName   string
+		statementTimeout time.Duration
+		expected         string
+	}{
+		{
+			description:    "no timeout",
+			dataSourceName: "host=db user=Dana dbname=library-db",
+			expected:       "host=db user=Dana dbname=library-db",
+		},
+		{
+			description:      "key value form",
+			dataSourceName:   "host=db user=Dana dbname=library-db",
+			statementTimeout: 5 * time.Second,
+			expected:         "host=db user=Dana dbname=library-db statement_timeout=5000",
+		},
+		{
+			description:      "url form",
+			dataSourceName:   "postgres://Dana@db:5432/library-db",
+			statementTimeout: 1500 * time.Millisecond,
+			expected:         "dbname='library-db' host='db' port='5432' user='Dana' statement_timeout=1500",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			actual, err := dataSource(testCase.dataSourceName, testCase.statementTimeout)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, actual)
+		})
+	}
+}
+
+func TestPing(t *testing.T) {
+	unreachable := errors.New("connection refused")
+	testCases := []struct {
+		description   string
+		failures      int
+		attempts      int
+		expectedError string
+	}{
+		{
+			description: "reachable at once",
+			attempts:    3,
+		},
+		{
+			description: "reachable after retries",
+			failures:    2,
+			attempts:    3,
+		},
+		{
+			description:   "gives up",
+			failures:      3,
+			attempts:      3,
+			expectedError: "database unreachable after 3 attempts: connection refused",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+			assert.NoError(t, err)
+			defer db.Close()
+
+			for i := 0; i < testCase.failures; i++ {
+				mock.ExpectPing().WillReturnError(unreachable)
+			}
+			if testCase.failures < testCase.attempts {
+				mock.ExpectPing()
+			}
+
+			options := Options{ConnectAttempts: testCase.attempts, ConnectBackoff: time.Millisecond}
+			err = ping(context.Background(), db, options, hclog.NewNullLogger())
+			if testCase.expectedError != "" {
+				assert.EqualError(t, err, testCase.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	testCases := []struct {
+		description string
+		query       string
+		expected    string
+	}{
+		{
+			description: "placeholders are kept",
+			query: `SELECT id, title FROM books
+						WHERE authors_id = $1 AND deleted_at IS NULL LIMIT $2`,
+			expected: "SELECT id, title FROM books WHERE authors_id = $1 AND deleted_at IS NULL LIMIT $2",
+		},
+		{
+			description: "literals are replaced",
+			query:       `UPDATE members SET status = 'suspended', note = 'it''s overdue' WHERE expires_at < CURRENT_DATE - 30 AND fine > 2.50`,
+			expected:    "UPDATE members SET status = ?, note = ? WHERE expires_at < CURRENT_DATE - ? AND fine > ?",
+		},
+		{
+			description: "identifiers with digits are kept",
+			query:       "SELECT pg_advisory_lock(4723119058), md5(title) FROM schema_migrations LIMIT 1",
+			expected:    "SELECT pg_advisory_lock(?), md5(title) FROM schema_migrations LIMIT ?",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, sanitize(testCase.query))
+		})
+	}
+}
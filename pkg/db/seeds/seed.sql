@@ -0,0 +1,74 @@
+-- Sample catalogue and members for local development and load tests.
+-- Seeding is safe to repeat: rows that already exist are left alone.
+
+INSERT INTO authors (ID, full_name, nick_name, specialization) VALUES
+    ('4ce0ddc1-ed52-4173-8e82-e32926ddff2e', 'Stephen King', 'The King of Horror', 'Horror Fiction'),
+    ('4656a695-fb18-4929-b37c-a6c32a5280e8', 'J.K. Rowling', 'Jo', 'Fantasy Fiction'),
+    ('d23bdad0-0d90-47b2-b202-8fa6eea08c80', 'Isaac Asimov', 'The Good Doctor', 'Science Fiction'),
+    ('d5345aed-3533-4ea2-a94e-50722589d956', 'Agatha Christie', 'Queen of Mystery', 'Mystery Fiction'),
+    ('37a1cf27-51c6-4a04-9018-6b05e6a1dd46', 'Ernest Hemingway', 'Papa', 'Literary Fiction')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO books (ID, authors_id, title, genre, ISBN) VALUES
+    ('e11f8107-880b-49c2-85b2-c780e7929978', (SELECT ID FROM authors WHERE full_name = 'Stephen King'), 'The Shining', 'Horror', '978-0-385-12167-5'),
+    ('82a531a6-2c7e-484a-b2e4-95e75519c8b7', (SELECT ID FROM authors WHERE full_name = 'Stephen King'), 'IT', 'Horror', '978-0-670-81302-8'),
+    ('5bc6ca48-ce09-45b7-8429-52818b755a6a', (SELECT ID FROM authors WHERE full_name = 'Stephen King'), 'Carrie', 'Horror', '978-0-385-08695-0'),
+    ('a7902a75-75c0-4268-b6a6-c7a9e1641c94', (SELECT ID FROM authors WHERE full_name = 'Stephen King'), 'The Stand', 'Horror', '978-0-385-12168-2'),
+    ('a6240bfd-7029-4e53-adc1-ea0f08505bb6', (SELECT ID FROM authors WHERE full_name = 'Stephen King'), 'Pet Sematary', 'Horror', '978-0-385-18244-7'),
+    ('34e640e0-8c38-4253-bb7d-e17643879d0d', (SELECT ID FROM authors WHERE full_name = 'Stephen King'), 'Misery', 'Horror', '978-0-670-81364-6'),
+    ('b013cf2b-beb8-4643-b644-35b41e2f9842', (SELECT ID FROM authors WHERE full_name = 'J.K. Rowling'), 'Harry Potter and the Philosopher''s Stone', 'Fantasy', '978-0-7475-3269-9'),
+    ('707883ba-c4be-4c8c-b9ab-032566403817', (SELECT ID FROM authors WHERE full_name = 'J.K. Rowling'), 'Harry Potter and the Chamber of Secrets', 'Fantasy', '978-0-7475-3849-3'),
+    ('beb70174-0d62-4605-9ff8-136846613666', (SELECT ID FROM authors WHERE full_name = 'J.K. Rowling'), 'Harry Potter and the Prisoner of Azkaban', 'Fantasy', '978-0-7475-4215-5'),
+    ('30d40bf9-b076-49a0-843f-441371d3e201', (SELECT ID FROM authors WHERE full_name = 'J.K. Rowling'), 'Harry Potter and the Goblet of Fire', 'Fantasy', '978-0-7475-4624-5'),
+    ('64b7903c-7f4b-4e0d-9c70-a65b631135ba', (SELECT ID FROM authors WHERE full_name = 'J.K. Rowling'), 'Harry Potter and the Order of the Phoenix', 'Fantasy', '978-0-7475-5100-3'),
+    ('54326a9f-4659-4c91-881c-f3b366a616e9', (SELECT ID FROM authors WHERE full_name = 'J.K. Rowling'), 'Harry Potter and the Half-Blood Prince', 'Fantasy', '978-0-7475-8108-6'),
+    ('cd16cd81-bb96-42d5-acb5-8e17c786e3c1', (SELECT ID FROM authors WHERE full_name = 'Isaac Asimov'), 'Foundation', 'Science Fiction', '978-0-553-29335-0'),
+    ('41eb881f-9b89-4f48-a17a-7212f27e06a6', (SELECT ID FROM authors WHERE full_name = 'Isaac Asimov'), 'Foundation and Empire', 'Science Fiction', '978-0-553-29337-4'),
+    ('fe70b5ef-237d-4ec5-85b3-a088a181c41b', (SELECT ID FROM authors WHERE full_name = 'Isaac Asimov'), 'Second Foundation', 'Science Fiction', '978-0-553-29336-7'),
+    ('0f6e9a85-68b7-4ce1-9124-eb629d2687f0', (SELECT ID FROM authors WHERE full_name = 'Isaac Asimov'), 'I, Robot', 'Science Fiction', '978-0-553-29438-8'),
+    ('d28179dd-8000-4b35-89c4-b3fa69f11e90', (SELECT ID FROM authors WHERE full_name = 'Isaac Asimov'), 'The Caves of Steel', 'Science Fiction', '978-0-553-29340-4'),
+    ('f9eceed5-c1ee-4691-b8fd-1316404d2355', (SELECT ID FROM authors WHERE full_name = 'Isaac Asimov'), 'The Naked Sun', 'Science Fiction', '978-0-553-29339-8'),
+    ('ab041e1c-8c7f-48c4-9c4b-5ba45f0bd418', (SELECT ID FROM authors WHERE full_name = 'Agatha Christie'), 'Murder on the Orient Express', 'Mystery', '978-0-06-207348-4'),
+    ('8d22c47f-5456-4c96-b01d-423216edb985', (SELECT ID FROM authors WHERE full_name = 'Agatha Christie'), 'Death on the Nile', 'Mystery', '978-0-06-207349-1'),
+    ('c5d02179-ff9e-47e9-b604-d164d2715de0', (SELECT ID FROM authors WHERE full_name = 'Agatha Christie'), 'The Murder of Roger Ackroyd', 'Mystery', '978-0-06-207350-7'),
+    ('19461482-18e4-4a33-bce8-d72935237897', (SELECT ID FROM authors WHERE full_name = 'Agatha Christie'), 'And Then There Were None', 'Mystery', '978-0-06-207347-7'),
+    ('08d9872d-31a7-4441-959f-ef93a404f561', (SELECT ID FROM authors WHERE full_name = 'Agatha Christie'), 'The ABC Murders', 'Mystery', '978-0-06-207351-4'),
+    ('b5acce70-de9f-4476-b8ee-f76af8a6b7f5', (SELECT ID FROM authors WHERE full_name = 'Agatha Christie'), 'Murder at the Vicarage', 'Mystery', '978-0-06-207352-1'),
+    ('180628ef-689a-4083-a195-15e5cdd6a50b', (SELECT ID FROM authors WHERE full_name = 'Ernest Hemingway'), 'The Old Man and the Sea', 'Literary Fiction', '978-0-684-80122-3'),
+    ('81155a22-0617-40c1-80c4-edb9aeacc9a7', (SELECT ID FROM authors WHERE full_name = 'Ernest Hemingway'), 'For Whom the Bell Tolls', 'Literary Fiction', '978-0-684-80335-7'),
+    ('b1eb6da7-2dfc-4078-91ea-c40d09d6ae79', (SELECT ID FROM authors WHERE full_name = 'Ernest Hemingway'), 'A Farewell to Arms', 'Literary Fiction', '978-0-684-80146-9'),
+    ('bac447be-63bd-4c16-bec0-0ee2f15bb99b', (SELECT ID FROM authors WHERE full_name = 'Ernest Hemingway'), 'The Sun Also Rises', 'Literary Fiction', '978-0-684-80071-4'),
+    ('5ccfff3d-3e37-4322-88dd-c664653448be', (SELECT ID FROM authors WHERE full_name = 'Ernest Hemingway'), 'A Moveable Feast', 'Literary Fiction', '978-0-684-82499-4'),
+    ('a3bf6a38-b115-448e-99e8-f21e8baa57c6', (SELECT ID FROM authors WHERE full_name = 'Ernest Hemingway'), 'The Garden of Eden', 'Literary Fiction', '978-0-684-80871-0')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO members (ID, full_name, card_number) VALUES
+    ('5d574a92-4b78-46eb-8ab0-02709b710b15', 'John Smith', '29000000000049'),
+    ('56013726-9dd0-436a-8722-f5e5a9896dc6', 'Emily Johnson', '29000000000031'),
+    ('0d3a7572-eaa6-4e74-904f-30c0f2842981', 'Michael Brown', '29000000000015'),
+    ('2b3692bc-07f0-4748-9847-13dc26409066', 'Sarah Williams', '29000000000023'),
+    ('80eb37ea-d1ba-4906-bd12-1f84a29b22a0', 'David Martinez', '29000000000056')
+ON CONFLICT DO NOTHING;
+
+-- Loans have no key of their own, so existing ones are skipped explicitly.
+INSERT INTO borrowed_books (member_id, book_id)
+SELECT members.ID, books.ID
+FROM (VALUES
+    ('John Smith', 'The Shining'),
+    ('John Smith', 'IT'),
+    ('John Smith', 'Foundation'),
+    ('Emily Johnson', 'Harry Potter and the Philosopher''s Stone'),
+    ('Emily Johnson', 'Harry Potter and the Chamber of Secrets'),
+    ('Emily Johnson', 'Murder on the Orient Express'),
+    ('Michael Brown', 'The Old Man and the Sea'),
+    ('Michael Brown', 'A Farewell to Arms'),
+    ('Sarah Williams', 'I, Robot'),
+    ('Sarah Williams', 'And Then There Were None'),
+    ('Sarah Williams', 'The Sun Also Rises'),
+    ('David Martinez', 'Death on the Nile'),
+    ('David Martinez', 'Carrie')
+) AS loans (member_name, book_title)
+         JOIN members ON members.full_name = loans.member_name
+         JOIN books ON books.title = loans.book_title
+WHERE NOT EXISTS (SELECT 1 FROM borrowed_books
+                  WHERE borrowed_books.member_id = members.ID AND borrowed_books.book_id = books.ID);
@@ -0,0 +1,8 @@
+// Package seeds holds optional sample data that is never part of a schema
+// migration.
+package seeds
+
+import _ "embed"
+
+//go:embed seed.sql
+var SQL string
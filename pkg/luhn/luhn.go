@@ -0,0 +1,54 @@
+package luhn
+
+import "errors"
+
+var ErrNotNumeric = errors.New("luhn: input must contain only digits")
+
+// CheckDigit returns the digit that makes payload+digit pass the Luhn check.
+func CheckDigit(payload string) (byte, error) {
+	sum, err := sum(payload, true)
+	if err != nil {
+		return 0, err
+	}
+
+	return byte('0' + (10-sum%10)%10), nil
+}
+
+func Valid(number string) bool {
+	if len(number) < 2 {
+		return false
+	}
+
+	sum, err := sum(number, false)
+	if err != nil {
+		return false
+	}
+
+	return sum%10 == 0
+}
+
+// sum walks the digits from the right, doubling every second one. When the
+// check digit is still missing the rightmost payload digit is doubled.
+func sum(digits string, doubleFirst bool) (int, error) {
+	total := 0
+	double := doubleFirst
+
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if d < 0 || d > 9 {
+			return 0, ErrNotNumeric
+		}
+
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		total += d
+		double = !double
+	}
+
+	return total, nil
+}
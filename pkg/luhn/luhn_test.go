@@ -0,0 +1,47 @@
+package luhn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDigit(t *testing.T) {
+	testCases := []struct {
+		description   string
+		payload       string
+		expected      byte
+		expectedError error
+	}{
+		{
+			description: "reference number",
+			payload:     "7992739871",
+			expected:    '3',
+		},
+		{
+			description: "zero check digit",
+			payload:     "2900000000000",
+			expected:    '7',
+		},
+		{
+			description:   "not numeric",
+			payload:       "29-1",
+			expectedError: ErrNotNumeric,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			actual, err := CheckDigit(testCase.payload)
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expected, actual)
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid("79927398713"))
+	assert.False(t, Valid("79927398710"))
+	assert.False(t, Valid("7992739871a"))
+	assert.False(t, Valid("7"))
+}
@@ -0,0 +1,93 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are exported over
+// OTLP/HTTP, and trace context travels in W3C traceparent headers.
+package tracing
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/hashicorp/go-hclog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const ServiceName = "library-api"
+
+// defaultPath is where OTLP/HTTP collectors take traces.
+const defaultPath = "/v1/traces"
+
+type Options struct {
+	// Endpoint is the URL of an OTLP/HTTP collector. Empty turns tracing off.
+	Endpoint string
+	// SampleRatio is the share of new traces that are recorded. Requests
+	// that arrive with a sampled trace are always recorded.
+	SampleRatio float64
+}
+
+// Setup installs the global propagator and, with an endpoint, a tracer
+// provider exporting to it. The returned function flushes and stops the
+// exporter.
+func Setup(ctx context.Context, options Options) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if options.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	endpoint, err := url.Parse(options.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoint.Path == "" {
+		endpoint.Path = defaultPath
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))))
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Logger adds the trace and span IDs of the span in ctx to logger, so that
+// log lines can be found from a trace and the other way round.
+func Logger(ctx context.Context, logger hclog.Logger) hclog.Logger {
+	span := trace.SpanContextFromContext(ctx)
+	if !span.IsValid() {
+		return logger
+	}
+
+	return logger.With("trace_id", span.TraceID().String(), "span_id", span.SpanID().String())
+}
+
+// TraceID returns the trace ID of the span in ctx, or an empty string outside
+// a span.
+func TraceID(ctx context.Context) string {
+	span := trace.SpanContextFromContext(ctx)
+	if !span.IsValid() {
+		return ""
+	}
+
+	return span.TraceID().String()
+}
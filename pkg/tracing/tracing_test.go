@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLogger(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	span := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled})
+
+	testCases := []struct {
+		description string
+		ctx         context.Context
+		expectedIDs bool
+	}{
+		{
+			description: "inside a span",
+			ctx:         trace.ContextWithSpanContext(context.Background(), span),
+			expectedIDs: true,
+		},
+		{
+			description: "outside a span",
+			ctx:         context.Background(),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			var output bytes.Buffer
+			logger := hclog.New(&hclog.LoggerOptions{Output: &output, JSONFormat: true})
+
+			Logger(testCase.ctx, logger).Info("book not found")
+
+			if testCase.expectedIDs {
+				assert.Contains(t, output.String(), `"span_id":"00f067aa0ba902b7","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+			} else {
+				assert.NotContains(t, output.String(), "trace_id")
+			}
+		})
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	span := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", TraceID(trace.ContextWithSpanContext(context.Background(), span)))
+	assert.Empty(t, TraceID(context.Background()))
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Options{})
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}